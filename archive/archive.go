@@ -0,0 +1,169 @@
+// Package archive reads files from archives that bundle multiple files, like
+// zip & tar, presenting a uniform interface for listing & opening archive
+// members in each format
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+const (
+	// FmtNone is a sentinel for data that isn't archived
+	FmtNone Format = ""
+	// FmtZip zip archives https://en.wikipedia.org/wiki/ZIP_(file_format)
+	FmtZip Format = "zip"
+	// FmtTar tape archives https://en.wikipedia.org/wiki/Tar_(computing)
+	FmtTar Format = "tar"
+)
+
+// ErrMemberNotFound indicates an archive doesn't contain a requested member
+var ErrMemberNotFound = errors.New("archive member not found")
+
+// Format represents a type of archive
+type Format string
+
+// String implements the stringer interface
+func (f Format) String() string {
+	return string(f)
+}
+
+// SupportedFormats indexes supported formats in a map for lookups
+var SupportedFormats = map[Format]struct{}{
+	FmtZip: {},
+	FmtTar: {},
+}
+
+// ParseFormat interprets a string into a supported archive format
+// errors when provided the empty string ("not archived" format)
+func ParseFormat(s string) (Format, error) {
+	f, ok := map[string]Format{
+		"zip": FmtZip,
+		"tar": FmtTar,
+	}[s]
+
+	if !ok {
+		return f, fmt.Errorf("invalid archive format %q", s)
+	}
+	if _, ok := SupportedFormats[f]; !ok {
+		return FmtNone, fmt.Errorf("unsupported archive format: %q", s)
+	}
+	return f, nil
+}
+
+// Member describes a file in an archive
+type Member struct {
+	// Name is the slash-separated path of the file within the archive
+	Name string
+	// Size is the uncompressed size of the file in bytes
+	Size int64
+}
+
+// Members lists the regular files in an archive, in archive order.
+// Directories, links & other special files are omitted
+func Members(archiveFormat string, r io.Reader) ([]Member, error) {
+	f, err := ParseFormat(archiveFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []Member
+	switch f {
+	case FmtZip:
+		zr, err := newZipReader(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if zf.Mode().IsRegular() {
+				members = append(members, Member{Name: cleanName(zf.Name), Size: int64(zf.UncompressedSize64)})
+			}
+		}
+		return members, nil
+	case FmtTar:
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return members, nil
+			} else if err != nil {
+				return nil, err
+			}
+			if hdr.FileInfo().Mode().IsRegular() {
+				members = append(members, Member{Name: cleanName(hdr.Name), Size: hdr.Size})
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no available reader for %q archives", f)
+}
+
+// OpenMember reads a single file from an archive. zip archives are read into
+// memory unless r is a *bytes.Reader, *strings.Reader, or other io.ReaderAt
+// that reports it's size. tar archives are streamed, reading r up to the end
+// of the member. callers must .Close() the reader
+func OpenMember(archiveFormat string, r io.Reader, name string) (io.ReadCloser, error) {
+	f, err := ParseFormat(archiveFormat)
+	if err != nil {
+		return nil, err
+	}
+	name = cleanName(name)
+
+	switch f {
+	case FmtZip:
+		zr, err := newZipReader(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if cleanName(zf.Name) == name && zf.Mode().IsRegular() {
+				return zf.Open()
+			}
+		}
+		return nil, fmt.Errorf("%w: %q", ErrMemberNotFound, name)
+	case FmtTar:
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil, fmt.Errorf("%w: %q", ErrMemberNotFound, name)
+			} else if err != nil {
+				return nil, err
+			}
+			if hdr.FileInfo().Mode().IsRegular() && cleanName(hdr.Name) == name {
+				return ioutil.NopCloser(tr), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no available reader for %q archives", f)
+}
+
+// newZipReader opens a zip archive, which requires random access to the
+// archive's central directory at the end of the file
+func newZipReader(r io.Reader) (*zip.Reader, error) {
+	if ra, ok := r.(interface {
+		io.ReaderAt
+		Size() int64
+	}); ok {
+		return zip.NewReader(ra, ra.Size())
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// cleanName normalizes a member path, removing any leading "./" or "/"
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,161 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testFiles = []struct {
+	name, content string
+}{
+	{"README.md", "# weather stations\n"},
+	{"data/stations.csv", "id,name\n1,central park\n"},
+	{"./data/readings.json", `[{"id":1,"temp":21.5}]`},
+}
+
+func testZip(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	if _, err := zw.Create("data/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range testFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testTar(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range testFiles {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"zip", "tar"} {
+		f, err := ParseFormat(s)
+		if err != nil {
+			t.Errorf("unexpected error for format %q: %s", s, err)
+		}
+		if _, ok := SupportedFormats[f]; !ok {
+			t.Errorf("expected %q to be a supported format", s)
+		}
+	}
+
+	for _, s := range []string{"", "gzip", "rar"} {
+		if _, err := ParseFormat(s); err == nil {
+			t.Errorf("expected format to error: %s, got nil", s)
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	expect := []Member{
+		{Name: "README.md", Size: 19},
+		{Name: "data/stations.csv", Size: 23},
+		{Name: "data/readings.json", Size: 22},
+	}
+
+	archives := map[string][]byte{
+		"zip": testZip(t),
+		"tar": testTar(t),
+	}
+	for format, data := range archives {
+		t.Run(format, func(t *testing.T) {
+			got, err := Members(format, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := Members("rar", bytes.NewReader(nil)); err == nil {
+		t.Errorf("expected invalid format to error")
+	}
+	if _, err := Members("zip", bytes.NewReader([]byte("not a zip"))); err == nil {
+		t.Errorf("expected invalid zip data to error")
+	}
+}
+
+func TestOpenMember(t *testing.T) {
+	archives := map[string][]byte{
+		"zip": testZip(t),
+		"tar": testTar(t),
+	}
+
+	for format, data := range archives {
+		t.Run(format, func(t *testing.T) {
+			for _, name := range []string{"data/readings.json", "./data/readings.json", "/data/readings.json"} {
+				// wrap the reader to check archives that aren't an io.ReaderAt
+				rc, err := OpenMember(format, ioutil.NopCloser(bytes.NewReader(data)), name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := ioutil.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != `[{"id":1,"temp":21.5}]` {
+					t.Errorf("member %q content mismatch. got: %q", name, string(got))
+				}
+			}
+
+			for _, name := range []string{"missing.csv", "data"} {
+				if _, err := OpenMember(format, bytes.NewReader(data), name); !errors.Is(err, ErrMemberNotFound) {
+					t.Errorf("expected opening %q to return ErrMemberNotFound, got: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenMemberExportedZip(t *testing.T) {
+	f, err := os.Open("../testdata/zip/exported.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rc, err := OpenMember("zip", f, "body.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	got, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 24 {
+		t.Errorf("expected 24 bytes of body, got: %d", len(got))
+	}
+}
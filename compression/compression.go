@@ -5,9 +5,16 @@ package compression
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/bits"
 
+	"github.com/andybalholm/brotli"
+	"github.com/dsnet/compress/bzip2"
 	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
 )
 
 const (
@@ -17,6 +24,17 @@ const (
 	FmtZStandard Format = "zst"
 	// FmtGZip GNU zip compression https://www.gnu.org/software/gzip/
 	FmtGZip Format = "gzip"
+	// FmtBZip2 bzip2 compression https://sourceware.org/bzip2/
+	FmtBZip2 Format = "bz2"
+	// FmtXZ xz compression https://tukaani.org/xz/
+	FmtXZ Format = "xz"
+	// FmtLZ4 LZ4 frame compression https://lz4.github.io/lz4/
+	FmtLZ4 Format = "lz4"
+	// FmtSnappy snappy compression using the framing format
+	// https://github.com/google/snappy/blob/master/framing_format.txt
+	FmtSnappy Format = "sz"
+	// FmtBrotli brotli compression https://github.com/google/brotli
+	FmtBrotli Format = "br"
 )
 
 // Format represents a type of byte compression
@@ -31,16 +49,29 @@ func (s Format) String() string {
 var SupportedFormats = map[Format]struct{}{
 	FmtZStandard: {},
 	FmtGZip:      {},
+	FmtBZip2:     {},
+	FmtXZ:        {},
+	FmtLZ4:       {},
+	FmtSnappy:    {},
+	FmtBrotli:    {},
 }
 
 // ParseFormat interprets a string into a supported compression format
 // errors when provided the empty string ("no compression" format)
 func ParseFormat(s string) (f Format, err error) {
 	f, ok := map[string]Format{
-		"gzip": FmtGZip,
-		"gz":   FmtGZip,
-		"zst":  FmtZStandard,
-		"zstd": FmtZStandard, // not a common file ending, but "zstd" is the shorthand name for the library
+		"gzip":   FmtGZip,
+		"gz":     FmtGZip,
+		"zst":    FmtZStandard,
+		"zstd":   FmtZStandard, // not a common file ending, but "zstd" is the shorthand name for the library
+		"bz2":    FmtBZip2,
+		"bzip2":  FmtBZip2,
+		"xz":     FmtXZ,
+		"lz4":    FmtLZ4,
+		"sz":     FmtSnappy,
+		"snappy": FmtSnappy,
+		"br":     FmtBrotli,
+		"brotli": FmtBrotli,
 	}[s]
 
 	if !ok {
@@ -54,19 +85,112 @@ func ParseFormat(s string) (f Format, err error) {
 	return f, nil
 }
 
+// Options tunes compression. Zero values use the default setting of the
+// compression format. Setting an option a format doesn't support is an error
+type Options struct {
+	// Level is the compression level on the scale of the format: -2 to 9 for
+	// gzip, 1-22 for zstd, 1-9 for bzip2 & lz4, 0-11 for brotli
+	Level int
+	// Window is the size of the compression window in bytes. zstd & brotli
+	// windows must be a power of two, lz4 windows are a block size of 64KB,
+	// 256KB, 1MB or 4MB. xz windows set the dictionary capacity
+	Window int
+	// Dictionary is a zstd dictionary, required to decompress data that was
+	// compressed with it
+	Dictionary []byte
+}
+
 // Compressor wraps a given writer with a specified comrpession format
 // callers must Close the writer to fully flush the compressor
 func Compressor(compressionFormat string, w io.Writer) (io.WriteCloser, error) {
+	return CompressorWithOptions(compressionFormat, w, Options{})
+}
+
+// CompressorWithOptions wraps a given writer with a compressor configured by
+// opts. callers must Close the writer to fully flush the compressor
+func CompressorWithOptions(compressionFormat string, w io.Writer, opts Options) (io.WriteCloser, error) {
 	f, err := ParseFormat(compressionFormat)
 	if err != nil {
 		return nil, err
 	}
+	if opts.Dictionary != nil && f != FmtZStandard {
+		return nil, errUnsupportedOption(f, "dictionaries")
+	}
 
 	switch f {
 	case FmtZStandard:
-		return zstd.NewWriter(w)
+		var zopts []zstd.EOption
+		if opts.Level != 0 {
+			if opts.Level < 1 || opts.Level > 22 {
+				return nil, fmt.Errorf("invalid zstd compression level: %d", opts.Level)
+			}
+			zopts = append(zopts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)))
+		}
+		if opts.Window != 0 {
+			zopts = append(zopts, zstd.WithWindowSize(opts.Window))
+		}
+		if opts.Dictionary != nil {
+			zopts = append(zopts, zstd.WithEncoderDict(opts.Dictionary))
+		}
+		return zstd.NewWriter(w, zopts...)
 	case FmtGZip:
+		if opts.Window != 0 {
+			return nil, errUnsupportedOption(f, "windows")
+		}
+		if opts.Level != 0 {
+			return gzip.NewWriterLevel(w, opts.Level)
+		}
 		return gzip.NewWriter(w), nil
+	case FmtBZip2:
+		if opts.Window != 0 {
+			return nil, errUnsupportedOption(f, "windows")
+		}
+		return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: opts.Level})
+	case FmtXZ:
+		if opts.Level != 0 {
+			return nil, errUnsupportedOption(f, "levels")
+		}
+		return xz.WriterConfig{DictCap: opts.Window}.NewWriter(w)
+	case FmtLZ4:
+		var lopts []lz4.Option
+		if opts.Level != 0 {
+			if opts.Level < 1 || opts.Level > 9 {
+				return nil, fmt.Errorf("invalid lz4 compression level: %d", opts.Level)
+			}
+			lopts = append(lopts, lz4.CompressionLevelOption(lz4.CompressionLevel(1<<(7+opts.Level))))
+		}
+		if opts.Window != 0 {
+			lopts = append(lopts, lz4.BlockSizeOption(lz4.BlockSize(opts.Window)))
+		}
+		lw := lz4.NewWriter(w)
+		if err := lw.Apply(lopts...); err != nil {
+			return nil, err
+		}
+		return lw, nil
+	case FmtSnappy:
+		if opts.Level != 0 {
+			return nil, errUnsupportedOption(f, "levels")
+		}
+		if opts.Window != 0 {
+			return nil, errUnsupportedOption(f, "windows")
+		}
+		return s2.NewWriter(w, s2.WriterSnappyCompat()), nil
+	case FmtBrotli:
+		if opts.Level < 0 || opts.Level > 11 {
+			return nil, fmt.Errorf("invalid brotli compression level: %d", opts.Level)
+		}
+		bopts := brotli.WriterOptions{Quality: brotli.DefaultCompression}
+		if opts.Level != 0 {
+			bopts.Quality = opts.Level
+		}
+		if opts.Window != 0 {
+			lgwin := bits.Len(uint(opts.Window)) - 1
+			if opts.Window != 1<<lgwin || lgwin < 10 || lgwin > 24 {
+				return nil, fmt.Errorf("invalid brotli window size: %d", opts.Window)
+			}
+			bopts.LGWin = lgwin
+		}
+		return brotli.NewWriterOptions(w, bopts), nil
 	}
 
 	return nil, fmt.Errorf("no available compressor for %q format", f)
@@ -75,25 +199,57 @@ func Compressor(compressionFormat string, w io.Writer) (io.WriteCloser, error) {
 // Decompressor wraps a reader of compressed data with a decompressor
 // callers must .Close() the reader
 func Decompressor(compressionFormat string, r io.Reader) (io.ReadCloser, error) {
+	return DecompressorWithOptions(compressionFormat, r, Options{})
+}
+
+// DecompressorWithOptions wraps a reader of compressed data with a
+// decompressor. Only the Dictionary option affects decompression, other
+// options are ignored. callers must .Close() the reader
+func DecompressorWithOptions(compressionFormat string, r io.Reader, opts Options) (io.ReadCloser, error) {
 	f, err := ParseFormat(compressionFormat)
 	if err != nil {
 		return nil, err
 	}
+	if opts.Dictionary != nil && f != FmtZStandard {
+		return nil, errUnsupportedOption(f, "dictionaries")
+	}
 
 	switch f {
 	case FmtZStandard:
-		rdr, err := zstd.NewReader(r)
+		var zopts []zstd.DOption
+		if opts.Dictionary != nil {
+			zopts = append(zopts, zstd.WithDecoderDicts(opts.Dictionary))
+		}
+		rdr, err := zstd.NewReader(r, zopts...)
 		if err != nil {
 			return nil, err
 		}
 		return zstdReadCloserShim{rdr}, nil
 	case FmtGZip:
 		return gzip.NewReader(r)
+	case FmtBZip2:
+		return bzip2.NewReader(r, nil)
+	case FmtXZ:
+		rdr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(rdr), nil
+	case FmtLZ4:
+		return ioutil.NopCloser(lz4.NewReader(r)), nil
+	case FmtSnappy:
+		return ioutil.NopCloser(s2.NewReader(r)), nil
+	case FmtBrotli:
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
 	}
 
 	return nil, fmt.Errorf("no available decompressor for %q format", f)
 }
 
+func errUnsupportedOption(f Format, option string) error {
+	return fmt.Errorf("%s compression doesn't support %s", f, option)
+}
+
 // small struct to compensate for zstd's decoder Close() method, which returns
 // no error. This breaks the io.ReadCloser interface. shim in an
 // error function with an error that will never occur
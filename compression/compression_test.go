@@ -9,7 +9,7 @@ import (
 
 func TestParseFormat(t *testing.T) {
 	good := []string{
-		"gz", "gzip", "zstd",
+		"gz", "gzip", "zstd", "bz2", "bzip2", "xz", "lz4", "sz", "snappy", "br", "brotli",
 	}
 
 	for _, s := range good {
@@ -97,3 +97,85 @@ func TestCompressionCycle(t *testing.T) {
 		})
 	}
 }
+
+func TestCompressorWithOptions(t *testing.T) {
+	plainText := strings.Repeat("I am a string destined to go through a tuned compression spin cycle\n", 100)
+
+	good := []struct {
+		format string
+		opts   Options
+	}{
+		{"zst", Options{Level: 19, Window: 1 << 16}},
+		{"gzip", Options{Level: 9}},
+		{"gzip", Options{Level: -2}},
+		{"bz2", Options{Level: 1}},
+		{"xz", Options{Window: 1 << 16}},
+		{"lz4", Options{Level: 9, Window: 64 << 10}},
+		{"br", Options{Level: 11, Window: 1 << 16}},
+	}
+
+	for _, c := range good {
+		t.Run(c.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			comp, err := CompressorWithOptions(c.format, buf, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.Copy(comp, strings.NewReader(plainText)); err != nil {
+				t.Fatal(err)
+			}
+			if err := comp.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			decomp, err := DecompressorWithOptions(c.format, buf, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer decomp.Close()
+
+			result := &bytes.Buffer{}
+			if _, err := io.Copy(result, decomp); err != nil {
+				t.Fatal(err)
+			}
+			if result.String() != plainText {
+				t.Errorf("compression round trip result mismatch")
+			}
+		})
+	}
+
+	bad := []struct {
+		format string
+		opts   Options
+		err    string
+	}{
+		{"zst", Options{Level: 23}, "invalid zstd compression level: 23"},
+		{"gzip", Options{Window: 1024}, "gzip compression doesn't support windows"},
+		{"gzip", Options{Dictionary: []byte("dict")}, "gzip compression doesn't support dictionaries"},
+		{"xz", Options{Level: 6}, "xz compression doesn't support levels"},
+		{"lz4", Options{Level: 10}, "invalid lz4 compression level: 10"},
+		{"sz", Options{Level: 1}, "sz compression doesn't support levels"},
+		{"sz", Options{Window: 1024}, "sz compression doesn't support windows"},
+		{"br", Options{Level: 12}, "invalid brotli compression level: 12"},
+		{"br", Options{Window: 1000}, "invalid brotli window size: 1000"},
+	}
+
+	for _, c := range bad {
+		t.Run(c.err, func(t *testing.T) {
+			_, err := CompressorWithOptions(c.format, &bytes.Buffer{}, c.opts)
+			if err == nil || err.Error() != c.err {
+				t.Errorf("error mismatch. expected: %q, got: %v", c.err, err)
+			}
+		})
+	}
+
+	if _, err := CompressorWithOptions("zst", &bytes.Buffer{}, Options{Window: 1000}); err == nil {
+		t.Errorf("expected invalid zstd window to error")
+	}
+	if _, err := CompressorWithOptions("lz4", &bytes.Buffer{}, Options{Window: 1000}); err == nil {
+		t.Errorf("expected invalid lz4 block size to error")
+	}
+	if _, err := DecompressorWithOptions("br", &bytes.Buffer{}, Options{Dictionary: []byte("dict")}); err == nil {
+		t.Errorf("expected decompressor dictionary for non-zstd format to error")
+	}
+}
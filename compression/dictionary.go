@@ -0,0 +1,91 @@
+package compression
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"sync"
+
+	"github.com/klauspost/compress/dict"
+)
+
+// zstdDictMagic is the magic number zstd dictionaries start with
+var zstdDictMagic = []byte{0x37, 0xa4, 0x30, 0xec}
+
+var (
+	dictionariesLk sync.RWMutex
+	dictionaries   = map[string][]byte{}
+)
+
+// RegisterDictionary makes a zstd dictionary available by reference, for use
+// by structures that name a dictionary in their compression configuration.
+// Registering a reference a second time replaces the dictionary
+func RegisterDictionary(ref string, dictionary []byte) error {
+	if ref == "" {
+		return fmt.Errorf("dictionary reference is required")
+	}
+	if _, err := ZstdDictionaryID(dictionary); err != nil {
+		return err
+	}
+
+	dictionariesLk.Lock()
+	defer dictionariesLk.Unlock()
+	dictionaries[ref] = dictionary
+	return nil
+}
+
+// LookupDictionary gets a registered dictionary by reference
+func LookupDictionary(ref string) ([]byte, bool) {
+	dictionariesLk.RLock()
+	defer dictionariesLk.RUnlock()
+	d, ok := dictionaries[ref]
+	return d, ok
+}
+
+// ZstdDictionaryID reads the ID of a zstd dictionary, erroring if the data
+// isn't a zstd dictionary
+func ZstdDictionaryID(dictionary []byte) (uint32, error) {
+	if len(dictionary) < 8 || string(dictionary[:4]) != string(zstdDictMagic) {
+		return 0, fmt.Errorf("invalid zstd dictionary")
+	}
+	return binary.LittleEndian.Uint32(dictionary[4:8]), nil
+}
+
+// TrainZstdDictionary builds a zstd dictionary of up to size bytes from
+// sample data, typically bodies of earlier versions of a dataset. Data
+// resembling the samples compresses better with the dictionary, which
+// matters most when bodies are small
+func TrainZstdDictionary(samples [][]byte, size int) (d []byte, err error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("training a dictionary requires at least one sample")
+	}
+	if size < 256 {
+		return nil, fmt.Errorf("dictionary size must be at least 256 bytes")
+	}
+
+	// derive the dictionary ID from the samples, keeping clear of the IDs zstd
+	// reserves for registered dictionaries
+	h := crc32.NewIEEE()
+	for _, s := range samples {
+		h.Write(s)
+	}
+	id := 32768 + h.Sum32()%((1<<31)-32768)
+
+	// the dictionary builder panics when samples have too little in common to
+	// build entropy tables from
+	defer func() {
+		if r := recover(); r != nil {
+			d, err = nil, fmt.Errorf("training zstd dictionary: not enough sample data")
+		}
+	}()
+
+	d, err = dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: size,
+		HashBytes:   6,
+		ZstdDictID:  id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("training zstd dictionary: %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,114 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func dailySample(day int) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("station_id,station_name,date,max_temperature_c,min_temperature_c,precipitation_mm\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(buf, "GHCND:USW000%05d,CENTRAL PARK NEW YORK NY US,2021-07-%02d,%d.%d,%d.%d,0.%d\n", 94728+i, day, 25+i%7, i%10, 15+i%5, (i*3)%10, i%4)
+	}
+	return buf.Bytes()
+}
+
+func dailySamples() (samples [][]byte) {
+	for day := 1; day <= 28; day++ {
+		samples = append(samples, dailySample(day))
+	}
+	return samples
+}
+
+func TestTrainZstdDictionary(t *testing.T) {
+	samples := dailySamples()
+
+	d, err := TrainZstdDictionary(samples, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d) > 4096*2 {
+		t.Errorf("dictionary is unexpectedly large: %d bytes", len(d))
+	}
+	id, err := ZstdDictionaryID(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id < 32768 {
+		t.Errorf("expected dictionary ID outside the reserved range, got: %d", id)
+	}
+
+	body := dailySample(30)
+	compress := func(opts Options) []byte {
+		buf := &bytes.Buffer{}
+		w, err := CompressorWithOptions("zstd", buf, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(body)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	plain := compress(Options{})
+	withDict := compress(Options{Dictionary: d})
+	if len(withDict) >= len(plain) {
+		t.Errorf("expected dictionary to improve compression. without: %d bytes, with: %d bytes", len(plain), len(withDict))
+	}
+
+	r, err := DecompressorWithOptions("zstd", bytes.NewReader(withDict), Options{Dictionary: d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, got) {
+		t.Errorf("dictionary round trip mismatch")
+	}
+
+	if _, err := TrainZstdDictionary(nil, 4096); err == nil {
+		t.Errorf("expected training without samples to error")
+	}
+	if _, err := TrainZstdDictionary(samples, 100); err == nil {
+		t.Errorf("expected training a tiny dictionary to error")
+	}
+	if _, err := TrainZstdDictionary(samples[:2], 1024); err == nil {
+		t.Errorf("expected training with too little sample data to error")
+	}
+}
+
+func TestRegisterDictionary(t *testing.T) {
+	d, err := TrainZstdDictionary(dailySamples(), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterDictionary("", d); err == nil {
+		t.Errorf("expected registering without a reference to error")
+	}
+	if err := RegisterDictionary("not_a_dictionary", []byte("plain text")); err == nil {
+		t.Errorf("expected registering invalid dictionary to error")
+	}
+	if _, ok := LookupDictionary("not_a_dictionary"); ok {
+		t.Errorf("expected invalid dictionary to not be registered")
+	}
+
+	if err := RegisterDictionary("test_daily_weather", d); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := LookupDictionary("test_daily_weather")
+	if !ok {
+		t.Fatal("expected registered dictionary to be found")
+	}
+	if !bytes.Equal(d, got) {
+		t.Errorf("registered dictionary mismatch")
+	}
+}
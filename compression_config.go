@@ -0,0 +1,93 @@
+package dataset
+
+import (
+	"fmt"
+)
+
+// CompressionOptions configures compression of a dataset body. Options are
+// stored in a Structure's CompressionConfig field. Zero values use the
+// default settings of the compression format
+type CompressionOptions struct {
+	// Level is the compression level, on the scale of the compression format.
+	// eg: 1-9 for gzip, 1-22 for zstd, 0-11 for brotli
+	Level int `json:"level,omitempty"`
+	// Window is the size of the compression window in bytes
+	Window int `json:"window,omitempty"`
+	// Dictionary references a zstd dictionary used to compress & decompress
+	// data. Dictionaries must be available for reading, see
+	// compression.RegisterDictionary
+	Dictionary string `json:"dictionary,omitempty"`
+}
+
+// NewCompressionOptions creates a CompressionOptions pointer from a map
+func NewCompressionOptions(opts map[string]interface{}) (*CompressionOptions, error) {
+	o := &CompressionOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["level"] != nil {
+		level, err := intConfigOption("level", opts["level"])
+		if err != nil {
+			return nil, err
+		}
+		o.Level = level
+	}
+
+	if opts["window"] != nil {
+		window, err := intConfigOption("window", opts["window"])
+		if err != nil {
+			return nil, err
+		}
+		if window < 0 {
+			return nil, fmt.Errorf("invalid window value: %v", opts["window"])
+		}
+		o.Window = window
+	}
+
+	if opts["dictionary"] != nil {
+		if dict, ok := opts["dictionary"].(string); ok {
+			o.Dictionary = dict
+		} else {
+			return nil, fmt.Errorf("invalid dictionary value: %v", opts["dictionary"])
+		}
+	}
+
+	return o, nil
+}
+
+// Map structures CompressionOptions as a map of string keys to values
+func (o *CompressionOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Level != 0 {
+		opt["level"] = o.Level
+	}
+	if o.Window != 0 {
+		opt["window"] = o.Window
+	}
+	if o.Dictionary != "" {
+		opt["dictionary"] = o.Dictionary
+	}
+	return opt
+}
+
+// intConfigOption reads an integer configuration value, which may be a whole
+// float64 when decoded from JSON
+func intConfigOption(name string, v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if float64(int(n)) != n {
+			return 0, fmt.Errorf("invalid %s value: %v", name, v)
+		}
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("invalid %s value: %v", name, v)
+	}
+}
@@ -0,0 +1,60 @@
+package dataset
+
+import (
+	"testing"
+)
+
+func TestNewCompressionOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *CompressionOptions
+		err  string
+	}{
+		{nil, &CompressionOptions{}, ""},
+		{map[string]interface{}{}, &CompressionOptions{}, ""},
+		{map[string]interface{}{"level": 19}, &CompressionOptions{Level: 19}, ""},
+		{map[string]interface{}{"level": float64(-2)}, &CompressionOptions{Level: -2}, ""},
+		{map[string]interface{}{"window": float64(1 << 20)}, &CompressionOptions{Window: 1 << 20}, ""},
+		{map[string]interface{}{"dictionary": "daily"}, &CompressionOptions{Dictionary: "daily"}, ""},
+		{map[string]interface{}{"level": 9, "window": int64(1024), "dictionary": "daily"}, &CompressionOptions{Level: 9, Window: 1024, Dictionary: "daily"}, ""},
+
+		{map[string]interface{}{"level": "high"}, nil, "invalid level value: high"},
+		{map[string]interface{}{"level": 1.5}, nil, "invalid level value: 1.5"},
+		{map[string]interface{}{"window": -1}, nil, "invalid window value: -1"},
+		{map[string]interface{}{"dictionary": 12}, nil, "invalid dictionary value: 12"},
+	}
+
+	for i, c := range cases {
+		got, err := NewCompressionOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestCompressionOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *CompressionOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&CompressionOptions{}, map[string]interface{}{}},
+		{&CompressionOptions{Level: 3, Window: 1024, Dictionary: "daily"}, map[string]interface{}{"level": 3, "window": 1024, "dictionary": "daily"}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if len(got) != len(c.res) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+		}
+		for key, val := range c.res {
+			if got[key] != val {
+				t.Errorf("case %d, key '%s' expected: '%v' got:'%v'", i, key, val, got[key])
+			}
+		}
+	}
+}
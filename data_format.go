@@ -3,6 +3,8 @@ package dataset
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 )
 
 // ErrUnknownDataFormat is the expected error for
@@ -30,65 +32,69 @@ const (
 	// read more at cbor.io
 	CBORDataFormat
 	// XMLDataFormat specifies eXtensible Markup Language-formatted data
-	// currently not supported.
 	XMLDataFormat
 	// XLSXDataFormat specifies microsoft excel formatted data
 	XLSXDataFormat
+	// ParquetDataFormat specifies Apache Parquet columnar data
+	// https://parquet.apache.org
+	ParquetDataFormat
+	// ArrowDataFormat specifies Apache Arrow IPC columnar data, in either the
+	// IPC stream or IPC file (Feather V2) format
+	// https://arrow.apache.org
+	ArrowDataFormat
+	// AvroDataFormat specifies Apache Avro object container files
+	// https://avro.apache.org
+	AvroDataFormat
+	// SQLiteDataFormat specifies a table or query within a SQLite database
+	// https://sqlite.org
+	SQLiteDataFormat
+	// YAMLDataFormat specifies YAML documents, or streams of documents
+	// https://yaml.org
+	YAMLDataFormat
 )
 
 // SupportedDataFormats gives a slice of data formats that are
 // expected to work with this dataset package. As we work through
 // support for different formats, the last step of providing full
-// support to a format will be an addition to this slice
+// support to a format will be an addition to this slice. Formats added with
+// RegisterDataFormat come after built-in formats
 func SupportedDataFormats() []DataFormat {
-	return []DataFormat{
+	formats := []DataFormat{
 		CBORDataFormat,
 		JSONDataFormat,
 		CSVDataFormat,
 		XLSXDataFormat,
 		NDJSONDataFormat,
+		XMLDataFormat,
+		ParquetDataFormat,
+		ArrowDataFormat,
+		AvroDataFormat,
+		SQLiteDataFormat,
+		YAMLDataFormat,
 	}
+
+	dataFormatsLk.RLock()
+	defer dataFormatsLk.RUnlock()
+	return append(formats, customDataFormats...)
 }
 
 // String implements stringer interface for DataFormat
 func (f DataFormat) String() string {
-	s, ok := map[DataFormat]string{
-		UnknownDataFormat: "",
-		CSVDataFormat:     "csv",
-		JSONDataFormat:    "json",
-		XMLDataFormat:     "xml",
-		XLSXDataFormat:    "xlsx",
-		CBORDataFormat:    "cbor",
-		NDJSONDataFormat:  "ndjson",
-	}[f]
-
-	if !ok {
-		return ""
-	}
-
-	return s
+	dataFormatsLk.RLock()
+	defer dataFormatsLk.RUnlock()
+	return dataFormats[f].Name
 }
 
-// ParseDataFormatString takes a string representation of a data format
-// TODO (b5): trim "." prefix, remove prefixed map keys
+// ParseDataFormatString takes a string representation of a data format. A
+// leading "." is ignored, so file extensions parse as their format
 func ParseDataFormatString(s string) (df DataFormat, err error) {
-	df, ok := map[string]DataFormat{
-		"":        UnknownDataFormat,
-		".csv":    CSVDataFormat,
-		"csv":     CSVDataFormat,
-		".json":   JSONDataFormat,
-		"json":    JSONDataFormat,
-		".xml":    XMLDataFormat,
-		"xml":     XMLDataFormat,
-		".xlsx":   XLSXDataFormat,
-		"xlsx":    XLSXDataFormat,
-		"cbor":    CBORDataFormat,
-		".cbor":   CBORDataFormat,
-		".ndjson": NDJSONDataFormat,
-		"ndjson":  NDJSONDataFormat,
-		".jsonl":  NDJSONDataFormat,
-		"jsonl":   NDJSONDataFormat,
-	}[s]
+	if s == "" {
+		return UnknownDataFormat, nil
+	}
+
+	dataFormatsLk.RLock()
+	defer dataFormatsLk.RUnlock()
+	df, ok := dataFormatNames[strings.TrimPrefix(s, ".")]
 	if !ok {
 		err = fmt.Errorf("invalid data format: `%s`", s)
 		df = UnknownDataFormat
@@ -97,6 +103,137 @@ func ParseDataFormatString(s string) (df DataFormat, err error) {
 	return
 }
 
+// DataFormatDefinition describes a data format to the dataset package
+type DataFormatDefinition struct {
+	// Name is the string representation of the format, eg: "csv"
+	Name string
+	// Aliases are additional strings ParseDataFormatString accepts for the
+	// format, eg: "jsonl" for NDJSON
+	Aliases []string
+	// ParseConfig creates the FormatConfig for the format from a map of
+	// options. nil for formats that have no configuration
+	ParseConfig func(opts map[string]interface{}) (FormatConfig, error)
+	// Tabular is true for formats that require a tabular schema
+	Tabular bool
+}
+
+var (
+	dataFormatsLk     sync.RWMutex
+	dataFormats       = map[DataFormat]DataFormatDefinition{}
+	dataFormatNames   = map[string]DataFormat{}
+	customDataFormats []DataFormat
+)
+
+func init() {
+	builtin := map[DataFormat]DataFormatDefinition{
+		CSVDataFormat: {
+			Name:        "csv",
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewCSVOptions(opts) },
+			Tabular:     true,
+		},
+		JSONDataFormat: {
+			Name:        "json",
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewJSONOptions(opts) },
+		},
+		NDJSONDataFormat: {
+			Name:        "ndjson",
+			Aliases:     []string{"jsonl"},
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewNDJSONOptions(opts) },
+		},
+		CBORDataFormat: {
+			Name:        "cbor",
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewCBOROptions(opts) },
+		},
+		XMLDataFormat: {
+			Name:        "xml",
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewXMLOptions(opts) },
+		},
+		XLSXDataFormat: {
+			Name:        "xlsx",
+			ParseConfig: NewXLSXOptions,
+			Tabular:     true,
+		},
+		ParquetDataFormat: {
+			Name:    "parquet",
+			Tabular: true,
+		},
+		ArrowDataFormat: {
+			Name:        "arrow",
+			Aliases:     []string{"arrows", "feather"},
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewArrowOptions(opts) },
+			Tabular:     true,
+		},
+		AvroDataFormat: {
+			Name:        "avro",
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewAvroOptions(opts) },
+			Tabular:     true,
+		},
+		SQLiteDataFormat: {
+			Name:        "sqlite",
+			Aliases:     []string{"db"},
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewSQLiteOptions(opts) },
+			Tabular:     true,
+		},
+		YAMLDataFormat: {
+			Name:        "yaml",
+			Aliases:     []string{"yml"},
+			ParseConfig: func(opts map[string]interface{}) (FormatConfig, error) { return NewYAMLOptions(opts) },
+		},
+	}
+
+	for f, def := range builtin {
+		if err := registerDataFormat(f, def); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterDataFormat adds a data format to the dataset package, returning the
+// DataFormat value assigned to it. Format names & aliases must be unique.
+// Most callers will want dsio.RegisterFormat, which also registers readers,
+// writers & schema detection for the format
+func RegisterDataFormat(def DataFormatDefinition) (DataFormat, error) {
+	dataFormatsLk.Lock()
+	defer dataFormatsLk.Unlock()
+
+	f := UnknownDataFormat
+	for registered := range dataFormats {
+		if registered > f {
+			f = registered
+		}
+	}
+	f++
+
+	if err := registerDataFormat(f, def); err != nil {
+		return UnknownDataFormat, err
+	}
+	customDataFormats = append(customDataFormats, f)
+	return f, nil
+}
+
+// registerDataFormat adds a format definition with a given value. callers
+// must hold a write lock on dataFormatsLk, or be called from init
+func registerDataFormat(f DataFormat, def DataFormatDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("data format name is required")
+	}
+	names := append([]string{def.Name}, def.Aliases...)
+	for _, name := range names {
+		if strings.HasPrefix(name, ".") {
+			return fmt.Errorf("data format name %q cannot start with '.'", name)
+		}
+		if _, taken := dataFormatNames[name]; taken {
+			return fmt.Errorf("data format name %q is already registered", name)
+		}
+	}
+
+	dataFormats[f] = def
+	for _, name := range names {
+		dataFormatNames[name] = f
+	}
+	return nil
+}
+
 // MarshalJSON satisfies the json.Marshaler interface
 func (f DataFormat) MarshalJSON() ([]byte, error) {
 	if f == UnknownDataFormat {
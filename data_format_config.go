@@ -2,6 +2,8 @@ package dataset
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 // FormatConfig is the interface for data format configurations
@@ -15,16 +17,14 @@ type FormatConfig interface {
 // ParseFormatConfigMap returns a FormatConfig implementation for a given data format
 // and options map, often used in decoding from recorded formats like, say, JSON
 func ParseFormatConfigMap(f DataFormat, opts map[string]interface{}) (FormatConfig, error) {
-	switch f {
-	case CSVDataFormat:
-		return NewCSVOptions(opts)
-	case JSONDataFormat:
-		return NewJSONOptions(opts)
-	case XLSXDataFormat:
-		return NewXLSXOptions(opts)
-	default:
+	dataFormatsLk.RLock()
+	parse := dataFormats[f].ParseConfig
+	dataFormatsLk.RUnlock()
+
+	if parse == nil {
 		return nil, fmt.Errorf("cannot parse configuration for format: %s", f.String())
 	}
+	return parse(opts)
 }
 
 // NewCSVOptions creates a CSVOptions pointer from a map
@@ -51,14 +51,11 @@ func NewCSVOptions(opts map[string]interface{}) (*CSVOptions, error) {
 	}
 
 	if opts["separator"] != nil {
-		if sep, ok := opts["separator"].(string); ok {
-			if len(sep) != 1 {
-				return nil, fmt.Errorf("separator must be a single character")
-			}
-			o.Separator = rune(sep[0])
-		} else {
-			return nil, fmt.Errorf("invalid separator value: %v", opts["separator"])
+		sep, err := csvCharOption("separator", opts["separator"])
+		if err != nil {
+			return nil, err
 		}
+		o.Separator = sep
 	}
 
 	if opts["variadicFields"] != nil {
@@ -69,9 +66,129 @@ func NewCSVOptions(opts map[string]interface{}) (*CSVOptions, error) {
 		}
 	}
 
+	if opts["quoteChar"] != nil {
+		q, err := csvCharOption("quoteChar", opts["quoteChar"])
+		if err != nil {
+			return nil, err
+		}
+		o.QuoteChar = q
+	}
+
+	if opts["escapeChar"] != nil {
+		e, err := csvCharOption("escapeChar", opts["escapeChar"])
+		if err != nil {
+			return nil, err
+		}
+		o.EscapeChar = e
+	}
+
+	if opts["commentPrefix"] != nil {
+		if cp, ok := opts["commentPrefix"].(string); ok {
+			o.CommentPrefix = cp
+		} else {
+			return nil, fmt.Errorf("invalid commentPrefix value: %v", opts["commentPrefix"])
+		}
+	}
+
+	if opts["nullValues"] != nil {
+		switch nv := opts["nullValues"].(type) {
+		case []string:
+			o.NullValues = nv
+		case []interface{}:
+			o.NullValues = make([]string, len(nv))
+			for i, v := range nv {
+				str, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid nullValues value: %v", opts["nullValues"])
+				}
+				o.NullValues[i] = str
+			}
+		default:
+			return nil, fmt.Errorf("invalid nullValues value: %v", opts["nullValues"])
+		}
+	}
+
+	if opts["trim"] != nil {
+		switch trim := opts["trim"].(type) {
+		case bool:
+			if trim {
+				o.Trim = CSVTrimBoth
+			}
+		case string:
+			switch trim {
+			case CSVTrimStart, CSVTrimEnd, CSVTrimBoth:
+				o.Trim = trim
+			case "", "none":
+			default:
+				return nil, fmt.Errorf("invalid trim value: %v", opts["trim"])
+			}
+		default:
+			return nil, fmt.Errorf("invalid trim value: %v", opts["trim"])
+		}
+	}
+
+	if opts["skipRows"] != nil {
+		skip, err := intConfigOption("skipRows", opts["skipRows"])
+		if err != nil {
+			return nil, err
+		}
+		o.SkipRows = skip
+		if o.SkipRows < 0 {
+			return nil, fmt.Errorf("invalid skipRows value: %v", opts["skipRows"])
+		}
+	}
+
+	if opts["lineTerminator"] != nil {
+		if lt, ok := opts["lineTerminator"].(string); ok && (lt == "\n" || lt == "\r\n" || lt == "\r") {
+			o.LineTerminator = lt
+		} else {
+			return nil, fmt.Errorf("invalid lineTerminator value: %q", opts["lineTerminator"])
+		}
+	}
+
+	var err error
+	if o.Lenient, err = lenientOption(opts); err != nil {
+		return nil, err
+	}
+
 	return o, nil
 }
 
+// lenientOption reads the "lenient" option shared by text formats. Lenient
+// readers skip malformed records instead of failing
+func lenientOption(opts map[string]interface{}) (bool, error) {
+	if opts["lenient"] == nil {
+		return false, nil
+	}
+	lenient, ok := opts["lenient"].(bool)
+	if !ok {
+		return false, fmt.Errorf("invalid lenient value: %v", opts["lenient"])
+	}
+	return lenient, nil
+}
+
+// csvCharOption reads a single character option value
+func csvCharOption(name string, v interface{}) (rune, error) {
+	str, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s value: %v", name, v)
+	}
+	if utf8.RuneCountInString(str) != 1 {
+		return 0, fmt.Errorf("%s must be a single character", name)
+	}
+	r, _ := utf8.DecodeRuneInString(str)
+	return r, nil
+}
+
+const (
+	// CSVTrimStart trims leading whitespace from unquoted CSV values
+	CSVTrimStart = "start"
+	// CSVTrimEnd trims trailing whitespace from unquoted CSV values
+	CSVTrimEnd = "end"
+	// CSVTrimBoth trims leading & trailing whitespace from unquoted CSV values
+	CSVTrimBoth = "both"
+)
+
 // CSVOptions specifies configuration details for csv files
 // This'll expand in the future to interoperate with okfn csv spec
 type CSVOptions struct {
@@ -88,6 +205,27 @@ type CSVOptions struct {
 	// VariadicFields sets permits records to have a variable number of fields
 	// avoid using this
 	VariadicFields bool `json:"variadicFields"`
+	// QuoteChar is the character that wraps quoted values, defaulting to '"'
+	QuoteChar rune `json:"quoteChar,omitempty"`
+	// EscapeChar escapes the character that follows it within a value, eg:
+	// '\\'. When unset quote characters are escaped by doubling them
+	EscapeChar rune `json:"escapeChar,omitempty"`
+	// CommentPrefix marks lines to skip when it starts a line, eg: "#"
+	CommentPrefix string `json:"commentPrefix,omitempty"`
+	// NullValues are unquoted values read as null, eg: "NA", "", "\\N". The
+	// first null value is written for nulls, which are otherwise empty
+	NullValues []string `json:"nullValues,omitempty"`
+	// Trim removes whitespace from unquoted values. One of CSVTrimStart,
+	// CSVTrimEnd or CSVTrimBoth
+	Trim string `json:"trim,omitempty"`
+	// SkipRows is a number of lines to skip before reading the header row or
+	// first record
+	SkipRows int `json:"skipRows,omitempty"`
+	// LineTerminator ends records when writing, one of "\n" (the default),
+	// "\r\n" or "\r". Readers accept any of them
+	LineTerminator string `json:"lineTerminator,omitempty"`
+	// Lenient readers skip malformed records instead of failing
+	Lenient bool `json:"lenient,omitempty"`
 }
 
 // Format announces the CSV Data Format for the FormatConfig interface
@@ -111,7 +249,35 @@ func (o *CSVOptions) Map() map[string]interface{} {
 		opt["variadicFields"] = o.VariadicFields
 	}
 	if o.Separator != rune(0) {
-		opt["separator"] = o.Separator
+		opt["separator"] = string(o.Separator)
+	}
+	if o.QuoteChar != rune(0) {
+		opt["quoteChar"] = string(o.QuoteChar)
+	}
+	if o.EscapeChar != rune(0) {
+		opt["escapeChar"] = string(o.EscapeChar)
+	}
+	if o.CommentPrefix != "" {
+		opt["commentPrefix"] = o.CommentPrefix
+	}
+	if o.NullValues != nil {
+		nv := make([]interface{}, len(o.NullValues))
+		for i, v := range o.NullValues {
+			nv[i] = v
+		}
+		opt["nullValues"] = nv
+	}
+	if o.Trim != "" {
+		opt["trim"] = o.Trim
+	}
+	if o.SkipRows != 0 {
+		opt["skipRows"] = o.SkipRows
+	}
+	if o.LineTerminator != "" {
+		opt["lineTerminator"] = o.LineTerminator
+	}
+	if o.Lenient {
+		opt["lenient"] = o.Lenient
 	}
 	return opt
 }
@@ -121,10 +287,34 @@ func NewJSONOptions(opts map[string]interface{}) (*JSONOptions, error) {
 	if opts == nil {
 		opts = make(map[string]interface{})
 	}
+
+	if opts["entriesPointer"] != nil {
+		ptr, ok := opts["entriesPointer"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid entriesPointer value: %v", opts["entriesPointer"])
+		}
+		if _, err := ParseJSONPointer(ptr); err != nil {
+			return nil, fmt.Errorf("invalid entriesPointer value: %s", err)
+		}
+	}
+
+	if _, err := numberModeOption(opts); err != nil {
+		return nil, err
+	}
+	if _, err := lenientOption(opts); err != nil {
+		return nil, err
+	}
+
 	return &JSONOptions{Options: opts}, nil
 }
 
-// JSONOptions specifies configuration details for json file format
+// JSONOptions specifies configuration details for json file format. The
+// "entriesPointer" option is a JSON pointer (RFC 6901) to the array or object
+// whose children are entries, eg: "/data/items" for bodies that wrap entries
+// in an envelope like {"meta":{}, "data":{"items":[...]}}. entriesPointer
+// is only read, JSON writers reject it. The "numbers"
+// option sets the NumberMode of readers, and "lenient" readers skip malformed
+// entries instead of failing
 type JSONOptions struct {
 	Options map[string]interface{}
 }
@@ -142,9 +332,154 @@ func (o *JSONOptions) Map() map[string]interface{} {
 	return o.Options
 }
 
+// EntriesPointer gives the reference tokens of the entriesPointer option,
+// the keys & array indexes leading from the top level value to the value
+// holding entries. An empty pointer refers to the top level value
+func (o *JSONOptions) EntriesPointer() ([]string, error) {
+	if o == nil {
+		return nil, nil
+	}
+	ptr, _ := o.Options["entriesPointer"].(string)
+	return ParseJSONPointer(ptr)
+}
+
+// Numbers gives the number mode of the numbers option
+func (o *JSONOptions) Numbers() NumberMode {
+	if o == nil {
+		return NumbersNative
+	}
+	mode, _ := numberModeOption(o.Options)
+	return mode
+}
+
+// Lenient reports if the lenient option is set
+func (o *JSONOptions) Lenient() bool {
+	if o == nil {
+		return false
+	}
+	lenient, _ := lenientOption(o.Options)
+	return lenient
+}
+
+// ParseJSONPointer splits a JSON pointer (RFC 6901) into unescaped reference
+// tokens. The empty pointer refers to the whole document, giving no tokens
+func ParseJSONPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("json pointer %q must start with '/'", ptr)
+	}
+
+	toks := strings.Split(ptr[1:], "/")
+	for i, tok := range toks {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] == '~' && (j == len(tok)-1 || tok[j+1] != '0' && tok[j+1] != '1') {
+				return nil, fmt.Errorf("json pointer %q has an invalid escape sequence", ptr)
+			}
+		}
+		toks[i] = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+	}
+	return toks, nil
+}
+
+// NDJSONOptions specifies configuration details for the ndjson file format
+type NDJSONOptions struct {
+	// Numbers sets the go types numbers are read as
+	Numbers NumberMode `json:"numbers,omitempty"`
+	// Lenient readers skip malformed lines instead of failing
+	Lenient bool `json:"lenient,omitempty"`
+}
+
+// NewNDJSONOptions creates a NDJSONOptions pointer from a map
+func NewNDJSONOptions(opts map[string]interface{}) (*NDJSONOptions, error) {
+	o := &NDJSONOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	var err error
+	if o.Numbers, err = numberModeOption(opts); err != nil {
+		return nil, err
+	}
+	if o.Lenient, err = lenientOption(opts); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Format announces the NDJSON data format for the FormatConfig interface
+func (*NDJSONOptions) Format() DataFormat {
+	return NDJSONDataFormat
+}
+
+// Map structures NDJSONOptions as a map of string keys to values
+func (o *NDJSONOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Numbers != NumbersNative {
+		opt["numbers"] = string(o.Numbers)
+	}
+	if o.Lenient {
+		opt["lenient"] = o.Lenient
+	}
+	return opt
+}
+
+// CBOROptions specifies configuration details for the cbor file format
+type CBOROptions struct {
+	// Numbers sets the go types numbers are read as
+	Numbers NumberMode `json:"numbers,omitempty"`
+}
+
+// NewCBOROptions creates a CBOROptions pointer from a map
+func NewCBOROptions(opts map[string]interface{}) (*CBOROptions, error) {
+	o := &CBOROptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	var err error
+	if o.Numbers, err = numberModeOption(opts); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Format announces the CBOR data format for the FormatConfig interface
+func (*CBOROptions) Format() DataFormat {
+	return CBORDataFormat
+}
+
+// Map structures CBOROptions as a map of string keys to values
+func (o *CBOROptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Numbers != NumbersNative {
+		opt["numbers"] = string(o.Numbers)
+	}
+	return opt
+}
+
 // XLSXOptions specifies configuraiton details for the xlsx file format
 type XLSXOptions struct {
+	// SheetName is the worksheet to read from or write to, defaults to "Sheet1"
 	SheetName string `json:"sheetName,omitempty"`
+	// HeaderRow specifies the first row of a sheet holds column titles. Readers
+	// skip the header, writers add one from schema column titles
+	HeaderRow bool `json:"headerRow,omitempty"`
+	// Range limits reading to a block of cells in A1 notation, eg: "B3:H200".
+	// A single cell like "B3" reads from that cell to the end of the sheet.
+	// Writers start writing at the top-left cell of the range
+	Range string `json:"range,omitempty"`
+	// AllSheets reads every sheet in a workbook as an object of arrays, with
+	// one entry per sheet keyed by sheet name. Writers create a sheet for
+	// each entry key
+	AllSheets bool `json:"allSheets,omitempty"`
 }
 
 // NewXLSXOptions creates a XLSXOptions pointer from a map
@@ -162,6 +497,34 @@ func NewXLSXOptions(opts map[string]interface{}) (FormatConfig, error) {
 		}
 	}
 
+	if opts["headerRow"] != nil {
+		if headerRow, ok := opts["headerRow"].(bool); ok {
+			o.HeaderRow = headerRow
+		} else {
+			return nil, fmt.Errorf("invalid headerRow value: %v", opts["headerRow"])
+		}
+	}
+
+	if opts["range"] != nil {
+		if rng, ok := opts["range"].(string); ok {
+			o.Range = rng
+		} else {
+			return nil, fmt.Errorf("invalid range value: %v", opts["range"])
+		}
+	}
+
+	if opts["allSheets"] != nil {
+		if allSheets, ok := opts["allSheets"].(bool); ok {
+			o.AllSheets = allSheets
+		} else {
+			return nil, fmt.Errorf("invalid allSheets value: %v", opts["allSheets"])
+		}
+	}
+
+	if o.AllSheets && o.SheetName != "" {
+		return nil, fmt.Errorf("sheetName and allSheets can't be used together")
+	}
+
 	return o, nil
 }
 
@@ -179,6 +542,296 @@ func (o *XLSXOptions) Map() map[string]interface{} {
 	if o.SheetName != "" {
 		opt["sheetName"] = o.SheetName
 	}
+	if o.HeaderRow {
+		opt["headerRow"] = o.HeaderRow
+	}
+	if o.Range != "" {
+		opt["range"] = o.Range
+	}
+	if o.AllSheets {
+		opt["allSheets"] = o.AllSheets
+	}
+
+	return opt
+}
+
+// XMLOptions specifies configuration details for the xml file format
+type XMLOptions struct {
+	// RecordPath is a slash-separated list of element names leading from the
+	// document root to the elements that make up entries, eg: "feed/item".
+	// A single name matches children of the root element with that name.
+	// When empty, every child of the root element is an entry
+	RecordPath string `json:"recordPath,omitempty"`
+	// IgnoreAttributes drops element attributes when reading
+	IgnoreAttributes bool `json:"ignoreAttributes,omitempty"`
+	// AttributePrefix is prepended to attribute names to distinguish them
+	// from child elements, defaults to "@"
+	AttributePrefix string `json:"attributePrefix,omitempty"`
+	// TextKey is the key used for the character data of an element that
+	// also has attributes or child elements, defaults to "#text"
+	TextKey string `json:"textKey,omitempty"`
+}
+
+// NewXMLOptions creates a XMLOptions pointer from a map
+func NewXMLOptions(opts map[string]interface{}) (*XMLOptions, error) {
+	o := &XMLOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["recordPath"] != nil {
+		if rp, ok := opts["recordPath"].(string); ok {
+			o.RecordPath = rp
+		} else {
+			return nil, fmt.Errorf("invalid recordPath value: %v", opts["recordPath"])
+		}
+	}
+
+	if opts["ignoreAttributes"] != nil {
+		if ia, ok := opts["ignoreAttributes"].(bool); ok {
+			o.IgnoreAttributes = ia
+		} else {
+			return nil, fmt.Errorf("invalid ignoreAttributes value: %v", opts["ignoreAttributes"])
+		}
+	}
+
+	if opts["attributePrefix"] != nil {
+		if ap, ok := opts["attributePrefix"].(string); ok {
+			o.AttributePrefix = ap
+		} else {
+			return nil, fmt.Errorf("invalid attributePrefix value: %v", opts["attributePrefix"])
+		}
+	}
+
+	if opts["textKey"] != nil {
+		if tk, ok := opts["textKey"].(string); ok {
+			o.TextKey = tk
+		} else {
+			return nil, fmt.Errorf("invalid textKey value: %v", opts["textKey"])
+		}
+	}
+
+	return o, nil
+}
+
+// Format announces the XML data format for the FormatConfig interface
+func (*XMLOptions) Format() DataFormat {
+	return XMLDataFormat
+}
+
+// Map structures XMLOptions as a map of string keys to values
+func (o *XMLOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.RecordPath != "" {
+		opt["recordPath"] = o.RecordPath
+	}
+	if o.IgnoreAttributes {
+		opt["ignoreAttributes"] = o.IgnoreAttributes
+	}
+	if o.AttributePrefix != "" {
+		opt["attributePrefix"] = o.AttributePrefix
+	}
+	if o.TextKey != "" {
+		opt["textKey"] = o.TextKey
+	}
+	return opt
+}
+
+// ArrowOptions specifies configuration details for the arrow file format
+type ArrowOptions struct {
+	// Stream writes the arrow IPC stream format instead of the IPC file
+	// (Feather V2) format. Readers detect the format automatically
+	Stream bool `json:"stream,omitempty"`
+}
+
+// NewArrowOptions creates a ArrowOptions pointer from a map
+func NewArrowOptions(opts map[string]interface{}) (*ArrowOptions, error) {
+	o := &ArrowOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["stream"] != nil {
+		if stream, ok := opts["stream"].(bool); ok {
+			o.Stream = stream
+		} else {
+			return nil, fmt.Errorf("invalid stream value: %v", opts["stream"])
+		}
+	}
+
+	return o, nil
+}
+
+// Format announces the Arrow data format for the FormatConfig interface
+func (*ArrowOptions) Format() DataFormat {
+	return ArrowDataFormat
+}
+
+// Map structures ArrowOptions as a map of string keys to values
+func (o *ArrowOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Stream {
+		opt["stream"] = o.Stream
+	}
+	return opt
+}
+
+// AvroOptions specifies configuration details for the avro file format
+type AvroOptions struct {
+	// Codec is the block compression codec used when writing, one of "null",
+	// "deflate" or "snappy". Defaults to "null"
+	Codec string `json:"codec,omitempty"`
+	// RecordName is the name of the record type written to the file header's
+	// schema, defaults to "entry"
+	RecordName string `json:"recordName,omitempty"`
+}
+
+// NewAvroOptions creates a AvroOptions pointer from a map
+func NewAvroOptions(opts map[string]interface{}) (*AvroOptions, error) {
+	o := &AvroOptions{}
+	if opts == nil {
+		return o, nil
+	}
 
+	if opts["codec"] != nil {
+		if codec, ok := opts["codec"].(string); ok {
+			o.Codec = codec
+		} else {
+			return nil, fmt.Errorf("invalid codec value: %v", opts["codec"])
+		}
+	}
+
+	if opts["recordName"] != nil {
+		if rn, ok := opts["recordName"].(string); ok {
+			o.RecordName = rn
+		} else {
+			return nil, fmt.Errorf("invalid recordName value: %v", opts["recordName"])
+		}
+	}
+
+	return o, nil
+}
+
+// Format announces the Avro data format for the FormatConfig interface
+func (*AvroOptions) Format() DataFormat {
+	return AvroDataFormat
+}
+
+// Map structures AvroOptions as a map of string keys to values
+func (o *AvroOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Codec != "" {
+		opt["codec"] = o.Codec
+	}
+	if o.RecordName != "" {
+		opt["recordName"] = o.RecordName
+	}
+	return opt
+}
+
+// SQLiteOptions specifies configuration details for the sqlite file format
+type SQLiteOptions struct {
+	// Table is the name of the table to read from or create when writing.
+	// Readers default to the only table in the database, writers to "body"
+	Table string `json:"table,omitempty"`
+	// Query is a SELECT statement to read rows from, taking precedence over
+	// Table. Queries can't be written to
+	Query string `json:"query,omitempty"`
+}
+
+// NewSQLiteOptions creates a SQLiteOptions pointer from a map
+func NewSQLiteOptions(opts map[string]interface{}) (*SQLiteOptions, error) {
+	o := &SQLiteOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["table"] != nil {
+		if table, ok := opts["table"].(string); ok {
+			o.Table = table
+		} else {
+			return nil, fmt.Errorf("invalid table value: %v", opts["table"])
+		}
+	}
+
+	if opts["query"] != nil {
+		if query, ok := opts["query"].(string); ok {
+			o.Query = query
+		} else {
+			return nil, fmt.Errorf("invalid query value: %v", opts["query"])
+		}
+	}
+
+	return o, nil
+}
+
+// Format announces the SQLite data format for the FormatConfig interface
+func (*SQLiteOptions) Format() DataFormat {
+	return SQLiteDataFormat
+}
+
+// Map structures SQLiteOptions as a map of string keys to values
+func (o *SQLiteOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Table != "" {
+		opt["table"] = o.Table
+	}
+	if o.Query != "" {
+		opt["query"] = o.Query
+	}
+	return opt
+}
+
+// YAMLOptions specifies configuration details for the yaml file format
+type YAMLOptions struct {
+	// MultiDocument treats each document of a YAML stream as an entry, the
+	// way NDJSON treats lines. Multi-document bodies must be arrays
+	MultiDocument bool `json:"multiDocument,omitempty"`
+}
+
+// NewYAMLOptions creates a YAMLOptions pointer from a map
+func NewYAMLOptions(opts map[string]interface{}) (*YAMLOptions, error) {
+	o := &YAMLOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["multiDocument"] != nil {
+		if md, ok := opts["multiDocument"].(bool); ok {
+			o.MultiDocument = md
+		} else {
+			return nil, fmt.Errorf("invalid multiDocument value: %v", opts["multiDocument"])
+		}
+	}
+
+	return o, nil
+}
+
+// Format announces the YAML data format for the FormatConfig interface
+func (*YAMLOptions) Format() DataFormat {
+	return YAMLDataFormat
+}
+
+// Map structures YAMLOptions as a map of string keys to values
+func (o *YAMLOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.MultiDocument {
+		opt["multiDocument"] = o.MultiDocument
+	}
 	return opt
 }
@@ -1,8 +1,11 @@
 package dataset
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func CompareFormatConfigs(a, b FormatConfig) error {
@@ -31,6 +34,13 @@ func TestParseFormatConfigMap(t *testing.T) {
 		{CSVDataFormat, map[string]interface{}{}, &CSVOptions{}, ""},
 		{JSONDataFormat, map[string]interface{}{}, &JSONOptions{}, ""},
 		{XLSXDataFormat, map[string]interface{}{}, &XLSXOptions{}, ""},
+		{XMLDataFormat, map[string]interface{}{}, &XMLOptions{}, ""},
+		{ArrowDataFormat, map[string]interface{}{}, &ArrowOptions{}, ""},
+		{AvroDataFormat, map[string]interface{}{}, &AvroOptions{}, ""},
+		{SQLiteDataFormat, map[string]interface{}{}, &SQLiteOptions{}, ""},
+		{YAMLDataFormat, map[string]interface{}{}, &YAMLOptions{}, ""},
+		{NDJSONDataFormat, map[string]interface{}{"numbers": "decimal"}, &NDJSONOptions{}, ""},
+		{CBORDataFormat, map[string]interface{}{"numbers": "big"}, &CBOROptions{}, ""},
 	}
 
 	for i, c := range cases {
@@ -63,6 +73,26 @@ func TestNewCSVOptions(t *testing.T) {
 		{map[string]interface{}{"separator": true}, nil, "invalid separator value: true"},
 		{map[string]interface{}{"variadicFields": true}, &CSVOptions{VariadicFields: true}, ""},
 		{map[string]interface{}{"variadicFields": "foo"}, nil, "invalid variadicFields value: foo"},
+		{map[string]interface{}{"separator": "§"}, &CSVOptions{Separator: '§'}, ""},
+		{map[string]interface{}{"quoteChar": "'"}, &CSVOptions{QuoteChar: '\''}, ""},
+		{map[string]interface{}{"quoteChar": "''"}, nil, "quoteChar must be a single character"},
+		{map[string]interface{}{"escapeChar": "\\"}, &CSVOptions{EscapeChar: '\\'}, ""},
+		{map[string]interface{}{"escapeChar": 1}, nil, "invalid escapeChar value: 1"},
+		{map[string]interface{}{"commentPrefix": "#"}, &CSVOptions{CommentPrefix: "#"}, ""},
+		{map[string]interface{}{"commentPrefix": false}, nil, "invalid commentPrefix value: false"},
+		{map[string]interface{}{"nullValues": []interface{}{"NA", "", "\\N"}}, &CSVOptions{NullValues: []string{"NA", "", "\\N"}}, ""},
+		{map[string]interface{}{"nullValues": []string{"NA"}}, &CSVOptions{NullValues: []string{"NA"}}, ""},
+		{map[string]interface{}{"nullValues": []interface{}{1}}, nil, "invalid nullValues value: [1]"},
+		{map[string]interface{}{"trim": true}, &CSVOptions{Trim: CSVTrimBoth}, ""},
+		{map[string]interface{}{"trim": false}, &CSVOptions{}, ""},
+		{map[string]interface{}{"trim": "start"}, &CSVOptions{Trim: CSVTrimStart}, ""},
+		{map[string]interface{}{"trim": "middle"}, nil, "invalid trim value: middle"},
+		{map[string]interface{}{"skipRows": 2}, &CSVOptions{SkipRows: 2}, ""},
+		{map[string]interface{}{"skipRows": float64(3)}, &CSVOptions{SkipRows: 3}, ""},
+		{map[string]interface{}{"skipRows": 1.5}, nil, "invalid skipRows value: 1.5"},
+		{map[string]interface{}{"skipRows": -1}, nil, "invalid skipRows value: -1"},
+		{map[string]interface{}{"lineTerminator": "\r\n"}, &CSVOptions{LineTerminator: "\r\n"}, ""},
+		{map[string]interface{}{"lineTerminator": ";"}, nil, `invalid lineTerminator value: ";"`},
 	}
 
 	for i, c := range cases {
@@ -72,8 +102,8 @@ func TestNewCSVOptions(t *testing.T) {
 			continue
 		}
 		if c.err == "" {
-			if got.HeaderRow != c.res.HeaderRow {
-				t.Errorf("case %d HeaderRow expected: %t, got: %t", i, got.HeaderRow, c.res.HeaderRow)
+			if diff := cmp.Diff(c.res, got); diff != "" {
+				t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
 				continue
 			}
 		}
@@ -99,6 +129,46 @@ func TestCSVOptionsMap(t *testing.T) {
 	}
 }
 
+func TestCSVOptionsRoundTrip(t *testing.T) {
+	opt := &CSVOptions{
+		HeaderRow:      true,
+		LazyQuotes:     true,
+		Separator:      ';',
+		VariadicFields: true,
+		QuoteChar:      '\'',
+		EscapeChar:     '\\',
+		CommentPrefix:  "//",
+		NullValues:     []string{"NA", "", "\\N"},
+		Trim:           CSVTrimEnd,
+		SkipRows:       2,
+		LineTerminator: "\r\n",
+	}
+
+	got, err := NewCSVOptions(opt.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(opt, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	// format configs are often recorded as JSON
+	data, err := json.Marshal(opt.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = NewCSVOptions(m); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(opt, got); diff != "" {
+		t.Errorf("json result mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestNewJSONOptions(t *testing.T) {
 	cases := []struct {
 		opts map[string]interface{}
@@ -107,6 +177,13 @@ func TestNewJSONOptions(t *testing.T) {
 	}{
 		{nil, &JSONOptions{}, ""},
 		{map[string]interface{}{}, &JSONOptions{}, ""},
+		{map[string]interface{}{"entriesPointer": "/data/items"}, &JSONOptions{}, ""},
+		{map[string]interface{}{"entriesPointer": 12}, nil, "invalid entriesPointer value: 12"},
+		{map[string]interface{}{"entriesPointer": "data"}, nil, `invalid entriesPointer value: json pointer "data" must start with '/'`},
+		{map[string]interface{}{"entriesPointer": "/a~2b"}, nil, `invalid entriesPointer value: json pointer "/a~2b" has an invalid escape sequence`},
+		{map[string]interface{}{"numbers": "decimal"}, &JSONOptions{}, ""},
+		{map[string]interface{}{"numbers": "exact"}, nil, "invalid numbers value: exact"},
+		{map[string]interface{}{"numbers": true}, nil, "invalid numbers value: true"},
 	}
 
 	for i, c := range cases {
@@ -118,6 +195,34 @@ func TestNewJSONOptions(t *testing.T) {
 	}
 }
 
+func TestJSONOptionsEntriesPointer(t *testing.T) {
+	cases := []struct {
+		ptr    interface{}
+		expect []string
+	}{
+		{nil, nil},
+		{"", nil},
+		{"/", []string{""}},
+		{"/data/items", []string{"data", "items"}},
+		{"/results/0", []string{"results", "0"}},
+		{"/a~1b/m~0n", []string{"a/b", "m~n"}},
+	}
+
+	for i, c := range cases {
+		opts, err := NewJSONOptions(map[string]interface{}{"entriesPointer": c.ptr})
+		if err != nil {
+			t.Fatalf("case %d unexpected error: %s", i, err)
+		}
+		got, err := opts.EntriesPointer()
+		if err != nil {
+			t.Fatalf("case %d unexpected error: %s", i, err)
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
 func TestJSONOptionsMap(t *testing.T) {
 	cases := []struct {
 		opt *JSONOptions
@@ -137,6 +242,95 @@ func TestJSONOptionsMap(t *testing.T) {
 	}
 }
 
+func TestNewNumbersOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  NumberMode
+		err  string
+	}{
+		{nil, NumbersNative, ""},
+		{map[string]interface{}{}, NumbersNative, ""},
+		{map[string]interface{}{"numbers": "native"}, NumbersNative, ""},
+		{map[string]interface{}{"numbers": "decimal"}, NumbersDecimal, ""},
+		{map[string]interface{}{"numbers": "big"}, NumbersBig, ""},
+		{map[string]interface{}{"numbers": "float"}, NumbersNative, "invalid numbers value: float"},
+		{map[string]interface{}{"numbers": 1}, NumbersNative, "invalid numbers value: 1"},
+	}
+
+	for i, c := range cases {
+		ndjson, err := NewNDJSONOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		cbor, err := NewCBOROptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if ndjson.Numbers != c.res || cbor.Numbers != c.res {
+			t.Errorf("case %d result mismatch. expected: %q, got: %q, %q", i, c.res, ndjson.Numbers, cbor.Numbers)
+		}
+		if json, _ := NewJSONOptions(c.opts); json.Numbers() != c.res {
+			t.Errorf("case %d json result mismatch. expected: %q, got: %q", i, c.res, json.Numbers())
+		}
+
+		expectMap := map[string]interface{}{}
+		if c.res != NumbersNative {
+			expectMap["numbers"] = string(c.res)
+		}
+		if diff := cmp.Diff(expectMap, ndjson.Map()); diff != "" {
+			t.Errorf("case %d ndjson map mismatch (-want +got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(expectMap, cbor.Map()); diff != "" {
+			t.Errorf("case %d cbor map mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestNewLenientOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  bool
+		err  string
+	}{
+		{nil, false, ""},
+		{map[string]interface{}{"lenient": false}, false, ""},
+		{map[string]interface{}{"lenient": true}, true, ""},
+		{map[string]interface{}{"lenient": "yes"}, false, "invalid lenient value: yes"},
+	}
+
+	for i, c := range cases {
+		csv, err := NewCSVOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d csv error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		ndjson, err := NewNDJSONOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d ndjson error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		json, err := NewJSONOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d json error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if csv.Lenient != c.res || ndjson.Lenient != c.res || json.Lenient() != c.res {
+			t.Errorf("case %d result mismatch. expected: %t, got: %t, %t, %t", i, c.res, csv.Lenient, ndjson.Lenient, json.Lenient())
+		}
+		if c.res && (csv.Map()["lenient"] != true || ndjson.Map()["lenient"] != true) {
+			t.Errorf("case %d expected lenient in options map", i)
+		}
+	}
+}
+
 func TestNewXLSXOptions(t *testing.T) {
 	cases := []struct {
 		opts map[string]interface{}
@@ -147,6 +341,12 @@ func TestNewXLSXOptions(t *testing.T) {
 		{map[string]interface{}{}, &XLSXOptions{}, ""},
 		{map[string]interface{}{"sheetName": "foo"}, &XLSXOptions{SheetName: "foo"}, ""},
 		{map[string]interface{}{"sheetName": true}, nil, "invalid sheetName value: true"},
+		{map[string]interface{}{"headerRow": true, "range": "B3:H200"}, &XLSXOptions{HeaderRow: true, Range: "B3:H200"}, ""},
+		{map[string]interface{}{"allSheets": true}, &XLSXOptions{AllSheets: true}, ""},
+		{map[string]interface{}{"headerRow": "yes"}, nil, "invalid headerRow value: yes"},
+		{map[string]interface{}{"range": 3}, nil, "invalid range value: 3"},
+		{map[string]interface{}{"allSheets": 1}, nil, "invalid allSheets value: 1"},
+		{map[string]interface{}{"allSheets": true, "sheetName": "foo"}, nil, "sheetName and allSheets can't be used together"},
 	}
 
 	for i, c := range cases {
@@ -162,8 +362,8 @@ func TestNewXLSXOptions(t *testing.T) {
 				continue
 			}
 
-			if xlsxo.SheetName != c.res.SheetName {
-				t.Errorf("case %d SheetName expected: %s, got: %s", i, xlsxo.SheetName, c.res.SheetName)
+			if *xlsxo != *c.res {
+				t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, xlsxo)
 				continue
 			}
 		}
@@ -178,6 +378,7 @@ func TestXLSXOptionsMap(t *testing.T) {
 		{nil, nil},
 		{&XLSXOptions{}, map[string]interface{}{}},
 		{&XLSXOptions{SheetName: "foo"}, map[string]interface{}{"sheetName": "foo"}},
+		{&XLSXOptions{HeaderRow: true, Range: "A2:C9", AllSheets: true}, map[string]interface{}{"headerRow": true, "range": "A2:C9", "allSheets": true}},
 	}
 
 	for i, c := range cases {
@@ -189,3 +390,253 @@ func TestXLSXOptionsMap(t *testing.T) {
 		}
 	}
 }
+
+func TestNewXMLOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *XMLOptions
+		err  string
+	}{
+		{nil, &XMLOptions{}, ""},
+		{map[string]interface{}{}, &XMLOptions{}, ""},
+		{map[string]interface{}{"recordPath": "feed/item"}, &XMLOptions{RecordPath: "feed/item"}, ""},
+		{map[string]interface{}{"recordPath": 5}, nil, "invalid recordPath value: 5"},
+		{map[string]interface{}{"ignoreAttributes": true}, &XMLOptions{IgnoreAttributes: true}, ""},
+		{map[string]interface{}{"ignoreAttributes": "foo"}, nil, "invalid ignoreAttributes value: foo"},
+		{map[string]interface{}{"attributePrefix": "-"}, &XMLOptions{AttributePrefix: "-"}, ""},
+		{map[string]interface{}{"attributePrefix": false}, nil, "invalid attributePrefix value: false"},
+		{map[string]interface{}{"textKey": "_"}, &XMLOptions{TextKey: "_"}, ""},
+		{map[string]interface{}{"textKey": false}, nil, "invalid textKey value: false"},
+	}
+
+	for i, c := range cases {
+		got, err := NewXMLOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestXMLOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *XMLOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&XMLOptions{}, map[string]interface{}{}},
+		{&XMLOptions{RecordPath: "feed/item", IgnoreAttributes: true, AttributePrefix: "-", TextKey: "_"}, map[string]interface{}{
+			"recordPath":       "feed/item",
+			"ignoreAttributes": true,
+			"attributePrefix":  "-",
+			"textKey":          "_",
+		}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if len(got) != len(c.res) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+		}
+		for key, val := range c.res {
+			if got[key] != val {
+				t.Errorf("case %d, key '%s' expected: '%v' got:'%v'", i, key, val, got[key])
+			}
+		}
+	}
+}
+
+func TestNewArrowOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *ArrowOptions
+		err  string
+	}{
+		{nil, &ArrowOptions{}, ""},
+		{map[string]interface{}{}, &ArrowOptions{}, ""},
+		{map[string]interface{}{"stream": true}, &ArrowOptions{Stream: true}, ""},
+		{map[string]interface{}{"stream": "yes"}, nil, "invalid stream value: yes"},
+	}
+
+	for i, c := range cases {
+		got, err := NewArrowOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestArrowOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *ArrowOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&ArrowOptions{}, map[string]interface{}{}},
+		{&ArrowOptions{Stream: true}, map[string]interface{}{"stream": true}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if len(got) != len(c.res) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+		}
+		for key, val := range c.res {
+			if got[key] != val {
+				t.Errorf("case %d, key '%s' expected: '%v' got:'%v'", i, key, val, got[key])
+			}
+		}
+	}
+}
+
+func TestNewAvroOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *AvroOptions
+		err  string
+	}{
+		{nil, &AvroOptions{}, ""},
+		{map[string]interface{}{}, &AvroOptions{}, ""},
+		{map[string]interface{}{"codec": "deflate"}, &AvroOptions{Codec: "deflate"}, ""},
+		{map[string]interface{}{"codec": 1}, nil, "invalid codec value: 1"},
+		{map[string]interface{}{"recordName": "row"}, &AvroOptions{RecordName: "row"}, ""},
+		{map[string]interface{}{"recordName": false}, nil, "invalid recordName value: false"},
+	}
+
+	for i, c := range cases {
+		got, err := NewAvroOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestAvroOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *AvroOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&AvroOptions{}, map[string]interface{}{}},
+		{&AvroOptions{Codec: "snappy", RecordName: "row"}, map[string]interface{}{"codec": "snappy", "recordName": "row"}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if len(got) != len(c.res) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+		}
+		for key, val := range c.res {
+			if got[key] != val {
+				t.Errorf("case %d, key '%s' expected: '%v' got:'%v'", i, key, val, got[key])
+			}
+		}
+	}
+}
+
+func TestNewSQLiteOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *SQLiteOptions
+		err  string
+	}{
+		{nil, &SQLiteOptions{}, ""},
+		{map[string]interface{}{}, &SQLiteOptions{}, ""},
+		{map[string]interface{}{"table": "cities"}, &SQLiteOptions{Table: "cities"}, ""},
+		{map[string]interface{}{"table": 1}, nil, "invalid table value: 1"},
+		{map[string]interface{}{"query": "SELECT 1"}, &SQLiteOptions{Query: "SELECT 1"}, ""},
+		{map[string]interface{}{"query": false}, nil, "invalid query value: false"},
+	}
+
+	for i, c := range cases {
+		got, err := NewSQLiteOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestSQLiteOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *SQLiteOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&SQLiteOptions{}, map[string]interface{}{}},
+		{&SQLiteOptions{Table: "cities", Query: "SELECT 1"}, map[string]interface{}{"table": "cities", "query": "SELECT 1"}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if len(got) != len(c.res) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+		}
+		for key, val := range c.res {
+			if got[key] != val {
+				t.Errorf("case %d, key '%s' expected: '%v' got:'%v'", i, key, val, got[key])
+			}
+		}
+	}
+}
+
+func TestNewYAMLOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *YAMLOptions
+		err  string
+	}{
+		{nil, &YAMLOptions{}, ""},
+		{map[string]interface{}{}, &YAMLOptions{}, ""},
+		{map[string]interface{}{"multiDocument": true}, &YAMLOptions{MultiDocument: true}, ""},
+		{map[string]interface{}{"multiDocument": "yes"}, nil, "invalid multiDocument value: yes"},
+	}
+
+	for i, c := range cases {
+		got, err := NewYAMLOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && *got != *c.res {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestYAMLOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *YAMLOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&YAMLOptions{}, map[string]interface{}{}},
+		{&YAMLOptions{MultiDocument: true}, map[string]interface{}{"multiDocument": true}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if len(got) != len(c.res) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.res), len(got))
+		}
+		for key, val := range c.res {
+			if got[key] != val {
+				t.Errorf("case %d, key '%s' expected: '%v' got:'%v'", i, key, val, got[key])
+			}
+		}
+	}
+}
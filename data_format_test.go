@@ -12,6 +12,12 @@ func TestSupportedDataFormats(t *testing.T) {
 		CSVDataFormat,
 		XLSXDataFormat,
 		NDJSONDataFormat,
+		XMLDataFormat,
+		ParquetDataFormat,
+		ArrowDataFormat,
+		AvroDataFormat,
+		SQLiteDataFormat,
+		YAMLDataFormat,
 	}
 
 	for i, f := range SupportedDataFormats() {
@@ -33,6 +39,11 @@ func TestDataFormatString(t *testing.T) {
 		{XLSXDataFormat, "xlsx"},
 		{CBORDataFormat, "cbor"},
 		{NDJSONDataFormat, "ndjson"},
+		{ParquetDataFormat, "parquet"},
+		{ArrowDataFormat, "arrow"},
+		{AvroDataFormat, "avro"},
+		{SQLiteDataFormat, "sqlite"},
+		{YAMLDataFormat, "yaml"},
 	}
 
 	for i, c := range cases {
@@ -64,6 +75,19 @@ func TestParseDataFormatString(t *testing.T) {
 		{"ndjson", NDJSONDataFormat, ""},
 		{".jsonl", NDJSONDataFormat, ""},
 		{"jsonl", NDJSONDataFormat, ""},
+		{".parquet", ParquetDataFormat, ""},
+		{"parquet", ParquetDataFormat, ""},
+		{".arrow", ArrowDataFormat, ""},
+		{"arrow", ArrowDataFormat, ""},
+		{".feather", ArrowDataFormat, ""},
+		{"feather", ArrowDataFormat, ""},
+		{".avro", AvroDataFormat, ""},
+		{"avro", AvroDataFormat, ""},
+		{".sqlite", SQLiteDataFormat, ""},
+		{"sqlite", SQLiteDataFormat, ""},
+		{".db", SQLiteDataFormat, ""},
+		{"yaml", YAMLDataFormat, ""},
+		{".yml", YAMLDataFormat, ""},
 	}
 
 	for i, c := range cases {
@@ -91,6 +115,11 @@ func TestDataFormatMarshalJSON(t *testing.T) {
 		{XLSXDataFormat, []byte(`"xlsx"`), ""},
 		{CBORDataFormat, []byte(`"cbor"`), ""},
 		{NDJSONDataFormat, []byte(`"ndjson"`), ""},
+		{ParquetDataFormat, []byte(`"parquet"`), ""},
+		{ArrowDataFormat, []byte(`"arrow"`), ""},
+		{AvroDataFormat, []byte(`"avro"`), ""},
+		{SQLiteDataFormat, []byte(`"sqlite"`), ""},
+		{YAMLDataFormat, []byte(`"yaml"`), ""},
 	}
 	for i, c := range cases {
 		got, err := c.format.MarshalJSON()
@@ -117,6 +146,11 @@ func TestDataFormatUnmarshalJSON(t *testing.T) {
 		{[]byte(`"xlsx"`), XLSXDataFormat, ""},
 		{[]byte(`"cbor"`), CBORDataFormat, ""},
 		{[]byte(`"ndjson"`), NDJSONDataFormat, ""},
+		{[]byte(`"parquet"`), ParquetDataFormat, ""},
+		{[]byte(`"feather"`), ArrowDataFormat, ""},
+		{[]byte(`"avro"`), AvroDataFormat, ""},
+		{[]byte(`"sqlite"`), SQLiteDataFormat, ""},
+		{[]byte(`"yaml"`), YAMLDataFormat, ""},
 	}
 
 	for i, c := range cases {
@@ -134,3 +168,56 @@ func TestDataFormatUnmarshalJSON(t *testing.T) {
 
 	}
 }
+
+func TestRegisterDataFormat(t *testing.T) {
+	parse := func(opts map[string]interface{}) (FormatConfig, error) { return NewJSONOptions(opts) }
+	df, err := RegisterDataFormat(DataFormatDefinition{Name: "test_format", Aliases: []string{"tf"}, ParseConfig: parse, Tabular: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		// remove the format so other tests only see built-in formats
+		dataFormatsLk.Lock()
+		delete(dataFormats, df)
+		delete(dataFormatNames, "test_format")
+		delete(dataFormatNames, "tf")
+		customDataFormats = customDataFormats[:len(customDataFormats)-1]
+		dataFormatsLk.Unlock()
+	}()
+	if df <= YAMLDataFormat {
+		t.Errorf("expected registered format to come after built-in formats. got: %d", df)
+	}
+	if df.String() != "test_format" {
+		t.Errorf("string mismatch. expected: %q, got: %q", "test_format", df.String())
+	}
+	for _, s := range []string{"test_format", ".tf"} {
+		if got, err := ParseDataFormatString(s); err != nil || got != df {
+			t.Errorf("parsing %q: expected: %d, got: %d, err: %v", s, df, got, err)
+		}
+	}
+	if _, err := ParseFormatConfigMap(df, nil); err != nil {
+		t.Errorf("parsing config: %s", err)
+	}
+	if !(&Structure{Format: "test_format"}).RequiresTabularSchema() {
+		t.Error("expected registered tabular format to require a tabular schema")
+	}
+	formats := SupportedDataFormats()
+	if formats[len(formats)-1] != df {
+		t.Error("expected registered format to be supported")
+	}
+
+	bad := []struct {
+		def DataFormatDefinition
+		err string
+	}{
+		{DataFormatDefinition{}, "data format name is required"},
+		{DataFormatDefinition{Name: "csv"}, `data format name "csv" is already registered`},
+		{DataFormatDefinition{Name: "other", Aliases: []string{"tf"}}, `data format name "tf" is already registered`},
+		{DataFormatDefinition{Name: ".other"}, `data format name ".other" cannot start with '.'`},
+	}
+	for i, c := range bad {
+		if _, err := RegisterDataFormat(c.def); err == nil || err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package detect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/qfs"
+)
+
+// ArchiveFromFilename checks if a filename names an archive by examining
+// file extensions, returning archive & compression formats. Recognizes
+// ".zip", ".tar", ".tgz" and ".tar.[compression_format]" files
+func ArchiveFromFilename(filename string) (archive.Format, compression.Format, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".tgz" {
+		return archive.FmtTar, compression.FmtGZip, true
+	}
+
+	comp, err := compression.ParseFormat(strings.TrimPrefix(ext, "."))
+	if err == nil {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(filename, filepath.Ext(filename))))
+	} else {
+		comp = compression.FmtNone
+	}
+
+	af, err := archive.ParseFormat(strings.TrimPrefix(ext, "."))
+	if err != nil {
+		return archive.FmtNone, compression.FmtNone, false
+	}
+	return af, comp, true
+}
+
+// ArchiveMembers lists files in an archive that could be a dataset body:
+// regular files with an extension of a supported data format. Compressed
+// members aren't candidates
+func ArchiveMembers(af archive.Format, comp compression.Format, data io.Reader) ([]string, error) {
+	if comp != compression.FmtNone {
+		rc, err := compression.Decompressor(comp.String(), data)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		data = rc
+	}
+
+	members, err := archive.Members(af.String(), data)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{}
+	for _, m := range members {
+		if _, mcomp, err := FormatFromFilename(m.Name); err == nil && mcomp == compression.FmtNone {
+			candidates = append(candidates, m.Name)
+		}
+	}
+	return candidates, nil
+}
+
+// FromArchive detects the structure of a dataset body bundled in an archive,
+// determining the data format from the member's file extension. Returns a
+// detected structure, the number of bytes read from the member, and any error
+func FromArchive(af archive.Format, comp compression.Format, member string, data io.Reader) (st *dataset.Structure, n int, err error) {
+	format, mcomp, err := FormatFromFilename(member)
+	if err != nil {
+		return nil, 0, err
+	}
+	if mcomp != compression.FmtNone {
+		return nil, 0, fmt.Errorf("compressed archive members are not supported: %q", member)
+	}
+
+	st = &dataset.Structure{
+		Archive:       af.String(),
+		ArchiveMember: member,
+		Compression:   comp.String(),
+		Format:        format.String(),
+	}
+
+	if comp != compression.FmtNone {
+		rc, err := compression.Decompressor(comp.String(), data)
+		if err != nil {
+			return st, 0, err
+		}
+		defer rc.Close()
+		data = rc
+	}
+	mr, err := archive.OpenMember(af.String(), data, member)
+	if err != nil {
+		return st, 0, err
+	}
+	defer mr.Close()
+
+	n, err = detectSchema(st, mr)
+	return st, n, err
+}
+
+// bodyArchive determines if a body is an archive from a structure's archive
+// field, falling back to the body's filename
+func bodyArchive(st *dataset.Structure, filename string) (archive.Format, compression.Format, bool) {
+	if st != nil && st.Archive != "" {
+		af, err := archive.ParseFormat(st.Archive)
+		if err != nil {
+			// leave invalid archive formats for readers to report
+			return archive.FmtNone, compression.FmtNone, false
+		}
+		_, comp, _ := ArchiveFromFilename(filename)
+		if st.Compression != "" {
+			comp = compression.Format(st.Compression)
+		}
+		return af, comp, true
+	}
+	return ArchiveFromFilename(filename)
+}
+
+// archiveStructure detects the structure of a dataset with an archived body.
+// The entire archive is read into memory
+func archiveStructure(ds *dataset.Dataset, body qfs.File, af archive.Format, comp compression.Format) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	// glue the archive back onto the dataset
+	defer ds.SetBodyFile(qfs.NewMemfileBytes(body.FileName(), data))
+
+	member := ""
+	if ds.Structure != nil {
+		member = ds.Structure.ArchiveMember
+	}
+	if member == "" {
+		candidates, err := ArchiveMembers(af, comp, bytes.NewReader(data))
+		if err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("listing archive members: %w", err)
+		}
+		if member, err = chooseArchiveMember(candidates); err != nil {
+			return err
+		}
+	}
+
+	guessedStructure, _, err := FromArchive(af, comp, member, bytes.NewReader(data))
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("determining dataset structure: %w", err)
+	}
+	assignGuessedStructure(ds, guessedStructure)
+	return nil
+}
+
+// chooseArchiveMember picks the body file from a list of candidates. With
+// more than one candidate the body must be named "body", as it is in archives
+// of exported datasets
+func chooseArchiveMember(candidates []string) (string, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	var bodies []string
+	for _, c := range candidates {
+		base := path.Base(c)
+		if strings.TrimSuffix(base, path.Ext(base)) == "body" {
+			bodies = append(bodies, c)
+		}
+	}
+	if len(bodies) == 1 {
+		return bodies[0], nil
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("archive has no files with a supported data format")
+	}
+	return "", fmt.Errorf("archive has multiple possible body files, set archiveMember to one of: %s", strings.Join(candidates, ", "))
+}
@@ -0,0 +1,185 @@
+package detect
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/qfs"
+)
+
+func TestArchiveFromFilename(t *testing.T) {
+	cases := []struct {
+		path       string
+		expectFmt  archive.Format
+		expectComp compression.Format
+		ok         bool
+	}{
+		{"bundle.zip", archive.FmtZip, compression.FmtNone, true},
+		{"bundle.ZIP", archive.FmtZip, compression.FmtNone, true},
+		{"bundle.tar", archive.FmtTar, compression.FmtNone, true},
+		{"bundle.tgz", archive.FmtTar, compression.FmtGZip, true},
+		{"bundle.tar.gz", archive.FmtTar, compression.FmtGZip, true},
+		{"bundle.tar.xz", archive.FmtTar, compression.FmtXZ, true},
+
+		{"body.csv", archive.FmtNone, compression.FmtNone, false},
+		{"body.csv.gz", archive.FmtNone, compression.FmtNone, false},
+		{"bundle", archive.FmtNone, compression.FmtNone, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			af, comp, ok := ArchiveFromFilename(c.path)
+			if ok != c.ok {
+				t.Errorf("ok mismatch. expected: %t, got: %t", c.ok, ok)
+			}
+			if af != c.expectFmt {
+				t.Errorf("archive format mismatch. expected: %q, got: %q", c.expectFmt, af)
+			}
+			if comp != c.expectComp {
+				t.Errorf("compression format mismatch. expected: %q, got: %q", c.expectComp, comp)
+			}
+		})
+	}
+}
+
+// testArchive creates a zip archive from pairs of file names & contents
+func testArchive(t *testing.T, files ...string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[i+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveMembers(t *testing.T) {
+	data, err := ioutil.ReadFile("../testdata/zip/exported.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ArchiveMembers(archive.FmtZip, compression.FmtNone, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"dataset.json", "body.csv"}, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ArchiveMembers(archive.FmtZip, compression.FmtNone, bytes.NewReader([]byte("not a zip"))); err == nil {
+		t.Errorf("expected invalid archive to error")
+	}
+}
+
+func TestFromArchive(t *testing.T) {
+	data := testArchive(t,
+		"data/stations.csv", "id,name\n1,central park\n",
+		"data/stations.csv.gz", "",
+	)
+
+	st, _, err := FromArchive(archive.FmtZip, compression.FmtNone, "data/stations.csv", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &dataset.Structure{
+		Archive:       "zip",
+		ArchiveMember: "data/stations.csv",
+		Format:        "csv",
+		FormatConfig:  map[string]interface{}{"headerRow": true, "lazyQuotes": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "id", "type": "integer"},
+					map[string]interface{}{"title": "name", "type": "string"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, st); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := FromArchive(archive.FmtZip, compression.FmtNone, "data/stations.csv.gz", bytes.NewReader(data)); err == nil {
+		t.Errorf("expected compressed member to error")
+	}
+	if _, _, err := FromArchive(archive.FmtZip, compression.FmtNone, "data/missing.csv", bytes.NewReader(data)); err == nil {
+		t.Errorf("expected missing member to error")
+	}
+}
+
+func TestStructureArchive(t *testing.T) {
+	exported, err := ioutil.ReadFile("../testdata/zip/exported.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiple := testArchive(t,
+		"a.csv", "a,b\n1,2\n",
+		"b.json", "[1,2]",
+	)
+
+	cases := []struct {
+		description string
+		filename    string
+		st          *dataset.Structure
+		data        []byte
+		member      string
+		format      string
+		err         string
+	}{
+		{"exported dataset", "export.zip", nil, exported, "body.csv", "csv", ""},
+		{"named member", "export.zip", &dataset.Structure{ArchiveMember: "dataset.json"}, exported, "dataset.json", "json", ""},
+		{"archive field", "export", &dataset.Structure{Archive: "zip", ArchiveMember: "b.json"}, multiple, "b.json", "json", ""},
+		{"multiple candidates", "bundle.zip", nil, multiple, "", "", "archive has multiple possible body files, set archiveMember to one of: a.csv, b.json"},
+		{"no candidates", "bundle.zip", nil, testArchive(t, "README.md", "# hi"), "", "", "archive has no files with a supported data format"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ds := &dataset.Dataset{Structure: c.st}
+			ds.SetBodyFile(qfs.NewMemfileBytes(c.filename, c.data))
+			err := Structure(ds)
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: %q, got: %v", c.err, err)
+			}
+
+			got, err := ioutil.ReadAll(ds.BodyFile())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.data, got) {
+				t.Errorf("expected archive body to be preserved")
+			}
+			if c.err != "" {
+				return
+			}
+
+			if ds.Structure.Archive != "zip" {
+				t.Errorf("archive mismatch. expected: %q, got: %q", "zip", ds.Structure.Archive)
+			}
+			if ds.Structure.ArchiveMember != c.member {
+				t.Errorf("archive member mismatch. expected: %q, got: %q", c.member, ds.Structure.ArchiveMember)
+			}
+			if ds.Structure.Format != c.format {
+				t.Errorf("format mismatch. expected: %q, got: %q", c.format, ds.Structure.Format)
+			}
+			if ds.Structure.Schema == nil {
+				t.Errorf("expected schema to be detected")
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// ArrowSchema determines a tabular json schema from the schema of an arrow IPC
+// stream or file. Arrow files keep their schema in a footer, so file-formatted
+// data is consumed entirely, streams are only read up to the schema message
+func ArrowSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	r, err := dsio.NewArrowReader(resource, tr)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, tr.BytesRead(), fmt.Errorf("invalid arrow data: %s", err.Error())
+	}
+	defer r.Close()
+
+	schema, err = dsio.JSONSchemaFromArrowSchema(r.ArrowSchema())
+	return schema, tr.BytesRead(), err
+}
@@ -0,0 +1,57 @@
+package detect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestArrowSchema(t *testing.T) {
+	if _, _, err := ArrowSchema(&dataset.Structure{}, strings.NewReader("ARROW1")); err == nil {
+		t.Error("expected invalid arrow data to error")
+	}
+
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+				map[string]interface{}{"title": "ratio", "type": "number"},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+				map[string]interface{}{"title": "meta", "type": []interface{}{"object", "array"}},
+			},
+		},
+	}
+
+	for _, stream := range []bool{false, true} {
+		st := &dataset.Structure{Format: "arrow", Schema: schema, FormatConfig: map[string]interface{}{"stream": stream}}
+		buf := &bytes.Buffer{}
+		w, err := dsio.NewArrowWriter(st, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteEntry(dsio.Entry{Value: []interface{}{"a", 1, 1.5, true, nil}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, n, err := ArrowSchema(&dataset.Structure{}, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Errorf("stream %t: expected bytes to be read", stream)
+		}
+		if diff := cmp.Diff(schema, got); diff != "" {
+			t.Errorf("stream %t: result mismatch (-want +got):\n%s", stream, diff)
+		}
+	}
+}
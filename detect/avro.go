@@ -0,0 +1,24 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// AvroSchema determines a tabular json schema from the avro schema embedded in
+// the header of an avro object container file
+func AvroSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	r, err := dsio.NewAvroReader(resource, tr)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, tr.BytesRead(), fmt.Errorf("invalid avro data: %s", err.Error())
+	}
+	defer r.Close()
+
+	schema, err = dsio.JSONSchemaFromAvroSchema(r.AvroSchema())
+	return schema, tr.BytesRead(), err
+}
@@ -0,0 +1,54 @@
+package detect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestAvroSchema(t *testing.T) {
+	if _, _, err := AvroSchema(&dataset.Structure{}, strings.NewReader("Obj")); err == nil {
+		t.Error("expected invalid avro data to error")
+	}
+
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string", "description": "a name"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+				map[string]interface{}{"title": "ratio", "type": "number"},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+				map[string]interface{}{"title": "meta", "type": []interface{}{"object", "array"}},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewAvroWriter(&dataset.Structure{Format: "avro", Schema: schema}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(dsio.Entry{Value: []interface{}{"a", 1, 1.5, true, nil}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, n, err := AvroSchema(&dataset.Structure{}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == 0 {
+		t.Error("expected bytes to be read")
+	}
+	if diff := cmp.Diff(schema, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -2,6 +2,7 @@ package detect
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 
@@ -16,9 +17,16 @@ const (
 	cborBaseTag                = 0xc0
 )
 
+// cborSelfDescribe is the encoded self-describe tag (55799)
+var cborSelfDescribe = []byte{0xd9, 0xd9, 0xf7}
+
 // CBORSchema determines the field names and types of an io.Reader of CBOR-formatted data, returning a json schema
 func CBORSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
 	rd := bufio.NewReader(data)
+	// skip the self-describe tag some encoders prefix CBOR data with
+	if prefix, e := rd.Peek(len(cborSelfDescribe)); e == nil && bytes.Equal(prefix, cborSelfDescribe) {
+		n, _ = rd.Discard(len(cborSelfDescribe))
+	}
 	bd, err := rd.ReadByte()
 	n++
 	if err != nil && err != io.EOF {
@@ -0,0 +1,294 @@
+package detect
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/vals"
+)
+
+// csvSniffSize is the number of bytes SniffCSV examines when detecting a
+// CSV schema
+const csvSniffSize = 64 * 1024
+
+// csvSniffLines caps the number of lines SniffCSV examines
+const csvSniffLines = 200
+
+var (
+	// csvSeparators are the field separators SniffCSV considers, in order of
+	// preference
+	csvSeparators = []rune{',', '\t', ';', '|'}
+	// csvQuoteChars are the quote characters SniffCSV considers, in order of
+	// preference
+	csvQuoteChars = []rune{'"', '\''}
+)
+
+// SniffCSV infers the dialect of a sample of CSV data: the separator (comma,
+// tab, semicolon or pipe), quote character, how many lines of preamble come
+// before the first record, and whether the first record is a header row.
+// Separator & QuoteChar are only set when they differ from the CSV defaults.
+// A sample that ends mid-line has it's last line ignored
+func SniffCSV(sample []byte) *dataset.CSVOptions {
+	opts := &dataset.CSVOptions{}
+	lines := csvSampleLines(sample)
+
+	var (
+		best      = csvLineStats{}
+		bestSep   = ','
+		bestQuote = '"'
+	)
+	for _, sep := range csvSeparators {
+		for _, quote := range csvQuoteChars {
+			stats := sniffCSVLines(lines, sep, quote)
+			if stats.fields < 2 || quote != '"' && stats.quoted == 0 {
+				continue
+			}
+			if stats.betterThan(best) {
+				best, bestSep, bestQuote = stats, sep, quote
+			}
+		}
+	}
+
+	if bestSep != ',' {
+		opts.Separator = bestSep
+	}
+	if bestQuote != '"' {
+		opts.QuoteChar = bestQuote
+	}
+
+	first := 0
+	if best.fields > 1 {
+		first = best.first
+		opts.SkipRows = best.skipRows
+	}
+
+	var rows [][]string
+	for _, line := range lines[first:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := splitCSVLine(line, bestSep, bestQuote)
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = strings.TrimSpace(f.value)
+			if bestSep != ',' && decimalComma.MatchString(row[i]) {
+				row[i] = strings.Replace(row[i], ",", ".", 1)
+			}
+		}
+		rows = append(rows, row)
+	}
+	opts.HeaderRow = csvHeaderRow(rows)
+	return opts
+}
+
+// decimalComma matches numbers written with a decimal comma, eg: "891,7"
+var decimalComma = regexp.MustCompile(`^-?[0-9]+,[0-9]+$`)
+
+// csvHeaderRow guesses if the first of a set of rows is a header. Columns
+// where every row after the first has the same non-string type vote for a
+// header if the first row's value has a different type, and against it
+// otherwise. Without any votes the first row is a header if it has no
+// numeric, boolean or empty values
+func csvHeaderRow(rows [][]string) bool {
+	if len(rows) == 0 {
+		return false
+	}
+
+	// integers & numbers are the same type for voting, empty cells are ignored
+	cellType := func(cell string) vals.Type {
+		if cell == "" {
+			return vals.TypeUnknown
+		}
+		if t := vals.ParseType([]byte(cell)); t != vals.TypeInteger {
+			return t
+		}
+		return vals.TypeNumber
+	}
+
+	header, data := 0, 0
+	for i, cell := range rows[0] {
+		colType := vals.TypeUnknown
+		for _, row := range rows[1:] {
+			if i >= len(row) {
+				continue
+			}
+			t := cellType(row[i])
+			if colType == vals.TypeUnknown {
+				colType = t
+			} else if t != vals.TypeUnknown && t != colType {
+				colType = vals.TypeString
+				break
+			}
+		}
+		if colType == vals.TypeUnknown || colType == vals.TypeString || colType == vals.TypeNull || cell == "" {
+			continue
+		}
+		if cellType(cell) == colType {
+			data++
+		} else {
+			header++
+		}
+	}
+
+	if header+data > 0 {
+		return header > data
+	}
+	return possibleCsvHeaderRow(rows[0])
+}
+
+// csvLineStats describes how a sample splits into fields for one dialect
+type csvLineStats struct {
+	// fields is the most common number of fields per line
+	fields int
+	// consistent is the number of lines with the most common number of fields
+	consistent int
+	// quoted is the number of fields wrapped in the quote character
+	quoted int
+	// digitSplits counts separators between two digits, which are more likely
+	// decimal commas than separators
+	digitSplits int
+	// first is the index of the first line with the most common number of
+	// fields, skipRows the number of lines to skip to reach it, which is zero
+	// unless narrower non-blank lines come first
+	first, skipRows int
+}
+
+// betterThan compares stats of two dialects, preferring the dialect that
+// splits the most lines into the same number of fields
+func (s csvLineStats) betterThan(o csvLineStats) bool {
+	if s.consistent != o.consistent {
+		return s.consistent > o.consistent
+	}
+	if s.digitSplits != o.digitSplits {
+		return s.digitSplits < o.digitSplits
+	}
+	if s.fields != o.fields {
+		return s.fields > o.fields
+	}
+	return s.quoted > o.quoted
+}
+
+func sniffCSVLines(lines []string, sep, quote rune) csvLineStats {
+	stats := csvLineStats{}
+	counts := make([]int, len(lines))
+	freq := map[int]int{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := splitCSVLine(line, sep, quote)
+		counts[i] = len(fields)
+		freq[len(fields)]++
+		for _, f := range fields {
+			if f.quoted {
+				stats.quoted++
+			}
+		}
+		runes := []rune(line)
+		for j := 1; j+1 < len(runes); j++ {
+			if runes[j] == sep && unicode.IsDigit(runes[j-1]) && unicode.IsDigit(runes[j+1]) {
+				stats.digitSplits++
+			}
+		}
+	}
+
+	for n, f := range freq {
+		// ties go to the wider layout
+		if f > stats.consistent || f == stats.consistent && n > stats.fields {
+			stats.fields, stats.consistent = n, f
+		}
+	}
+
+	preamble := false
+	for i, n := range counts {
+		if n == stats.fields {
+			stats.first = i
+			break
+		}
+		if n > stats.fields {
+			// wider lines aren't preamble
+			preamble = false
+			break
+		}
+		if n > 0 {
+			preamble = true
+		}
+	}
+	if preamble {
+		stats.skipRows = stats.first
+	}
+	return stats
+}
+
+// csvSampleLines splits a sample into lines, dropping a trailing partial line
+// from a sample that fills the sniffing buffer
+func csvSampleLines(sample []byte) []string {
+	if len(sample) >= csvSniffSize {
+		if i := bytes.LastIndexAny(sample, "\r\n"); i >= 0 {
+			sample = sample[:i]
+		}
+	}
+
+	var lines []string
+	text := string(sample)
+	for len(text) > 0 && len(lines) < csvSniffLines {
+		i := strings.IndexAny(text, "\r\n")
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i])
+		if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+			i++
+		}
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// csvField is a field split from a line of CSV text
+type csvField struct {
+	value  string
+	quoted bool
+}
+
+// splitCSVLine splits a single line of CSV into fields. Quoted fields that
+// continue past the end of the line end with the line
+func splitCSVLine(line string, sep, quote rune) []csvField {
+	var (
+		fields             []csvField
+		buf                strings.Builder
+		field              = csvField{}
+		inQuote, wasQuoted bool
+		runes              = []rune(line)
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inQuote && r == quote:
+			if i+1 < len(runes) && runes[i+1] == quote {
+				buf.WriteRune(quote)
+				i++
+			} else {
+				inQuote = false
+			}
+		case inQuote:
+			buf.WriteRune(r)
+		case r == quote && strings.TrimSpace(buf.String()) == "" && !wasQuoted:
+			buf.Reset()
+			inQuote, wasQuoted = true, true
+		case r == sep:
+			field.value, field.quoted = buf.String(), wasQuoted
+			fields = append(fields, field)
+			buf.Reset()
+			wasQuoted = false
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	field.value, field.quoted = buf.String(), wasQuoted && !inQuote
+	return append(fields, field)
+}
@@ -0,0 +1,85 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestSniffCSV(t *testing.T) {
+	cases := []struct {
+		description string
+		sample      string
+		expect      *dataset.CSVOptions
+	}{
+		{"empty", "", &dataset.CSVOptions{}},
+		{"single column", "name\nbob\nsue\n", &dataset.CSVOptions{HeaderRow: true}},
+		{"comma with header", "name,count\nbob,1\nsue,2\n", &dataset.CSVOptions{HeaderRow: true}},
+		{"comma without header", "bob,1\nsue,2\n", &dataset.CSVOptions{}},
+		{"tab", "name\tcount\nbob\t1\nsue\t2\n", &dataset.CSVOptions{HeaderRow: true, Separator: '\t'}},
+		{"pipe", "bob|1|a,b\nsue|2|c,d\n", &dataset.CSVOptions{Separator: '|'}},
+		{"semicolon & decimal commas",
+			"stadt;einwohner;fläche\nBerlin;3644826;891,7\nHamburg;1841179;755,2\n",
+			&dataset.CSVOptions{HeaderRow: true, Separator: ';'}},
+		{"semicolon & decimal commas without header",
+			"Berlin;891,7\nHamburg;755,2\n",
+			&dataset.CSVOptions{Separator: ';'}},
+		{"quoted separators",
+			"\"Jacksonville, Fla.\",2335\n\"El Paso, Texas\",2260\nChicago,12120\n",
+			&dataset.CSVOptions{}},
+		{"single quotes",
+			"'Jacksonville, Fla.',2335\n'El Paso, Texas',2260\n'Chicago',12120\n",
+			&dataset.CSVOptions{QuoteChar: '\''}},
+		{"preamble",
+			"Station report\r\nexported 2021-08-01\r\n\r\ncity;count\r\nx;1\r\ny;2\r\n",
+			&dataset.CSVOptions{HeaderRow: true, Separator: ';', SkipRows: 3}},
+		{"leading blank lines aren't preamble",
+			"\n\ncity,count\nx,1\n",
+			&dataset.CSVOptions{HeaderRow: true}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			got := SniffCSV([]byte(c.sample))
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVSchemaDialect(t *testing.T) {
+	data := "Station report\nexported 2021-08-01\nstadt;einwohner;fläche\nBerlin;3644826;891,7\nHamburg;1841179;755,2\n"
+	st := &dataset.Structure{Format: "csv"}
+	schema, _, err := CSVSchema(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectConfig := map[string]interface{}{
+		"headerRow":  true,
+		"lazyQuotes": true,
+		"separator":  ";",
+		"skipRows":   2,
+	}
+	if diff := cmp.Diff(expectConfig, st.FormatConfig); diff != "" {
+		t.Errorf("format config mismatch (-want +got):\n%s", diff)
+	}
+
+	expectSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "stadt", "type": "string"},
+				map[string]interface{}{"title": "einwohner", "type": "integer"},
+				map[string]interface{}{"title": "fläche", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSchema, schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	logger "github.com/ipfs/go-log"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
 )
 
@@ -26,6 +27,11 @@ var (
 // elements of a structure component required to make the dataset readable.
 // A minimum structure component has non-zero Format and Schema fields, and
 // may need additional FormatConfig settings to parse properly.
+// Formats are determined from the body's filename, falling back to the
+// contents of the body when the filename has no recognized extension or
+// contradicts the data's compression or magic number. Bodies in zip or tar
+// archives are detected from the archive member the structure names, or the
+// only member that could be a body when no member is named.
 // Structure will not mutate any component fields that are not a default value
 func Structure(ds *dataset.Dataset) error {
 	if ds == nil {
@@ -43,49 +49,89 @@ func Structure(ds *dataset.Dataset) error {
 	if body == nil {
 		return dataset.ErrNoBody
 	}
+
+	if af, comp, ok := bodyArchive(ds.Structure, body.FileName()); ok {
+		return archiveStructure(ds, body, af, comp)
+	}
+
+	// sniff the body first, sniffed bytes are replayed by rdr
+	sniffed, sniffedComp, magic, rdr, sniffErr := sniffFormat(body)
 	// use a TeeReader that writes to a buffer to preserve data
 	buf := &bytes.Buffer{}
-	tr := io.TeeReader(body, buf)
-	var df dataset.DataFormat
+	tr := io.TeeReader(rdr, buf)
 
 	df, comp, err := FormatFromFilename(body.FileName())
 	if err != nil {
-		log.Debug(err.Error())
-		return fmt.Errorf("invalid data format: %w", err)
+		if sniffErr != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("invalid data format: %w", err)
+		}
+		// no usable file extension, rely on the contents of the body
+		df, comp = sniffed, sniffedComp
+	} else if sniffErr == nil {
+		// compression magic numbers & binary format signatures are more
+		// trustworthy than a file extension, as are multiple lines of JSON values
+		comp = sniffedComp
+		if magic || df == dataset.JSONDataFormat && sniffed == dataset.NDJSONDataFormat {
+			df = sniffed
+		}
 	}
 
-	guessedStructure, _, err := FromReader(df, comp, tr)
+	guessedStructure := &dataset.Structure{
+		Format:      df.String(),
+		Compression: comp.String(),
+	}
+	// format configuration set ahead of time can shape the detected schema, as
+	// the entriesPointer of JSON bodies does
+	if ds.Structure != nil && ds.Structure.Format == df.String() {
+		guessedStructure.FormatConfig = ds.Structure.FormatConfig
+	}
+	_, err = fromReader(guessedStructure, tr)
 	if err != nil {
 		log.Debug(err.Error())
 		return fmt.Errorf("determining dataset structure: %w", err)
 	}
 
-	// attach the structure, schema, and formatConfig, as appropriate
+	assignGuessedStructure(ds, guessedStructure)
+
+	// glue whatever we just read back onto the reader
+	// TODO (b5)- this may ruin readers that transparently depend on a read-closer
+	// we should consider a method on qfs.File that allows this non-destructive read pattern
+	size := int64(-1)
+	if sizef, ok := body.(qfs.SizeFile); ok {
+		size = sizef.Size()
+	}
+	ds.SetBodyFile(qfs.NewMemfileReaderSize(body.FileName(), io.MultiReader(buf, rdr), size))
+	return nil
+}
+
+// assignGuessedStructure attaches the structure, schema, and formatConfig of
+// a detected structure to a dataset, as appropriate
+func assignGuessedStructure(ds *dataset.Dataset, guessedStructure *dataset.Structure) {
 	if ds.Structure == nil {
 		ds.Structure = guessedStructure
 	}
+	if ds.Structure.Archive == "" {
+		ds.Structure.Archive = guessedStructure.Archive
+	}
+	if ds.Structure.ArchiveMember == "" {
+		ds.Structure.ArchiveMember = guessedStructure.ArchiveMember
+	}
 	if ds.Structure.Format == "" {
 		ds.Structure.Format = guessedStructure.Format
 	}
 	if ds.Structure.Compression == "" {
 		ds.Structure.Compression = guessedStructure.Compression
 	}
+	if ds.Structure.Encoding == "" && ds.Structure.Format == guessedStructure.Format {
+		ds.Structure.Encoding = guessedStructure.Encoding
+	}
 	if ds.Structure.FormatConfig == nil && ds.Structure.Format == guessedStructure.Format {
 		ds.Structure.FormatConfig = guessedStructure.FormatConfig
 	}
 	if ds.Structure.Schema == nil {
 		ds.Structure.Schema = guessedStructure.Schema
 	}
-
-	// glue whatever we just read back onto the reader
-	// TODO (b5)- this may ruin readers that transparently depend on a read-closer
-	// we should consider a method on qfs.File that allows this non-destructive read pattern
-	size := int64(-1)
-	if sizef, ok := body.(qfs.SizeFile); ok {
-		size = sizef.Size()
-	}
-	ds.SetBodyFile(qfs.NewMemfileReaderSize(body.FileName(), io.MultiReader(buf, body), size))
-	return nil
 }
 
 // needsFormatConfig returns true if a given structure needs a FormatConfig
@@ -127,16 +173,44 @@ func FromFile(path string) (st *dataset.Structure, err error) {
 }
 
 // FromReader detects a dataset structure from a reader and data format, returning a detected dataset
-// structure, the number of bytes read from the reader, and any error
+// structure, the number of bytes read from the reader, and any error. Compressed data is decompressed,
+// the character encoding of text-based formats is guessed, and data that isn't utf-8 is transcoded
+// before schema detection
 func FromReader(format dataset.DataFormat, comp compression.Format, data io.Reader) (st *dataset.Structure, n int, err error) {
 	st = &dataset.Structure{
 		Format:      format.String(),
 		Compression: comp.String(),
 	}
-	st.Schema, n, err = Schema(st, data)
+	n, err = fromReader(st, data)
 	return
 }
 
+// fromReader detects the schema of a structure with format & compression
+// fields set from a reader of data
+func fromReader(st *dataset.Structure, data io.Reader) (n int, err error) {
+	if st.Compression != "" {
+		rc, err := compression.Decompressor(st.Compression, data)
+		if err != nil {
+			return 0, err
+		}
+		defer rc.Close()
+		data = rc
+	}
+	return detectSchema(st, data)
+}
+
+// detectSchema sets the encoding, schema & format configuration of a
+// structure from decompressed data. Any format configuration the structure
+// already has is available to schema detection
+func detectSchema(st *dataset.Structure, data io.Reader) (n int, err error) {
+	data = maybeDecodeText(st, data)
+	// schema detection reads plain data, without compression or archiving
+	plain := &dataset.Structure{Format: st.Format, FormatConfig: st.FormatConfig}
+	st.Schema, n, err = Schema(plain, data)
+	st.FormatConfig = plain.FormatConfig
+	return n, err
+}
+
 // FormatFromFilename extracts data & compression formats from a filename string
 // by examining file extensions. Assumes that when multiple extensions are
 // present they come in the order: filename.[data_format].[compression_format]
@@ -150,26 +224,13 @@ func FormatFromFilename(path string) (dataset.DataFormat, compression.Format, er
 		compFmt = compression.FmtNone
 	}
 
-	switch ext {
-	case ".cbor":
-		return dataset.CBORDataFormat, compFmt, nil
-	case ".json":
-		return dataset.JSONDataFormat, compFmt, nil
-	case ".csv":
-		return dataset.CSVDataFormat, compFmt, nil
-	case ".xml":
-		return dataset.XMLDataFormat, compFmt, nil
-	case ".xlsx":
-		return dataset.XLSXDataFormat, compFmt, nil
-	case ".jsonl":
-		return dataset.NDJSONDataFormat, compFmt, nil
-	case ".ndjson":
-		return dataset.NDJSONDataFormat, compFmt, nil
-	case "":
+	if ext == "" {
 		return dataset.UnknownDataFormat, compFmt, errors.New("no file extension provided")
-	default:
-		return dataset.UnknownDataFormat, compFmt, fmt.Errorf("unsupported file type: '%s'", ext)
 	}
+	if df, ok := dsio.FormatFromExtension(ext); ok {
+		return df, compFmt, nil
+	}
+	return dataset.UnknownDataFormat, compFmt, fmt.Errorf("unsupported file type: '%s'", ext)
 }
 
 // ErrInvalidTabularData indicates non-tabular data in a context that expects
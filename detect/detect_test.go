@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/dstest"
 	"github.com/qri-io/qfs"
 )
@@ -135,6 +137,7 @@ func TestFromFile(t *testing.T) {
 		{"testdata/invalid.cbor", "", "invalid top-level type for CBOR data. cbor datasets must begin with either an array or map"},
 		{"testdata/cbor_object.cbor", "testdata/cbor_object.structure.json", ""},
 		{"testdata/cbor_array.cbor", "testdata/cbor_array.structure.json", ""},
+		{"testdata/cbor_self_describe.cbor", "testdata/cbor_array.structure.json", ""},
 	}
 
 	for i, c := range cases {
@@ -196,10 +199,21 @@ func TestFormatFromFilename(t *testing.T) {
 
 		{"foo/bar/baz.csv.zst", dataset.CSVDataFormat, compression.FmtZStandard, ""},
 		{"foo/bar/baz.json.gzip", dataset.JSONDataFormat, compression.FmtGZip, ""},
+		{"foo/bar/baz.csv.bz2", dataset.CSVDataFormat, compression.FmtBZip2, ""},
+		{"foo/bar/baz.json.xz", dataset.JSONDataFormat, compression.FmtXZ, ""},
+		{"foo/bar/baz.ndjson.lz4", dataset.NDJSONDataFormat, compression.FmtLZ4, ""},
+		{"foo/bar/baz.csv.sz", dataset.CSVDataFormat, compression.FmtSnappy, ""},
+		{"foo/bar/baz.json.br", dataset.JSONDataFormat, compression.FmtBrotli, ""},
 		{"foo/bar/baz.xlsx", dataset.XLSXDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.cbor", dataset.CBORDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.jsonl", dataset.NDJSONDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.ndjson", dataset.NDJSONDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.parquet", dataset.ParquetDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.arrow", dataset.ArrowDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.feather", dataset.ArrowDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.avro", dataset.AvroDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.yaml", dataset.YAMLDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.yml", dataset.YAMLDataFormat, compression.FmtNone, ""},
 
 		{"foo/bar/baz.xml.blarg", dataset.UnknownDataFormat, compression.FmtNone, "unsupported file type: '.blarg'"},
 		{"foo/bar/baz", dataset.UnknownDataFormat, compression.FmtNone, "no file extension provided"},
@@ -311,3 +325,35 @@ func mustParseJSONSchema(data []byte) map[string]interface{} {
 	}
 	return v
 }
+
+func TestRegisteredFormat(t *testing.T) {
+	schema := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	df, err := dsio.RegisterFormat(dsio.Format{
+		Name:       "detect_test_format",
+		Extensions: []string{".dtf"},
+		NewReader:  func(st *dataset.Structure, r io.Reader) (dsio.EntryReader, error) { return nil, errors.New("unused") },
+		NewWriter:  func(st *dataset.Structure, w io.Writer) (dsio.EntryWriter, error) { return nil, errors.New("unused") },
+		DetectSchema: func(st *dataset.Structure, data io.Reader) (map[string]interface{}, int, error) {
+			return schema, 0, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotF, gotComp, err := FormatFromFilename("foo/bar.dtf.gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotF != df || gotComp != compression.FmtGZip {
+		t.Errorf("format mismatch. expected: %s, %s got: %s, %s", df, compression.FmtGZip, gotF, gotComp)
+	}
+
+	st, _, err := FromReader(df, compression.FmtNone, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(schema, st.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,7 +1,7 @@
 package detect
 
 import (
-	"encoding/csv"
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,7 +11,7 @@ import (
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
-	"github.com/qri-io/dataset/dsio/replacecr"
+	"github.com/qri-io/dataset/tabular"
 	"github.com/qri-io/dataset/vals"
 	"github.com/qri-io/varName"
 )
@@ -28,21 +28,30 @@ func Schema(r *dataset.Structure, data io.Reader) (schema map[string]interface{}
 		return
 	}
 
-	switch r.DataFormat() {
-	case dataset.CBORDataFormat:
-		return CBORSchema(r, data)
-	case dataset.JSONDataFormat:
-		return JSONSchema(r, data)
-	case dataset.CSVDataFormat:
-		return CSVSchema(r, data)
-	case dataset.XLSXDataFormat:
-		return XLSXSchema(r, data)
-	case dataset.NDJSONDataFormat:
-		return NDJSONSchema(r, data)
-	default:
-		err = fmt.Errorf("%q is not supported for field detection", r.Format)
-		return
+	if detect, ok := schemaDetectors[r.DataFormat()]; ok {
+		return detect(r, data)
+	}
+	if f, ok := dsio.LookupFormat(r.DataFormat()); ok && f.DetectSchema != nil {
+		return f.DetectSchema(r, data)
 	}
+
+	err = fmt.Errorf("%q is not supported for field detection", r.Format)
+	return
+}
+
+// schemaDetectors maps built-in data formats to schema detection functions.
+// Formats added with dsio.RegisterFormat provide their own detection
+var schemaDetectors = map[dataset.DataFormat]func(*dataset.Structure, io.Reader) (map[string]interface{}, int, error){
+	dataset.CBORDataFormat:    CBORSchema,
+	dataset.JSONDataFormat:    JSONSchema,
+	dataset.CSVDataFormat:     CSVSchema,
+	dataset.XLSXDataFormat:    XLSXSchema,
+	dataset.NDJSONDataFormat:  NDJSONSchema,
+	dataset.XMLDataFormat:     XMLSchema,
+	dataset.ParquetDataFormat: ParquetSchema,
+	dataset.ArrowDataFormat:   ArrowSchema,
+	dataset.AvroDataFormat:    AvroSchema,
+	dataset.YAMLDataFormat:    YAMLSchema,
 }
 
 type field struct {
@@ -51,21 +60,41 @@ type field struct {
 }
 
 // CSVSchema determines the field names and types of an io.Reader of CSV-formatted data, returning a json schema
+// The CSV dialect is sniffed from the start of the data, setting the separator, quote character, preamble lines to
+// skip and header row in the resource's FormatConfig
 func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
 	tr := dsio.NewTrackedReader(data)
-	r := csv.NewReader(replacecr.Reader(tr))
-	r.FieldsPerRecord = -1
-	r.TrimLeadingSpace = true
-	r.LazyQuotes = true
+	br := bufio.NewReaderSize(tr, csvSniffSize)
+	// read errors are left for the csv reader to encounter
+	sample, _ := br.Peek(csvSniffSize)
+	dialect := SniffCSV(sample)
+
+	opt := dialect.Map()
+	// TODO - for now we're going to assume lazy quotes. we should scan the entire file
+	// for unescaped quotes & only set this to true if that's the case.
+	opt["lazyQuotes"] = true
+	resource.FormatConfig = opt
 
-	opt := map[string]interface{}{
-		// TODO - for now we're going to assume lazy quotes. we should scan the entire file
-		// for unescaped quotes & only set this to true if that's the case.
-		"lazyQuotes": true,
+	// read every row, including any header, as variadic string fields
+	readOpts := map[string]interface{}{
+		"variadicFields": true,
+		"trim":           dataset.CSVTrimStart,
+	}
+	for key, val := range opt {
+		if key != "headerRow" {
+			readOpts[key] = val
+		}
+	}
+	r, err := dsio.NewCSVReader(&dataset.Structure{
+		Format:       dataset.CSVDataFormat.String(),
+		FormatConfig: readOpts,
+		Schema:       tabular.BaseTabularSchema,
+	}, br)
+	if err != nil {
+		return nil, tr.BytesRead(), err
 	}
-	resource.FormatConfig = opt
 
-	header, err := r.Read()
+	header, err := readCSVRecord(r)
 	if err != nil {
 		return nil, tr.BytesRead(), err
 	}
@@ -81,7 +110,7 @@ func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]i
 		types[i] = map[vals.Type]int{}
 	}
 
-	if possibleCsvHeaderRow(header) {
+	if dialect.HeaderRow {
 		for i, f := range fields {
 			f.Title = varName.CreateVarNameFromString(header[i])
 			f.Type = vals.TypeUnknown
@@ -95,7 +124,7 @@ func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]i
 
 	count := 0
 	for {
-		rec, err := r.Read()
+		rec, err := readCSVRecord(r)
 		// max out at 2000 reads
 		if count > 2000 {
 			break
@@ -143,6 +172,20 @@ func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]i
 	return sch, tr.BytesRead(), nil
 }
 
+// readCSVRecord reads a row of strings from a csv reader
+func readCSVRecord(r *dsio.CSVReader) ([]string, error) {
+	ent, err := r.ReadEntry()
+	if err != nil {
+		return nil, err
+	}
+	row, _ := ent.Value.([]interface{})
+	rec := make([]string, len(row))
+	for i, v := range row {
+		rec[i], _ = v.(string)
+	}
+	return rec, nil
+}
+
 func getKeys(m map[vals.Type]int) []vals.Type {
 	keys := make([]vals.Type, 0, len(m))
 	for k := range m {
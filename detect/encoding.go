@@ -0,0 +1,131 @@
+package detect
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// encodingSampleSize is the number of bytes examined to guess the character
+// encoding of a body
+const encodingSampleSize = 64 * 1024
+
+// textFormats are the data formats dsio transcodes with Structure.Encoding
+var textFormats = map[dataset.DataFormat]bool{
+	dataset.CSVDataFormat:    true,
+	dataset.JSONDataFormat:   true,
+	dataset.NDJSONDataFormat: true,
+	dataset.XMLDataFormat:    true,
+	dataset.YAMLDataFormat:   true,
+}
+
+// Encoding guesses the character encoding of a sample of text, returning a
+// name dsio.LookupEncoding understands. UTF-8 text, which includes plain
+// ASCII, gives an empty string. UTF-16 is only recognized by its byte order
+// mark. Text that isn't valid UTF-8 is checked for Japanese Shift-JIS, and
+// otherwise assumed to be windows-1252 if it uses any of the characters
+// windows-1252 adds to latin-1. The sample may be cut off at any byte
+func Encoding(sample []byte) string {
+	if len(sample) >= 2 && (sample[0] == 0xFF && sample[1] == 0xFE || sample[0] == 0xFE && sample[1] == 0xFF) {
+		return "utf-16"
+	}
+	if utf8.Valid(trimPartialRune(sample)) {
+		return ""
+	}
+	if isShiftJIS(sample) {
+		return "shift_jis"
+	}
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F {
+			return "windows-1252"
+		}
+	}
+	return "iso-8859-1"
+}
+
+// trimPartialRune drops an incomplete utf-8 sequence from the end of a sample
+func trimPartialRune(sample []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(sample); i++ {
+		if b := sample[len(sample)-i]; b < utf8.RuneSelf {
+			break
+		} else if utf8.RuneStart(b) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				return sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	return sample
+}
+
+// isShiftJIS checks a sample is well-formed Shift-JIS containing at least
+// one double-byte character, and that every non-ASCII character it decodes
+// to is Japanese script or punctuation
+func isShiftJIS(sample []byte) bool {
+	doubleByte := false
+	for i := 0; i < len(sample); i++ {
+		b := sample[i]
+		switch {
+		case b < 0x80, b >= 0xA1 && b <= 0xDF:
+			// ascii or half-width katakana
+		case b >= 0x81 && b <= 0x9F, b >= 0xE0 && b <= 0xFC:
+			if i+1 == len(sample) {
+				// sample cut off mid-character, drop the lead byte & stop
+				sample = sample[:i]
+				continue
+			}
+			if t := sample[i+1]; t < 0x40 || t == 0x7F || t > 0xFC {
+				return false
+			}
+			doubleByte = true
+			i++
+		default:
+			return false
+		}
+	}
+	if !doubleByte {
+		return false
+	}
+
+	text, err := japanese.ShiftJIS.NewDecoder().Bytes(sample)
+	if err != nil {
+		return false
+	}
+	for _, r := range string(text) {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		switch {
+		case r >= 0x3000 && r <= 0x30FF, // punctuation, hiragana & katakana
+			r >= 0x4E00 && r <= 0x9FFF, // kanji
+			r >= 0xFF00 && r <= 0xFFEF: // full & half-width forms
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// maybeDecodeText guesses the encoding of decompressed text-based data,
+// setting the structure's Encoding field & transcoding the returned reader to
+// utf-8 when data isn't utf-8
+func maybeDecodeText(st *dataset.Structure, data io.Reader) io.Reader {
+	if !textFormats[st.DataFormat()] {
+		return data
+	}
+
+	br := bufio.NewReaderSize(data, encodingSampleSize)
+	// errors are left for the caller to encounter when reading
+	sample, _ := br.Peek(encodingSampleSize)
+	st.Encoding = Encoding(sample)
+
+	enc, err := dsio.LookupEncoding(st.Encoding)
+	if err != nil || enc == nil {
+		return br
+	}
+	return enc.NewDecoder().Reader(br)
+}
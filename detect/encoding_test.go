@@ -0,0 +1,75 @@
+package detect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/qfs"
+)
+
+func TestEncoding(t *testing.T) {
+	cases := []struct {
+		sample string
+		expect string
+	}{
+		{"", ""},
+		{"plain ascii,1\n", ""},
+		{"Zürich,415367\n", ""},
+		{"Z\xc3", ""},
+		{"\xff\xfea\x00", "utf-16"},
+		{"\xfe\xff\x00a", "utf-16"},
+		{"Z\xfcrich,415367\n", "iso-8859-1"},
+		{"caf\xe9 \x93quoted\x94\n", "windows-1252"},
+		{"\x93\x8c\x8b\x9e,\x91\xe5\x8d\xe3\n", "shift_jis"},
+		{"\x93\x8c\x8b", "shift_jis"},
+		{"M\xfcller", "iso-8859-1"},
+	}
+
+	for i, c := range cases {
+		if got := Encoding([]byte(c.sample)); got != c.expect {
+			t.Errorf("case %d expected: %q, got: %q", i, c.expect, got)
+		}
+	}
+}
+
+func TestFromReaderEncoding(t *testing.T) {
+	data := "city,pop\nZ\xfcrich,415367\nS\xe3o Paulo,12330000\n"
+	st, _, err := FromReader(dataset.CSVDataFormat, compression.FmtNone, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Encoding != "iso-8859-1" {
+		t.Errorf("encoding mismatch. expected: %q, got: %q", "iso-8859-1", st.Encoding)
+	}
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, st.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("\xff\xfe[\x00]\x00")))
+	if err := Structure(ds); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Encoding != "utf-16" {
+		t.Errorf("encoding mismatch. expected: %q, got: %q", "utf-16", ds.Structure.Encoding)
+	}
+
+	st, _, _ = FromReader(dataset.JSONDataFormat, compression.FmtGZip, bytes.NewReader([]byte{0x1f, 0x8b, 0x08}))
+	if st.Encoding != "" {
+		t.Errorf("expected compressed data to skip encoding detection. got: %q", st.Encoding)
+	}
+}
@@ -1,8 +1,10 @@
 package detect
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/qri-io/dataset"
 )
@@ -10,8 +12,24 @@ import (
 // JSONSchema determines the field names and types of an io.Reader of JSON-formatted data, returning a json schema
 // This is currently a suuuuuuuuper simple interpretation that spits out a generic schema that'll work. In the future
 // we can do all sorts of stuff here to make better inferences about the shape of a dataset, but for now, this'll work,
-// and we'll instead focus on making it easier for users to provide hand-built schemas
+// and we'll instead focus on making it easier for users to provide hand-built schemas.
+// When the resource's format config has an entriesPointer, the schema describes the value
+// the pointer refers to
 func JSONSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	if resource != nil && resource.FormatConfig != nil {
+		opts, err := dataset.NewJSONOptions(resource.FormatConfig)
+		if err != nil {
+			return nil, 0, err
+		}
+		pointer, err := opts.EntriesPointer()
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(pointer) > 0 {
+			return jsonPointerSchema(resource.FormatConfig["entriesPointer"].(string), pointer, data)
+		}
+	}
+
 	var (
 		count = 0
 		buf   = make([]byte, 100)
@@ -53,6 +71,89 @@ func JSONSchema(resource *dataset.Structure, data io.Reader) (schema map[string]
 	}
 }
 
+// jsonPointerSchema determines the schema of the value a JSON pointer refers
+// to, decoding only as much data as it takes to reach the value
+func jsonPointerSchema(ptr string, pointer []string, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	cr := &countingReader{r: data}
+	dec := json.NewDecoder(cr)
+	defer func() { n = cr.n }()
+
+	for _, tok := range pointer {
+		found, err := seekJSONPointerToken(dec, tok)
+		if err != nil {
+			return nil, n, err
+		}
+		if !found {
+			return nil, n, fmt.Errorf("entriesPointer %q not found", ptr)
+		}
+	}
+
+	t, err := dec.Token()
+	if err != nil {
+		return nil, n, fmt.Errorf("invalid json data")
+	}
+	switch t {
+	case json.Delim('['):
+		return dataset.BaseSchemaArray, n, nil
+	case json.Delim('{'):
+		return dataset.BaseSchemaObject, n, nil
+	default:
+		return nil, n, fmt.Errorf("entriesPointer must refer to an array or object")
+	}
+}
+
+// seekJSONPointerToken moves a decoder to the child of the next value that
+// matches a JSON pointer reference token, reporting if the child exists
+func seekJSONPointerToken(dec *json.Decoder, tok string) (bool, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return false, fmt.Errorf("invalid json data")
+	}
+
+	var skip json.RawMessage
+	switch t {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return false, fmt.Errorf("invalid json data")
+			}
+			if key == tok {
+				return true, nil
+			}
+			if err := dec.Decode(&skip); err != nil {
+				return false, fmt.Errorf("invalid json data")
+			}
+		}
+	case json.Delim('['):
+		idx, err := strconv.Atoi(tok)
+		if err != nil {
+			break
+		}
+		for i := 0; dec.More(); i++ {
+			if i == idx {
+				return true, nil
+			}
+			if err := dec.Decode(&skip); err != nil {
+				return false, fmt.Errorf("invalid json data")
+			}
+		}
+	}
+	return false, nil
+}
+
+// countingReader tallies the number of bytes read from a reader
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
 // NDJSONSchema returns an array identity schema
 func NDJSONSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
 	return dataset.BaseSchemaArray, 0, nil
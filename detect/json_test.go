@@ -7,8 +7,18 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
 )
 
+// ptrSt creates a JSON structure with an entriesPointer
+func ptrSt(ptr string) *dataset.Structure {
+	return &dataset.Structure{
+		Format:       "json",
+		FormatConfig: map[string]interface{}{"entriesPointer": ptr},
+	}
+}
+
 func TestJSONSchema(t *testing.T) {
 
 	pr, _ := io.Pipe()
@@ -30,6 +40,14 @@ func TestJSONSchema(t *testing.T) {
 		{&dataset.Structure{}, "{", dataset.BaseSchemaObject, ""},
 		{&dataset.Structure{}, "[", dataset.BaseSchemaArray, ""},
 		{&dataset.Structure{}, strings.Repeat(" ", 250) + "[", dataset.BaseSchemaArray, ""},
+		{ptrSt("/data"), `{"meta":{"n":[1,2]},"data":{"a":1}}`, dataset.BaseSchemaObject, ""},
+		{ptrSt("/data/items"), `{"meta":{},"data":{"items":[{"a":1}]}}`, dataset.BaseSchemaArray, ""},
+		{ptrSt("/pages/1"), `{"pages":[[1],{"a":1}]}`, dataset.BaseSchemaObject, ""},
+		{ptrSt("/pages/2"), `{"pages":[[1],{"a":1}]}`, nil, `entriesPointer "/pages/2" not found`},
+		{ptrSt("/data"), `{"meta":{}}`, nil, `entriesPointer "/data" not found`},
+		{ptrSt("/data"), `{"data":12}`, nil, "entriesPointer must refer to an array or object"},
+		{ptrSt("/data"), `{"data"`, nil, "invalid json data"},
+		{ptrSt("data"), `{"data":[]}`, nil, `invalid entriesPointer value: json pointer "data" must start with '/'`},
 	}
 
 	for i, c := range cases {
@@ -46,3 +64,35 @@ func TestJSONSchema(t *testing.T) {
 		}
 	}
 }
+
+func TestStructureEntriesPointer(t *testing.T) {
+	ds := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format:       "json",
+			FormatConfig: map[string]interface{}{"entriesPointer": "/data"},
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`{"meta":[1,2,3],"data":{"a":1,"b":2}}`)))
+	if err := Structure(ds); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(dataset.BaseSchemaObject, ds.Structure.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if ds.Structure.FormatConfig["entriesPointer"] != "/data" {
+		t.Errorf("expected entriesPointer to be preserved. got: %v", ds.Structure.FormatConfig)
+	}
+
+	r, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := dsio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{"a": int64(1), "b": int64(2)}
+	if diff := cmp.Diff(expect, vals); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
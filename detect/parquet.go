@@ -0,0 +1,80 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/qri-io/dataset"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// ParquetSchema determines a tabular json schema from the schema stored in the
+// footer of a parquet file. Parquet footers come at the end of the file, so
+// the entire reader is consumed
+func ParquetSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	buf, err := ioutil.ReadAll(data)
+	n = len(buf)
+	if err != nil {
+		return nil, n, fmt.Errorf("error reading data: %s", err.Error())
+	}
+
+	pf, err := buffer.NewBufferFile(buf)
+	if err != nil {
+		return nil, n, err
+	}
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, n, fmt.Errorf("invalid parquet data: %s", err.Error())
+	}
+	defer pr.ReadStop()
+
+	sh := pr.SchemaHandler
+	cols := make([]interface{}, 0, len(sh.ValueColumns))
+	for _, path := range sh.ValueColumns {
+		idx := sh.MapIndex[path]
+		el := sh.SchemaElements[idx]
+		if el.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+			return nil, n, fmt.Errorf("repeated parquet fields are not supported: %q", el.GetName())
+		}
+		cols = append(cols, map[string]interface{}{
+			"title": sh.Infos[idx].ExName,
+			"type":  parquetJSONSchemaType(el),
+		})
+	}
+
+	schema = map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": cols,
+		},
+	}
+	return schema, n, nil
+}
+
+// parquetJSONSchemaType maps a parquet column type to a json schema type
+func parquetJSONSchemaType(el *parquet.SchemaElement) interface{} {
+	if el.ConvertedType != nil {
+		switch *el.ConvertedType {
+		case parquet.ConvertedType_DECIMAL:
+			return "number"
+		case parquet.ConvertedType_JSON:
+			return []interface{}{"object", "array"}
+		}
+	}
+
+	switch el.GetType() {
+	case parquet.Type_BOOLEAN:
+		return "boolean"
+	case parquet.Type_INT32, parquet.Type_INT64:
+		return "integer"
+	case parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		return "number"
+	default:
+		return "string"
+	}
+}
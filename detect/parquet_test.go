@@ -0,0 +1,55 @@
+package detect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestParquetSchema(t *testing.T) {
+	if _, _, err := ParquetSchema(&dataset.Structure{}, strings.NewReader("PAR1")); err == nil {
+		t.Error("expected invalid parquet data to error")
+	}
+
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+				map[string]interface{}{"title": "ratio", "type": "number"},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+				map[string]interface{}{"title": "meta", "type": []interface{}{"object", "array"}},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewParquetWriter(&dataset.Structure{Format: "parquet", Schema: schema}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(dsio.Entry{Value: []interface{}{"a", 1, 1.5, true, nil}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	size := buf.Len()
+
+	got, n, err := ParquetSchema(&dataset.Structure{}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != size {
+		t.Errorf("bytes read mismatch. expected: %d, got: %d", size, n)
+	}
+	if diff := cmp.Diff(schema, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
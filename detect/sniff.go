@@ -0,0 +1,303 @@
+package detect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// sniffSize is the number of leading bytes FormatFromReader examines
+const sniffSize = 64 * 1024
+
+// ErrUnknownFormat indicates data that doesn't match any format
+// FormatFromReader recognizes
+var ErrUnknownFormat = errors.New("unknown data format")
+
+// compressionMagic maps compression formats to the bytes their streams start
+// with. brotli streams have no magic number & can't be sniffed
+var compressionMagic = []struct {
+	format compression.Format
+	magic  []byte
+}{
+	{compression.FmtGZip, []byte{0x1f, 0x8b}},
+	{compression.FmtZStandard, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{compression.FmtBZip2, []byte("BZh")},
+	{compression.FmtXZ, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{compression.FmtLZ4, []byte{0x04, 0x22, 0x4d, 0x18}},
+	{compression.FmtSnappy, []byte("\xff\x06\x00\x00sNaPpY")},
+}
+
+// dataFormatMagic maps data formats to the bytes their files start with
+var dataFormatMagic = []struct {
+	format dataset.DataFormat
+	magic  []byte
+}{
+	{dataset.ParquetDataFormat, []byte("PAR1")},
+	{dataset.ArrowDataFormat, []byte("ARROW1")},
+	{dataset.ArrowDataFormat, []byte{0xff, 0xff, 0xff, 0xff}}, // IPC stream continuation marker
+	{dataset.AvroDataFormat, []byte("Obj\x01")},
+	{dataset.SQLiteDataFormat, []byte("SQLite format 3\x00")},
+	{dataset.CBORDataFormat, []byte{0xd9, 0xd9, 0xf7}}, // self-described CBOR tag
+}
+
+// FormatFromReader examines the leading bytes of a stream to determine it's
+// data & compression formats. Binary formats & compression are recognized
+// by their magic numbers, XLSX by the workbook parts of it's zip container,
+// and text is checked for JSON, NDJSON, XML & YAML before assuming CSV.
+// Compressed data is decompressed to find the format of it's contents.
+// FormatFromReader doesn't consume the stream. Bytes it reads are replayed
+// by the returned reader, which callers must read from in place of r
+func FormatFromReader(r io.Reader) (dataset.DataFormat, compression.Format, io.Reader, error) {
+	df, comp, _, rdr, err := sniffFormat(r)
+	return df, comp, rdr, err
+}
+
+// sniffFormat implements FormatFromReader, additionally reporting if the
+// data format was identified by a magic number
+func sniffFormat(r io.Reader) (df dataset.DataFormat, comp compression.Format, magic bool, rdr io.Reader, err error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	peek, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return dataset.UnknownDataFormat, compression.FmtNone, false, br, err
+	}
+	if len(peek) == 0 {
+		return dataset.UnknownDataFormat, compression.FmtNone, false, br, fmt.Errorf("%w: no data", ErrUnknownFormat)
+	}
+
+	complete := len(peek) < sniffSize
+	comp = compressionFromMagic(peek)
+	if comp != compression.FmtNone {
+		peek = decompressPeek(comp, peek)
+		complete = complete && len(peek) < sniffSize
+	}
+
+	df, magic = sniffDataFormat(peek, complete)
+	if df == dataset.UnknownDataFormat {
+		return df, comp, false, br, ErrUnknownFormat
+	}
+	return df, comp, magic, br, nil
+}
+
+// compressionFromMagic gives the compression format a sample starts with
+func compressionFromMagic(peek []byte) compression.Format {
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(peek, m.magic) {
+			return m.format
+		}
+	}
+	return compression.FmtNone
+}
+
+// decompressPeek decompresses as much of the start of a compressed stream
+// as it can
+func decompressPeek(comp compression.Format, peek []byte) []byte {
+	rc, err := compression.Decompressor(comp.String(), bytes.NewReader(peek))
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	buf := make([]byte, sniffSize)
+	n, _ := io.ReadFull(rc, buf)
+	return buf[:n]
+}
+
+// sniffDataFormat determines the data format of a sample, reporting if the
+// format was identified by a magic number. complete is true when the sample
+// holds the entire stream
+func sniffDataFormat(peek []byte, complete bool) (df dataset.DataFormat, magic bool) {
+	for _, m := range dataFormatMagic {
+		if bytes.HasPrefix(peek, m.magic) {
+			return m.format, true
+		}
+	}
+
+	if bytes.HasPrefix(peek, []byte("PK\x03\x04")) {
+		if bytes.Contains(peek, []byte("xl/")) {
+			return dataset.XLSXDataFormat, true
+		}
+		return dataset.UnknownDataFormat, false
+	}
+
+	// CBOR arrays & maps start with bytes that can't start utf-8 text, but
+	// can start text in other encodings, so this isn't a magic number
+	if len(peek) > 0 && peek[0] >= 0x80 && peek[0] <= 0xbf && isCBOR(peek, complete) {
+		return dataset.CBORDataFormat, false
+	}
+
+	return sniffTextFormat(peek, complete), false
+}
+
+// isCBOR checks a sample is a single well-formed CBOR data item, or the
+// start of one when the sample doesn't hold the entire stream
+func isCBOR(peek []byte, complete bool) bool {
+	n, ok := cborItemLen(peek, 0)
+	if !ok {
+		// an item that runs past the end of an incomplete sample may be valid
+		return n == len(peek) && !complete
+	}
+	// CBOR bodies are a single item, with nothing following it
+	return n == len(peek)
+}
+
+// cborMaxDepth limits how deeply isCBOR checks nested items
+const cborMaxDepth = 32
+
+// cborItemLen gives the length of the well-formed CBOR data item b starts
+// with. When b doesn't hold a well-formed item ok is false, and n is len(b)
+// if the item is only missing bytes past the end of b
+func cborItemLen(b []byte, depth int) (n int, ok bool) {
+	if len(b) == 0 || depth > cborMaxDepth {
+		return len(b), false
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	n = 1
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info < 28:
+		size := 1 << (info - 24)
+		if len(b) < 1+size {
+			return len(b), false
+		}
+		for _, c := range b[1 : 1+size] {
+			arg = arg<<8 | uint64(c)
+		}
+		n += size
+	case info == 31:
+		// indefinite length byte strings, text strings, arrays & maps are
+		// terminated by a break code
+		if major < 2 || major > 5 {
+			// integers & tags can't have indefinite lengths, and break codes
+			// can't appear outside of indefinite length items
+			return 0, false
+		}
+		for {
+			if n >= len(b) {
+				return len(b), false
+			}
+			if b[n] == 0xff {
+				return n + 1, true
+			}
+			if (major == 2 || major == 3) && b[n]>>5 != major {
+				// string chunks must be strings of the same type
+				return 0, false
+			}
+			m, ok := cborItemLen(b[n:], depth+1)
+			if !ok {
+				return n + m, false
+			}
+			n += m
+		}
+	default:
+		// additional information values 28-30 are reserved
+		return 0, false
+	}
+
+	switch major {
+	case 2, 3:
+		if arg > uint64(len(b)-n) {
+			return len(b), false
+		}
+		return n + int(arg), true
+	case 4, 5:
+		items := arg
+		if major == 5 {
+			items *= 2
+		}
+		for i := uint64(0); i < items; i++ {
+			m, ok := cborItemLen(b[n:], depth+1)
+			if !ok {
+				return n + m, false
+			}
+			n += m
+		}
+		return n, true
+	case 6:
+		m, ok := cborItemLen(b[n:], depth+1)
+		return n + m, ok
+	}
+	return n, true
+}
+
+// sniffTextFormat determines the format of a sample of text
+func sniffTextFormat(peek []byte, complete bool) dataset.DataFormat {
+	if name := Encoding(peek); name != "" {
+		enc, err := dsio.LookupEncoding(name)
+		if err != nil {
+			return dataset.UnknownDataFormat
+		}
+		if name == "utf-16" {
+			// drop a partial code unit from the end of the sample
+			peek = peek[:len(peek)&^1]
+		}
+		if peek, err = enc.NewDecoder().Bytes(peek); err != nil {
+			return dataset.UnknownDataFormat
+		}
+	}
+	if bytes.IndexByte(peek, 0) >= 0 {
+		// control characters don't appear in text
+		return dataset.UnknownDataFormat
+	}
+
+	text := bytes.TrimLeft(bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case len(text) == 0:
+		return dataset.UnknownDataFormat
+	case text[0] == '{' || text[0] == '[':
+		if isNDJSON(text, complete) {
+			return dataset.NDJSONDataFormat
+		}
+		return dataset.JSONDataFormat
+	case text[0] == '<':
+		return dataset.XMLDataFormat
+	case bytes.HasPrefix(text, []byte("---")) || bytes.HasPrefix(text, []byte("%YAML")):
+		return dataset.YAMLDataFormat
+	default:
+		return dataset.CSVDataFormat
+	}
+}
+
+// isNDJSON checks if the first JSON value of a sample ends it's line and is
+// followed by another JSON value
+func isNDJSON(text []byte, complete bool) bool {
+	dec := json.NewDecoder(bytes.NewReader(text))
+	var first json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return false
+	}
+
+	rest := text[dec.InputOffset():]
+	// the remainder of the first line must be blank
+	line := rest
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		line = rest[:i]
+	} else {
+		// a single value on a single line
+		return false
+	}
+	if len(bytes.TrimSpace(line)) != 0 {
+		return false
+	}
+
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	if len(rest) == 0 {
+		return false
+	}
+	if rest[0] != '{' && rest[0] != '[' {
+		return false
+	}
+	var second json.RawMessage
+	err := json.NewDecoder(bytes.NewReader(rest)).Decode(&second)
+	// a second value cut off by the end of the sample still counts
+	return err == nil || !complete && errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,176 @@
+package detect
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/qfs"
+)
+
+func TestFormatFromReader(t *testing.T) {
+	cbor, err := ioutil.ReadFile("testdata/cbor_array.cbor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xlsx, err := ioutil.ReadFile("../dsio/testdata/xlsx/simple/body.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		description string
+		data        []byte
+		df          dataset.DataFormat
+		comp        compression.Format
+		err         string
+	}{
+		{"empty", []byte{}, dataset.UnknownDataFormat, compression.FmtNone, "unknown data format: no data"},
+		{"whitespace", []byte(" \n\t"), dataset.UnknownDataFormat, compression.FmtNone, "unknown data format"},
+		{"binary", []byte{0x00, 0x01, 0x02, 0x03}, dataset.UnknownDataFormat, compression.FmtNone, "unknown data format"},
+		{"plain zip", []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00notes.txt"), dataset.UnknownDataFormat, compression.FmtNone, "unknown data format"},
+
+		{"json array", []byte(`[{"a":1},{"a":2}]`), dataset.JSONDataFormat, compression.FmtNone, ""},
+		{"json object", []byte("  {\n  \"a\": 1,\n  \"b\": 2\n}\n"), dataset.JSONDataFormat, compression.FmtNone, ""},
+		{"json with byte order mark", []byte("\xef\xbb\xbf[1,2,3]"), dataset.JSONDataFormat, compression.FmtNone, ""},
+		{"single line of json", []byte("{\"a\":1}\n"), dataset.JSONDataFormat, compression.FmtNone, ""},
+		{"ndjson", []byte("{\"a\":1}\n{\"a\":2}\n"), dataset.NDJSONDataFormat, compression.FmtNone, ""},
+		{"ndjson arrays", []byte("[1,2]\r\n[3,4]"), dataset.NDJSONDataFormat, compression.FmtNone, ""},
+		{"values on the same line", []byte(`{"a":1} {"a":2}`), dataset.JSONDataFormat, compression.FmtNone, ""},
+		{"xml", []byte(`<?xml version="1.0"?><root></root>`), dataset.XMLDataFormat, compression.FmtNone, ""},
+		{"yaml", []byte("---\n- a: 1\n"), dataset.YAMLDataFormat, compression.FmtNone, ""},
+		{"yaml directive", []byte("%YAML 1.2\n---\nfoo: bar\n"), dataset.YAMLDataFormat, compression.FmtNone, ""},
+		{"csv", []byte("a,b,c\n1,2,3\n"), dataset.CSVDataFormat, compression.FmtNone, ""},
+		{"latin-1 csv", []byte("name,city\nJos\xe9,M\xe1laga\n"), dataset.CSVDataFormat, compression.FmtNone, ""},
+		{"utf-16 csv", []byte("\xff\xfea\x00,\x00b\x00\n\x00"), dataset.CSVDataFormat, compression.FmtNone, ""},
+
+		{"cbor", cbor, dataset.CBORDataFormat, compression.FmtNone, ""},
+		{"self-described cbor", []byte{0xd9, 0xd9, 0xf7, 0x80}, dataset.CBORDataFormat, compression.FmtNone, ""},
+		{"indefinite length cbor", []byte{0xbf, 0x61, 'a', 0x5f, 0x41, 0x01, 0xff, 0xff}, dataset.CBORDataFormat, compression.FmtNone, ""},
+		{"latin-1 csv starting with a quote", []byte("\xabname\xbb,city\nJos\xe9,M\xe1laga\n"), dataset.CSVDataFormat, compression.FmtNone, ""},
+		{"truncated cbor", []byte{0x83, 0x01, 0x02}, dataset.CSVDataFormat, compression.FmtNone, ""},
+		{"xlsx", xlsx, dataset.XLSXDataFormat, compression.FmtNone, ""},
+		{"parquet", []byte("PAR1\x15\x04"), dataset.ParquetDataFormat, compression.FmtNone, ""},
+		{"arrow", []byte("ARROW1\x00\x00"), dataset.ArrowDataFormat, compression.FmtNone, ""},
+		{"avro", []byte("Obj\x01\x04\x14"), dataset.AvroDataFormat, compression.FmtNone, ""},
+		{"sqlite", []byte("SQLite format 3\x00\x10\x00"), dataset.SQLiteDataFormat, compression.FmtNone, ""},
+
+		{"gzip ndjson", mustCompress(t, "gzip", []byte("{\"a\":1}\n{\"a\":2}\n")), dataset.NDJSONDataFormat, compression.FmtGZip, ""},
+		{"zstd csv", mustCompress(t, "zst", []byte("a,b\n1,2\n")), dataset.CSVDataFormat, compression.FmtZStandard, ""},
+		{"bzip2 csv", mustCompress(t, "bz2", []byte("a,b\n1,2\n")), dataset.CSVDataFormat, compression.FmtBZip2, ""},
+		{"xz json", mustCompress(t, "xz", []byte(`[1,2,3]`)), dataset.JSONDataFormat, compression.FmtXZ, ""},
+		{"lz4 json", mustCompress(t, "lz4", []byte(`[1,2,3]`)), dataset.JSONDataFormat, compression.FmtLZ4, ""},
+		{"snappy json", mustCompress(t, "snappy", []byte(`[1,2,3]`)), dataset.JSONDataFormat, compression.FmtSnappy, ""},
+		{"gzip cbor", mustCompress(t, "gzip", cbor), dataset.CBORDataFormat, compression.FmtGZip, ""},
+		{"gzip binary", mustCompress(t, "gzip", []byte{0x00, 0x00}), dataset.UnknownDataFormat, compression.FmtGZip, "unknown data format"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			df, comp, r, err := FormatFromReader(bytes.NewReader(c.data))
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: %q, got: %v", c.err, err)
+			}
+			if c.err != "" && !errors.Is(err, ErrUnknownFormat) {
+				t.Errorf("expected error to be ErrUnknownFormat")
+			}
+			if df != c.df {
+				t.Errorf("data format mismatch. expected: %q, got: %q", c.df, df)
+			}
+			if comp != c.comp {
+				t.Errorf("compression mismatch. expected: %q, got: %q", c.comp, comp)
+			}
+
+			got, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.data, got) {
+				t.Errorf("expected returned reader to replay the entire stream")
+			}
+		})
+	}
+}
+
+func TestFormatFromReaderLargeNDJSON(t *testing.T) {
+	// the sample cuts the stream off mid-value
+	line := []byte(`{"description":"` + string(bytes.Repeat([]byte("x"), 1000)) + `"}` + "\n")
+	data := bytes.Repeat(line, 100)
+	df, _, _, err := FormatFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if df != dataset.NDJSONDataFormat {
+		t.Errorf("expected ndjson, got: %q", df)
+	}
+}
+
+func TestStructureSniffedFormat(t *testing.T) {
+	cases := []struct {
+		description string
+		filename    string
+		data        []byte
+		format      string
+		comp        string
+		err         string
+	}{
+		{"no extension", "body", []byte("{\"a\":1}\n{\"a\":2}\n"), "ndjson", "", ""},
+		{"unsupported extension", "body.dat", []byte("a,b\n1,2\n"), "csv", "", ""},
+		{"misleading compression", "body.json.gz", []byte(`[1,2,3]`), "json", "", ""},
+		{"missing compression", "body.csv", mustCompress(t, "gzip", []byte("a,b\n1,2\n")), "csv", "gzip", ""},
+		{"misleading binary format", "body.json", []byte{0xd9, 0xd9, 0xf7, 0x82, 0x01, 0x02}, "cbor", "", ""},
+		{"cbor without extension", "body", []byte{0x82, 0x01, 0x02}, "cbor", "", ""},
+		{"latin-1 text", "body.csv", []byte("\xabquoted\xbb,b\n1,2\n"), "csv", "", ""},
+		{"windows-1252 text", "body.csv", []byte("\x93quoted\x94,b\n1,2\n"), "csv", "", ""},
+		{"json lines", "body.json", []byte("{\"a\":1}\n{\"a\":2}\n"), "ndjson", "", ""},
+		{"text keeps extension", "body.csv", []byte(`[1,2,3]`), "csv", "", ""},
+		{"unknown contents", "body", []byte{0x00, 0x01}, "", "", "invalid data format: no file extension provided"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ds := &dataset.Dataset{}
+			ds.SetBodyFile(qfs.NewMemfileBytes(c.filename, c.data))
+			err := Structure(ds)
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: %q, got: %v", c.err, err)
+			}
+			if c.err != "" {
+				return
+			}
+			if ds.Structure.Format != c.format {
+				t.Errorf("format mismatch. expected: %q, got: %q", c.format, ds.Structure.Format)
+			}
+			if ds.Structure.Compression != c.comp {
+				t.Errorf("compression mismatch. expected: %q, got: %q", c.comp, ds.Structure.Compression)
+			}
+
+			got, err := ioutil.ReadAll(ds.BodyFile())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.data, got) {
+				t.Errorf("expected body to be preserved")
+			}
+		})
+	}
+}
+
+func mustCompress(t *testing.T, format string, data []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := compression.Compressor(format, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,42 @@
+package detect
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// XMLSchema determines the schema of an io.Reader of XML-formatted data. XML
+// entries are always read as an array, so this only checks the root element
+// is well-formed before returning an array schema
+func XMLSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	dec := xml.NewDecoder(tr)
+	// only element names are checked, so declared encodings can be ignored
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, tr.BytesRead(), fmt.Errorf("invalid xml data")
+			}
+			log.Debugf(err.Error())
+			return nil, tr.BytesRead(), fmt.Errorf("invalid xml data: %s", err.Error())
+		}
+		// the decoder errors on mismatched tags, read until the root closes
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return dataset.BaseSchemaArray, tr.BytesRead(), nil
+			}
+		}
+	}
+}
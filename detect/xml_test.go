@@ -0,0 +1,34 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestXMLSchema(t *testing.T) {
+	cases := []struct {
+		data   string
+		expect map[string]interface{}
+		err    string
+	}{
+		{"", nil, "invalid xml data"},
+		{"<?xml version=\"1.0\"?>", nil, "invalid xml data"},
+		{"<a></b>", nil, "invalid xml data: XML syntax error on line 1: element <a> closed by </b>"},
+		{"<feed><item>", nil, "invalid xml data: XML syntax error on line 1: unexpected EOF"},
+		{"<?xml version=\"1.0\"?>\n<!-- feed -->\n<feed><item/></feed>", dataset.BaseSchemaArray, ""},
+	}
+
+	for i, c := range cases {
+		got, _, err := XMLSchema(&dataset.Structure{}, strings.NewReader(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d returned schema mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLSchema determines the top level type of an io.Reader of YAML-formatted
+// data from the kind of its first document. Multi-document YAML is always an
+// array
+func YAMLSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	if resource != nil && resource.FormatConfig != nil {
+		opts, err := dataset.NewYAMLOptions(resource.FormatConfig)
+		if err != nil {
+			return nil, 0, err
+		}
+		if opts.MultiDocument {
+			return dataset.BaseSchemaArray, 0, nil
+		}
+	}
+
+	tr := dsio.NewTrackedReader(data)
+	doc := &yaml.Node{}
+	if err := yaml.NewDecoder(tr).Decode(doc); err != nil {
+		if err == io.EOF {
+			return nil, tr.BytesRead(), fmt.Errorf("invalid yaml data")
+		}
+		log.Debugf(err.Error())
+		return nil, tr.BytesRead(), fmt.Errorf("invalid yaml data: %s", err.Error())
+	}
+
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return dataset.BaseSchemaArray, tr.BytesRead(), nil
+	case yaml.MappingNode:
+		return dataset.BaseSchemaObject, tr.BytesRead(), nil
+	default:
+		return nil, tr.BytesRead(), fmt.Errorf("invalid yaml data: top level must be a sequence or mapping")
+	}
+}
@@ -0,0 +1,36 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestYAMLSchema(t *testing.T) {
+	cases := []struct {
+		st     *dataset.Structure
+		data   string
+		expect map[string]interface{}
+		err    string
+	}{
+		{&dataset.Structure{}, "", nil, "invalid yaml data"},
+		{&dataset.Structure{}, "plain", nil, "invalid yaml data: top level must be a sequence or mapping"},
+		{&dataset.Structure{}, "- [a", nil, "invalid yaml data: yaml: line 1: did not find expected ',' or ']'"},
+		{&dataset.Structure{}, "# list\n- a\n- b\n", dataset.BaseSchemaArray, ""},
+		{&dataset.Structure{}, "---\na: b\n", dataset.BaseSchemaObject, ""},
+		{&dataset.Structure{FormatConfig: map[string]interface{}{"multiDocument": true}}, "a: b\n---\nc: d\n", dataset.BaseSchemaArray, ""},
+	}
+
+	for i, c := range cases {
+		got, _, err := YAMLSchema(c.st, strings.NewReader(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d returned schema mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+package dsio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+const (
+	// arrowBatchSize is the number of rows the writer buffers into each record
+	// batch
+	arrowBatchSize = 1024
+	// arrowSchemaMetadataKey is the arrow field metadata key that keeps the
+	// JSON schema of a column that can't be expressed by the arrow type alone
+	arrowSchemaMetadataKey = "jsonschema"
+)
+
+// arrowRecordReader is the common interface of arrow IPC stream and file
+// readers. Records returned by Read are valid until the next call to Read
+type arrowRecordReader interface {
+	Read() (array.Record, error)
+}
+
+// ArrowReader implements the EntryReader interface for the Arrow IPC data
+// format. Both the IPC stream format and the IPC file (Feather V2) format are
+// read, distinguished by the file format's leading magic bytes. The file
+// format keeps record batch locations in a footer at the end of the file, so
+// file-formatted read sources are buffered in memory. Each row is read as an
+// array entry
+type ArrowReader struct {
+	st       *dataset.Structure
+	schema   *arrow.Schema
+	rr       arrowRecordReader
+	release  func()
+	jsonCols []bool
+	rec      array.Record
+	recRow   int
+	rowsRead int
+	close    func() error // close func from wrapping
+}
+
+var _ EntryReader = (*ArrowReader)(nil)
+
+// NewArrowReader creates a reader from a structure and read source
+func NewArrowReader(st *dataset.Structure, r io.Reader) (*ArrowReader, error) {
+	r, close, err := maybeWrapDecompressor(st, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ar := &ArrowReader{st: st, close: close}
+	if err := ar.open(r); err != nil {
+		if close != nil {
+			close()
+		}
+		return nil, err
+	}
+	return ar, nil
+}
+
+// open reads the schema of an arrow IPC file or stream
+func (r *ArrowReader) open(rdr io.Reader) error {
+	br := bufio.NewReader(rdr)
+	if magic, _ := br.Peek(len(ipc.Magic)); bytes.Equal(magic, ipc.Magic) {
+		data, err := ioutil.ReadAll(br)
+		if err != nil {
+			return err
+		}
+		fr, err := ipc.NewFileReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("reading arrow file: %w", err)
+		}
+		r.rr = fr
+		r.schema = fr.Schema()
+		r.release = func() { fr.Close() }
+	} else {
+		sr, err := ipc.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("reading arrow stream: %w", err)
+		}
+		r.rr = sr
+		r.schema = sr.Schema()
+		r.release = sr.Release
+	}
+
+	r.jsonCols = make([]bool, len(r.schema.Fields()))
+	for i, f := range r.schema.Fields() {
+		r.jsonCols[i] = arrowFieldIsJSON(f)
+	}
+	return nil
+}
+
+// ArrowSchema gives the arrow schema of the read source
+func (r *ArrowReader) ArrowSchema() *arrow.Schema {
+	return r.schema
+}
+
+// Structure gives this reader's structure
+func (r *ArrowReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one arrow row from the reader
+func (r *ArrowReader) ReadEntry() (Entry, error) {
+	for r.rec == nil || r.recRow >= int(r.rec.NumRows()) {
+		rec, err := r.rr.Read()
+		if err != nil {
+			if err != io.EOF {
+				log.Debug(err.Error())
+			}
+			r.rec = nil
+			return Entry{}, err
+		}
+		r.rec = rec
+		r.recRow = 0
+	}
+
+	row := make([]interface{}, r.rec.NumCols())
+	for i, col := range r.rec.Columns() {
+		v, err := arrowValue(col, r.recRow)
+		if err != nil {
+			return Entry{}, fmt.Errorf("row %d, column %q: %w", r.rowsRead, r.rec.ColumnName(i), err)
+		}
+		if s, ok := v.(string); ok && r.jsonCols[i] {
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return Entry{}, fmt.Errorf("row %d, column %q: %w", r.rowsRead, r.rec.ColumnName(i), err)
+			}
+		}
+		row[i] = v
+	}
+
+	ent := Entry{Index: r.rowsRead, Value: row}
+	r.recRow++
+	r.rowsRead++
+	return ent, nil
+}
+
+// Close finalizes the reader
+func (r *ArrowReader) Close() error {
+	r.release()
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+// arrowValue converts the value at row i of an arrow array to a go type that
+// other dsio writers understand
+func arrowValue(arr array.Interface, i int) (interface{}, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.Int8:
+		return int64(a.Value(i)), nil
+	case *array.Int16:
+		return int64(a.Value(i)), nil
+	case *array.Int32:
+		return int64(a.Value(i)), nil
+	case *array.Int64:
+		return a.Value(i), nil
+	case *array.Uint8:
+		return int64(a.Value(i)), nil
+	case *array.Uint16:
+		return int64(a.Value(i)), nil
+	case *array.Uint32:
+		return int64(a.Value(i)), nil
+	case *array.Uint64:
+		return int64(a.Value(i)), nil
+	case *array.Float32:
+		return float64(a.Value(i)), nil
+	case *array.Float64:
+		return a.Value(i), nil
+	case *array.String:
+		return a.Value(i), nil
+	case *array.Binary:
+		return string(a.Value(i)), nil
+	case *array.Date32:
+		return time.Unix(int64(a.Value(i))*86400, 0).UTC().Format("2006-01-02"), nil
+	case *array.Date64:
+		return time.Unix(0, int64(a.Value(i))*int64(time.Millisecond)).UTC().Format("2006-01-02"), nil
+	case *array.Timestamp:
+		unit := map[arrow.TimeUnit]time.Duration{
+			arrow.Second:      time.Second,
+			arrow.Millisecond: time.Millisecond,
+			arrow.Microsecond: time.Microsecond,
+			arrow.Nanosecond:  time.Nanosecond,
+		}[a.DataType().(*arrow.TimestampType).Unit]
+		return time.Unix(0, int64(a.Value(i))*int64(unit)).UTC().Format(time.RFC3339Nano), nil
+	case *array.List:
+		offsets := a.Offsets()
+		values := a.ListValues()
+		list := make([]interface{}, 0, offsets[i+1]-offsets[i])
+		for j := int(offsets[i]); j < int(offsets[i+1]); j++ {
+			v, err := arrowValue(values, j)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case *array.Struct:
+		fields := a.DataType().(*arrow.StructType).Fields()
+		obj := make(map[string]interface{}, len(fields))
+		for j, f := range fields {
+			v, err := arrowValue(a.Field(j), i)
+			if err != nil {
+				return nil, err
+			}
+			obj[f.Name] = v
+		}
+		return obj, nil
+	default:
+		return nil, fmt.Errorf("unsupported arrow type: %s", arr.DataType())
+	}
+}
+
+// ArrowWriter implements the EntryWriter interface for Arrow IPC formatted
+// data. Column types are derived from the structure's tabular schema. The
+// IPC file (Feather V2) format is written unless the structure's ArrowOptions
+// specify the stream format
+type ArrowWriter struct {
+	st      *dataset.Structure
+	cols    tabular.Columns
+	kinds   []colKind
+	w       arrowRecordWriter
+	builder *array.RecordBuilder
+	rows    int
+	close   func() error // close func from wrapping
+}
+
+var _ EntryWriter = (*ArrowWriter)(nil)
+
+// arrowRecordWriter is the common interface of arrow IPC stream and file
+// writers
+type arrowRecordWriter interface {
+	Write(rec array.Record) error
+	Close() error
+}
+
+// NewArrowWriter creates a Writer from a structure and write destination
+func NewArrowWriter(st *dataset.Structure, w io.Writer) (*ArrowWriter, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("arrow writer requires at least one column")
+	}
+	schema, err := ArrowSchemaFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &dataset.ArrowOptions{}
+	if st.FormatConfig != nil {
+		if opts, err = dataset.NewArrowOptions(st.FormatConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	w, close, err := maybeWrapCompressor(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make([]colKind, len(cols))
+	for i, c := range cols {
+		kinds[i] = colKindFromColType(c.Type)
+	}
+
+	mem := memory.NewGoAllocator()
+	aw := &ArrowWriter{
+		st:      st,
+		cols:    cols,
+		kinds:   kinds,
+		builder: array.NewRecordBuilder(mem, schema),
+		close:   close,
+	}
+
+	if opts.Stream {
+		aw.w = ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+	} else {
+		fw, err := ipc.NewFileWriter(&positionWriter{w: w}, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+		if err != nil {
+			return nil, err
+		}
+		aw.w = fw
+	}
+	return aw, nil
+}
+
+// Structure gives this writer's structure
+func (w *ArrowWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one arrow row to the writer
+func (w *ArrowWriter) WriteEntry(ent Entry) error {
+	arr, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value to write arrow row. got: %v", ent)
+	}
+	if len(arr) > len(w.cols) {
+		return fmt.Errorf("row has %d values, schema defines %d columns", len(arr), len(w.cols))
+	}
+
+	// convert the whole row before appending, so a bad value doesn't leave
+	// columns with different numbers of rows
+	row := make([]interface{}, len(w.cols))
+	for i := range arr {
+		v, err := colKindValue(arr[i], w.kinds[i])
+		if err != nil {
+			return fmt.Errorf("column %q: %w", w.cols[i].Title, err)
+		}
+		row[i] = v
+	}
+	for i, v := range row {
+		appendArrowValue(w.builder.Field(i), v)
+	}
+
+	w.rows++
+	if w.rows == arrowBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// appendArrowValue adds a value returned by colKindValue to a column builder
+func appendArrowValue(b array.Builder, v interface{}) {
+	switch x := v.(type) {
+	case nil:
+		b.AppendNull()
+	case int64:
+		b.(*array.Int64Builder).Append(x)
+	case float64:
+		b.(*array.Float64Builder).Append(x)
+	case bool:
+		b.(*array.BooleanBuilder).Append(x)
+	case string:
+		b.(*array.StringBuilder).Append(x)
+	}
+}
+
+// flush writes buffered rows as a record batch
+func (w *ArrowWriter) flush() error {
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+	w.rows = 0
+	return w.w.Write(rec)
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *ArrowWriter) Close() error {
+	defer w.builder.Release()
+	if w.rows > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if err := w.w.Close(); err != nil {
+		return err
+	}
+	if w.close != nil {
+		return w.close()
+	}
+	return nil
+}
+
+// positionWriter adapts an io.Writer to the io.WriteSeeker the arrow file
+// writer expects. The file writer only seeks to ask for the current position
+type positionWriter struct {
+	w   io.Writer
+	pos int64
+}
+
+func (p *positionWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.pos += int64(n)
+	return n, err
+}
+
+func (p *positionWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return p.pos, fmt.Errorf("arrow writer cannot seek")
+	}
+	return p.pos, nil
+}
+
+// ArrowSchemaFromJSONSchema converts a tabular JSON schema to an arrow schema.
+// Column types arrow can't express directly, descriptions and validation
+// keywords are kept as JSON in field metadata so they survive a round trip
+// through JSONSchemaFromArrowSchema
+func ArrowSchemaFromJSONSchema(sch map[string]interface{}) (*arrow.Schema, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]arrow.Field, len(cols))
+	for i, c := range cols {
+		var dt arrow.DataType
+		switch colKindFromColType(c.Type) {
+		case colInteger:
+			dt = arrow.PrimitiveTypes.Int64
+		case colNumber:
+			dt = arrow.PrimitiveTypes.Float64
+		case colBoolean:
+			dt = arrow.FixedWidthTypes.Boolean
+		default:
+			dt = arrow.BinaryTypes.String
+		}
+
+		fields[i] = arrow.Field{Name: c.Title, Type: dt, Nullable: true}
+
+		colSchema := map[string]interface{}{}
+		for k, v := range c.Validation {
+			colSchema[k] = v
+		}
+		if c.Description != "" {
+			colSchema["description"] = c.Description
+		}
+		if c.Type != nil {
+			if len(*c.Type) != 1 || (*c.Type)[0] != arrowJSONSchemaType(dt) {
+				colSchema["type"] = c.Type
+			}
+		}
+		if len(colSchema) > 0 {
+			data, err := json.Marshal(colSchema)
+			if err != nil {
+				return nil, err
+			}
+			fields[i].Metadata = arrow.NewMetadata([]string{arrowSchemaMetadataKey}, []string{string(data)})
+		}
+	}
+
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// JSONSchemaFromArrowSchema converts an arrow schema to a tabular JSON schema
+func JSONSchemaFromArrowSchema(s *arrow.Schema) (map[string]interface{}, error) {
+	items := make([]interface{}, len(s.Fields()))
+	for i, f := range s.Fields() {
+		col := map[string]interface{}{}
+		if idx := f.Metadata.FindKey(arrowSchemaMetadataKey); idx >= 0 {
+			if err := json.Unmarshal([]byte(f.Metadata.Values()[idx]), &col); err != nil {
+				return nil, fmt.Errorf("field %q: invalid %s metadata: %w", f.Name, arrowSchemaMetadataKey, err)
+			}
+		}
+		col["title"] = f.Name
+		if _, ok := col["type"]; !ok {
+			col["type"] = arrowJSONSchemaType(f.Type)
+		}
+		items[i] = col
+	}
+
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}, nil
+}
+
+// arrowJSONSchemaType gives the JSON schema type for an arrow data type
+func arrowJSONSchemaType(dt arrow.DataType) string {
+	switch dt.ID() {
+	case arrow.BOOL:
+		return "boolean"
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return "integer"
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64, arrow.DECIMAL:
+		return "number"
+	case arrow.LIST, arrow.FIXED_SIZE_LIST:
+		return "array"
+	case arrow.STRUCT, arrow.MAP:
+		return "object"
+	case arrow.NULL:
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+// arrowFieldIsJSON reports whether a field holds JSON-encoded object or array
+// values written by ArrowWriter
+func arrowFieldIsJSON(f arrow.Field) bool {
+	if f.Type.ID() != arrow.STRING {
+		return false
+	}
+	idx := f.Metadata.FindKey(arrowSchemaMetadataKey)
+	if idx < 0 {
+		return false
+	}
+	col := struct {
+		Type *tabular.ColType `json:"type"`
+	}{}
+	if err := json.Unmarshal([]byte(f.Metadata.Values()[idx]), &col); err != nil {
+		return false
+	}
+	return colKindFromColType(col.Type) == colJSON
+}
@@ -0,0 +1,318 @@
+package dsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+var arrowSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "name", "type": "string", "description": "a name", "maxLength": float64(10)},
+			map[string]interface{}{"title": "count", "type": "integer"},
+			map[string]interface{}{"title": "ratio", "type": []interface{}{"number", "null"}},
+			map[string]interface{}{"title": "ok", "type": "boolean"},
+			map[string]interface{}{"title": "meta", "type": "object"},
+			map[string]interface{}{"title": "mixed", "type": []interface{}{"string", "integer"}},
+		},
+	},
+}
+
+func TestArrowReadWrite(t *testing.T) {
+	entries := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}, int64(2)},
+		[]interface{}{"b", int64(2), nil, false, nil, "two"},
+		[]interface{}{nil, "3", int64(3), "true", map[string]interface{}{}},
+	}
+	expect := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}, "2"},
+		[]interface{}{"b", int64(2), nil, false, nil, "two"},
+		[]interface{}{nil, int64(3), float64(3), true, map[string]interface{}{}, nil},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+	}{
+		{"file", &dataset.Structure{Format: "arrow", Schema: arrowSchema}},
+		{"stream", &dataset.Structure{Format: "arrow", Schema: arrowSchema, FormatConfig: map[string]interface{}{"stream": true}}},
+		{"gzip file", &dataset.Structure{Format: "arrow", Compression: "gzip", Schema: arrowSchema}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range entries {
+				if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArrowManyRows(t *testing.T) {
+	st := &dataset.Structure{Format: "arrow", Schema: arrowSchema}
+	buf := &bytes.Buffer{}
+	w, err := NewArrowWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := arrowBatchSize*2 + 10
+	for i := 0; i < count; i++ {
+		if err := w.WriteEntry(Entry{Value: []interface{}{"x", i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewArrowReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	err = EachEntry(r, func(_ int, ent Entry, _ error) error {
+		if got := ent.Value.([]interface{})[1]; got != int64(i) {
+			t.Fatalf("row %d count mismatch. got: %v", i, got)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != count {
+		t.Errorf("row count mismatch. expected: %d, got: %d", count, i)
+	}
+}
+
+func TestArrowReadNestedTypes(t *testing.T) {
+	mem := memory.NewGoAllocator()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "small", Type: arrow.PrimitiveTypes.Int16, Nullable: true},
+		{Name: "list", Type: arrow.ListOf(arrow.PrimitiveTypes.Float32), Nullable: true},
+		{Name: "struct", Type: arrow.StructOf(arrow.Field{Name: "a", Type: arrow.BinaryTypes.String}), Nullable: true},
+		{Name: "day", Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+	}, nil)
+
+	b := array.NewRecordBuilder(mem, schema)
+	defer b.Release()
+	b.Field(0).(*array.Int16Builder).Append(7)
+	lb := b.Field(1).(*array.ListBuilder)
+	lb.Append(true)
+	lb.ValueBuilder().(*array.Float32Builder).AppendValues([]float32{0.5, 1}, nil)
+	sb := b.Field(2).(*array.StructBuilder)
+	sb.Append(true)
+	sb.FieldBuilder(0).(*array.StringBuilder).Append("b")
+	b.Field(3).(*array.Date32Builder).Append(18262)
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	buf := &bytes.Buffer{}
+	w := ipc.NewWriter(buf, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+	if err := w.Write(rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewArrowReader(&dataset.Structure{Format: "arrow"}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{int64(7), []interface{}{0.5, float64(1)}, map[string]interface{}{"a": "b"}, "2020-01-01"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	gotSchema, err := JSONSchemaFromArrowSchema(r.ArrowSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "small", "type": "integer"},
+				map[string]interface{}{"title": "list", "type": "array"},
+				map[string]interface{}{"title": "struct", "type": "object"},
+				map[string]interface{}{"title": "day", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSchema, gotSchema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestArrowSchemaRoundTrip(t *testing.T) {
+	as, err := ArrowSchemaFromJSONSchema(arrowSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTypes := []arrow.DataType{
+		arrow.BinaryTypes.String,
+		arrow.PrimitiveTypes.Int64,
+		arrow.PrimitiveTypes.Float64,
+		arrow.FixedWidthTypes.Boolean,
+		arrow.BinaryTypes.String,
+		arrow.BinaryTypes.String,
+	}
+	for i, f := range as.Fields() {
+		if !arrow.TypeEqual(expectTypes[i], f.Type) {
+			t.Errorf("field %d type mismatch. expected: %s, got: %s", i, expectTypes[i], f.Type)
+		}
+	}
+
+	got, err := JSONSchemaFromArrowSchema(as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(arrowSchema, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ArrowSchemaFromJSONSchema(dataset.BaseSchemaArray); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+}
+
+func TestArrowErrors(t *testing.T) {
+	if _, err := NewArrowWriter(&dataset.Structure{Format: "arrow", Schema: dataset.BaseSchemaArray}, &bytes.Buffer{}); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+	if _, err := NewArrowWriter(&dataset.Structure{Format: "arrow", Schema: arrowSchema, FormatConfig: map[string]interface{}{"stream": "yes"}}, &bytes.Buffer{}); err == nil {
+		t.Error("expected invalid format config to error")
+	}
+	if _, err := NewArrowReader(&dataset.Structure{Format: "arrow"}, bytes.NewBufferString("not an arrow file")); err == nil {
+		t.Error("expected invalid stream data to error")
+	}
+	if _, err := NewArrowReader(&dataset.Structure{Format: "arrow"}, bytes.NewBufferString("ARROW1 but truncated")); err == nil {
+		t.Error("expected invalid file data to error")
+	}
+
+	st := &dataset.Structure{Format: "arrow", Schema: arrowSchema}
+	w, err := NewArrowWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: "not a row"}); err == nil {
+		t.Error("expected non-array entry to error")
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", "not a number"}}); err == nil {
+		t.Error("expected invalid integer to error")
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", 1, 2, true, nil, nil, "extra"}}); err == nil {
+		t.Error("expected too many values to error")
+	}
+}
+
+func TestArrowWriteInvalidRow(t *testing.T) {
+	st := &dataset.Structure{Format: "arrow", Schema: arrowSchema}
+	buf := &bytes.Buffer{}
+	w, err := NewArrowWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"b", "zz"}}); err == nil {
+		t.Error("expected invalid integer to error")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewArrowReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []interface{}{}
+	err = EachEntry(r, func(_ int, ent Entry, _ error) error {
+		rows = append(rows, ent.Value.([]interface{})[:2])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{[]interface{}{"a", int64(1)}}, rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestArrowWriteExactNumbers(t *testing.T) {
+	st := &dataset.Structure{Format: "arrow", Schema: arrowSchema}
+	buf := &bytes.Buffer{}
+	w, err := NewArrowWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := []interface{}{"a", json.Number("3"), big.NewFloat(0.5), true, map[string]interface{}{"n": json.Number("1.10")}}
+	if err := w.WriteEntry(Entry{Value: row}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"b", json.Number("3.5")}}); err == nil {
+		t.Error("expected fractional integer to error")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewArrowReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{[]interface{}{"a", int64(3), 0.5, true, map[string]interface{}{"n": 1.1}, nil}}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
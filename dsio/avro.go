@@ -0,0 +1,628 @@
+package dsio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+const (
+	// avroBlockSize is the number of records the writer buffers into each
+	// container file block
+	avroBlockSize = 1024
+	// avroSchemaAttribute is the avro record field attribute that keeps the
+	// JSON schema of a column that can't be expressed by the avro type alone
+	avroSchemaAttribute = "jsonschema"
+	// defaultAvroRecordName is the name given to the record type written by
+	// the avro writer when no record name is configured
+	defaultAvroRecordName = "entry"
+)
+
+// validAvroName matches valid avro record & field names
+var validAvroName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// AvroReader implements the EntryReader interface for avro object container
+// files. The schema embedded in the file header must describe a record, each
+// record is read as an array entry of field values in schema order
+type AvroReader struct {
+	st       *dataset.Structure
+	ocfr     *goavro.OCFReader
+	schema   map[string]interface{}
+	names    avroNames
+	fields   []avroField
+	rowsRead int
+	close    func() error // close func from wrapping
+}
+
+var _ EntryReader = (*AvroReader)(nil)
+
+// avroField is the name & type of a field in an avro record schema
+type avroField struct {
+	name string
+	typ  interface{}
+	// json is true for fields holding JSON-encoded objects or arrays written
+	// by AvroWriter
+	json bool
+}
+
+// NewAvroReader creates a reader from a structure and read source
+func NewAvroReader(st *dataset.Structure, r io.Reader) (*AvroReader, error) {
+	r, close, err := maybeWrapDecompressor(st, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ocfr, err := goavro.NewOCFReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading avro file: %w", err)
+	}
+
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(ocfr.MetaData()["avro.schema"], &schema); err != nil {
+		return nil, fmt.Errorf("avro reader requires a record schema")
+	}
+	fields, err := avroRecordFields(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	names := avroNames{}
+	names.add(schema, "")
+
+	return &AvroReader{
+		st:     st,
+		ocfr:   ocfr,
+		schema: schema,
+		names:  names,
+		fields: fields,
+		close:  close,
+	}, nil
+}
+
+// AvroSchema gives the avro schema embedded in the read source's header
+func (r *AvroReader) AvroSchema() map[string]interface{} {
+	return r.schema
+}
+
+// Structure gives this reader's structure
+func (r *AvroReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one avro record from the reader
+func (r *AvroReader) ReadEntry() (Entry, error) {
+	if !r.ocfr.Scan() {
+		if err := r.ocfr.Err(); err != nil {
+			log.Debug(err.Error())
+			return Entry{}, err
+		}
+		return Entry{}, io.EOF
+	}
+
+	datum, err := r.ocfr.Read()
+	if err != nil {
+		log.Debug(err.Error())
+		return Entry{}, err
+	}
+	rec, ok := datum.(map[string]interface{})
+	if !ok {
+		return Entry{}, fmt.Errorf("expected avro record, got: %T", datum)
+	}
+
+	row := make([]interface{}, len(r.fields))
+	for i, f := range r.fields {
+		v, err := r.names.value(rec[f.name], f.typ)
+		if err != nil {
+			return Entry{}, fmt.Errorf("record %d, field %q: %w", r.rowsRead, f.name, err)
+		}
+		if s, ok := v.(string); ok && f.json {
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return Entry{}, fmt.Errorf("record %d, field %q: %w", r.rowsRead, f.name, err)
+			}
+		}
+		row[i] = v
+	}
+
+	ent := Entry{Index: r.rowsRead, Value: row}
+	r.rowsRead++
+	return ent, nil
+}
+
+// Close finalizes the reader
+func (r *AvroReader) Close() error {
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+// avroRecordFields gives the fields of an avro record schema
+func avroRecordFields(schema map[string]interface{}) ([]avroField, error) {
+	if schema["type"] != "record" {
+		return nil, fmt.Errorf("avro reader requires a record schema")
+	}
+	fs, _ := schema["fields"].([]interface{})
+	fields := make([]avroField, len(fs))
+	for i, f := range fs {
+		fm, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid avro record field %d", i)
+		}
+		name, _ := fm["name"].(string)
+		fields[i] = avroField{name: name, typ: fm["type"]}
+		if attr, ok := fm[avroSchemaAttribute].(map[string]interface{}); ok {
+			fields[i].json = colKindFromColType(colTypeFromValue(attr["type"])) == colJSON
+		}
+	}
+	return fields, nil
+}
+
+// avroNames indexes the named types (records, enums and fixed) of an avro
+// schema by both short and full name, so type references can be resolved
+type avroNames map[string]map[string]interface{}
+
+// add registers all named types in a schema
+func (n avroNames) add(typ interface{}, namespace string) {
+	switch t := typ.(type) {
+	case []interface{}:
+		for _, member := range t {
+			n.add(member, namespace)
+		}
+	case map[string]interface{}:
+		switch t["type"] {
+		case "record", "error", "enum", "fixed":
+			name, _ := t["name"].(string)
+			if ns, ok := t["namespace"].(string); ok {
+				namespace = ns
+			}
+			n[name] = t
+			if namespace != "" {
+				n[namespace+"."+name] = t
+			}
+			if fs, ok := t["fields"].([]interface{}); ok {
+				for _, f := range fs {
+					if fm, ok := f.(map[string]interface{}); ok {
+						n.add(fm["type"], namespace)
+					}
+				}
+			}
+		case "array":
+			n.add(t["items"], namespace)
+		case "map":
+			n.add(t["values"], namespace)
+		}
+	}
+}
+
+// resolve dereferences named type references
+func (n avroNames) resolve(typ interface{}) interface{} {
+	if name, ok := typ.(string); ok {
+		if named, ok := n[name]; ok {
+			return named
+		}
+	}
+	return typ
+}
+
+// unionMember finds the member of a union goavro names with key
+func (n avroNames) unionMember(union []interface{}, key string) interface{} {
+	for _, member := range union {
+		if avroTypeName(member) == key {
+			return member
+		}
+	}
+	if named, ok := n[key]; ok {
+		return named
+	}
+	return nil
+}
+
+// avroTypeName gives the name goavro uses as the key for values of a type
+// within a union
+func avroTypeName(typ interface{}) string {
+	switch t := typ.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		name, _ := t["type"].(string)
+		switch name {
+		case "record", "error", "enum", "fixed":
+			name, _ = t["name"].(string)
+			if ns, ok := t["namespace"].(string); ok && ns != "" {
+				name = ns + "." + name
+			}
+		default:
+			if lt, ok := t["logicalType"].(string); ok {
+				name = name + "." + lt
+			}
+		}
+		return name
+	}
+	return ""
+}
+
+// value converts a value decoded by goavro to a go type that other dsio
+// writers understand. typ is the avro type of the value, and is used to
+// unwrap union values
+func (n avroNames) value(v interface{}, typ interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	typ = n.resolve(typ)
+
+	if union, ok := typ.([]interface{}); ok {
+		m, ok := v.(map[string]interface{})
+		if !ok || len(m) != 1 {
+			return nil, fmt.Errorf("invalid avro union value: %v", v)
+		}
+		for key, x := range m {
+			return n.value(x, n.unionMember(union, key))
+		}
+	}
+
+	t, _ := typ.(map[string]interface{})
+	switch x := v.(type) {
+	case bool, string, int64, float64:
+		return x, nil
+	case int32:
+		return int64(x), nil
+	case float32:
+		return float64(x), nil
+	case []byte:
+		return string(x), nil
+	case time.Time:
+		if t != nil && t["logicalType"] == "date" {
+			return x.UTC().Format("2006-01-02"), nil
+		}
+		return x.UTC().Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return x.String(), nil
+	case *big.Rat:
+		f, _ := x.Float64()
+		return f, nil
+	case []interface{}:
+		var items interface{}
+		if t != nil {
+			items = t["items"]
+		}
+		arr := make([]interface{}, len(x))
+		for i, item := range x {
+			val, err := n.value(item, items)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = val
+		}
+		return arr, nil
+	case map[string]interface{}:
+		fieldTypes := map[string]interface{}{}
+		if t != nil {
+			if t["type"] == "map" {
+				for key := range x {
+					fieldTypes[key] = t["values"]
+				}
+			} else if fs, ok := t["fields"].([]interface{}); ok {
+				for _, f := range fs {
+					if fm, ok := f.(map[string]interface{}); ok {
+						name, _ := fm["name"].(string)
+						fieldTypes[name] = fm["type"]
+					}
+				}
+			}
+		}
+		obj := make(map[string]interface{}, len(x))
+		for key, val := range x {
+			converted, err := n.value(val, fieldTypes[key])
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = converted
+		}
+		return obj, nil
+	default:
+		return nil, fmt.Errorf("unsupported avro value type: %T", v)
+	}
+}
+
+// AvroWriter implements the EntryWriter interface for avro object container
+// files. Column types are derived from the structure's tabular schema, each
+// row is written as a record with one optional field per column
+type AvroWriter struct {
+	st    *dataset.Structure
+	ocfw  *goavro.OCFWriter
+	cols  tabular.Columns
+	kinds []colKind
+	block []interface{}
+	close func() error // close func from wrapping
+}
+
+var _ EntryWriter = (*AvroWriter)(nil)
+
+// NewAvroWriter creates a Writer from a structure and write destination
+func NewAvroWriter(st *dataset.Structure, w io.Writer) (*AvroWriter, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("avro writer requires at least one column")
+	}
+
+	opts := &dataset.AvroOptions{}
+	if st.FormatConfig != nil {
+		if opts, err = dataset.NewAvroOptions(st.FormatConfig); err != nil {
+			return nil, err
+		}
+	}
+	name := opts.RecordName
+	if name == "" {
+		name = defaultAvroRecordName
+	}
+
+	schema, err := AvroSchemaFromJSONSchema(st.Schema, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	w, close, err := maybeWrapCompressor(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	ocfw, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:               w,
+		Schema:          string(data),
+		CompressionName: opts.Codec,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make([]colKind, len(cols))
+	for i, c := range cols {
+		kinds[i] = colKindFromColType(c.Type)
+	}
+
+	return &AvroWriter{
+		st:    st,
+		ocfw:  ocfw,
+		cols:  cols,
+		kinds: kinds,
+		close: close,
+	}, nil
+}
+
+// Structure gives this writer's structure
+func (w *AvroWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one avro record to the writer
+func (w *AvroWriter) WriteEntry(ent Entry) error {
+	arr, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value to write avro record. got: %v", ent)
+	}
+	if len(arr) > len(w.cols) {
+		return fmt.Errorf("row has %d values, schema defines %d columns", len(arr), len(w.cols))
+	}
+
+	rec := make(map[string]interface{}, len(w.cols))
+	for i, c := range w.cols {
+		var v interface{}
+		if i < len(arr) {
+			var err error
+			if v, err = colKindValue(arr[i], w.kinds[i]); err != nil {
+				return fmt.Errorf("column %q: %w", c.Title, err)
+			}
+		}
+		if v != nil {
+			v = goavro.Union(avroPrimitive(w.kinds[i]), v)
+		}
+		rec[c.Title] = v
+	}
+
+	w.block = append(w.block, rec)
+	if len(w.block) == avroBlockSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes buffered records as a container file block
+func (w *AvroWriter) flush() error {
+	err := w.ocfw.Append(w.block)
+	w.block = w.block[:0]
+	return err
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *AvroWriter) Close() error {
+	if len(w.block) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if w.close != nil {
+		return w.close()
+	}
+	return nil
+}
+
+// avroPrimitive gives the avro type a column kind is written as
+func avroPrimitive(k colKind) string {
+	switch k {
+	case colInteger:
+		return "long"
+	case colNumber:
+		return "double"
+	case colBoolean:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// AvroSchemaFromJSONSchema converts a tabular JSON schema to an avro record
+// schema with the given name. Each column becomes an optional field. Column
+// types avro can't express directly & validation keywords are kept as JSON in
+// a field attribute so they survive a round trip through
+// JSONSchemaFromAvroSchema
+func AvroSchemaFromJSONSchema(sch map[string]interface{}, name string) (map[string]interface{}, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]interface{}, len(cols))
+	for i, c := range cols {
+		if !validAvroName.MatchString(c.Title) {
+			return nil, fmt.Errorf("column %d title %q cannot be used as an avro field name", i, c.Title)
+		}
+
+		prim := avroPrimitive(colKindFromColType(c.Type))
+		field := map[string]interface{}{
+			"name":    c.Title,
+			"type":    []interface{}{"null", prim},
+			"default": nil,
+		}
+		if c.Description != "" {
+			field["doc"] = c.Description
+		}
+
+		colSchema := map[string]interface{}{}
+		for k, v := range c.Validation {
+			colSchema[k] = v
+		}
+		if c.Type != nil {
+			if len(*c.Type) != 1 || (*c.Type)[0] != avroJSONSchemaType(prim, nil) {
+				colSchema["type"] = colTypeValue(*c.Type)
+			}
+		}
+		if len(colSchema) > 0 {
+			field[avroSchemaAttribute] = colSchema
+		}
+		fields[i] = field
+	}
+
+	return map[string]interface{}{
+		"type":   "record",
+		"name":   name,
+		"fields": fields,
+	}, nil
+}
+
+// JSONSchemaFromAvroSchema converts an avro record schema to a tabular JSON
+// schema, with one column per record field
+func JSONSchemaFromAvroSchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	fields, err := avroRecordFields(schema)
+	if err != nil {
+		return nil, err
+	}
+	names := avroNames{}
+	names.add(schema, "")
+
+	fs, _ := schema["fields"].([]interface{})
+	items := make([]interface{}, len(fields))
+	for i, f := range fields {
+		fm := fs[i].(map[string]interface{})
+		col := map[string]interface{}{}
+		if attr, ok := fm[avroSchemaAttribute].(map[string]interface{}); ok {
+			for k, v := range attr {
+				col[k] = v
+			}
+		}
+		col["title"] = f.name
+		if doc, ok := fm["doc"].(string); ok {
+			col["description"] = doc
+		}
+		if _, ok := col["type"]; !ok {
+			col["type"] = avroJSONSchemaType(f.typ, names)
+		}
+		if enum, ok := names.resolve(f.typ).(map[string]interface{}); ok && enum["type"] == "enum" {
+			if _, ok := col["enum"]; !ok {
+				col["enum"] = enum["symbols"]
+			}
+		}
+		items[i] = col
+	}
+
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}, nil
+}
+
+// avroJSONSchemaType gives the JSON schema type for an avro type. Unions of
+// null and a single other type are treated as optional values of that type
+func avroJSONSchemaType(typ interface{}, names avroNames) interface{} {
+	switch t := names.resolve(typ).(type) {
+	case []interface{}:
+		var types []interface{}
+		hasNull := false
+		seen := map[interface{}]bool{}
+		for _, member := range t {
+			mt := avroJSONSchemaType(member, names)
+			if mt == "null" {
+				hasNull = true
+				continue
+			}
+			if !seen[mt] {
+				seen[mt] = true
+				types = append(types, mt)
+			}
+		}
+		switch {
+		case len(types) == 0:
+			return "null"
+		case len(types) == 1:
+			return types[0]
+		case hasNull:
+			types = append(types, "null")
+		}
+		return types
+	case map[string]interface{}:
+		switch t["type"] {
+		case "record", "error", "map":
+			return "object"
+		case "array":
+			return "array"
+		case "enum", "fixed":
+			return "string"
+		}
+		switch t["logicalType"] {
+		case "decimal":
+			return "number"
+		case "date", "time-millis", "time-micros", "timestamp-millis", "timestamp-micros":
+			return "string"
+		}
+		return avroJSONSchemaType(t["type"], names)
+	case string:
+		switch t {
+		case "null":
+			return "null"
+		case "boolean":
+			return "boolean"
+		case "int", "long":
+			return "integer"
+		case "float", "double":
+			return "number"
+		}
+	}
+	return "string"
+}
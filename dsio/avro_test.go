@@ -0,0 +1,271 @@
+package dsio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/linkedin/goavro/v2"
+	"github.com/qri-io/dataset"
+)
+
+var avroTestSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "name", "type": "string", "description": "a name", "maxLength": float64(10)},
+			map[string]interface{}{"title": "count", "type": "integer"},
+			map[string]interface{}{"title": "ratio", "type": []interface{}{"number", "null"}},
+			map[string]interface{}{"title": "ok", "type": "boolean"},
+			map[string]interface{}{"title": "meta", "type": "object"},
+			map[string]interface{}{"title": "mixed", "type": []interface{}{"string", "integer"}},
+		},
+	},
+}
+
+func TestAvroReadWrite(t *testing.T) {
+	entries := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}, int64(2)},
+		[]interface{}{"b", int64(2), nil, false, nil, "two"},
+		[]interface{}{nil, "3", int64(3), "true", map[string]interface{}{}},
+	}
+	expect := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}, "2"},
+		[]interface{}{"b", int64(2), nil, false, nil, "two"},
+		[]interface{}{nil, int64(3), float64(3), true, map[string]interface{}{}, nil},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+	}{
+		{"plain", &dataset.Structure{Format: "avro", Schema: avroTestSchema}},
+		{"deflate codec", &dataset.Structure{Format: "avro", Schema: avroTestSchema, FormatConfig: map[string]interface{}{"codec": "deflate", "recordName": "row"}}},
+		{"gzip", &dataset.Structure{Format: "avro", Compression: "gzip", Schema: avroTestSchema}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range entries {
+				if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAvroManyRows(t *testing.T) {
+	st := &dataset.Structure{Format: "avro", Schema: avroTestSchema}
+	buf := &bytes.Buffer{}
+	w, err := NewAvroWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := avroBlockSize*2 + 10
+	for i := 0; i < count; i++ {
+		if err := w.WriteEntry(Entry{Value: []interface{}{"x", i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewAvroReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	err = EachEntry(r, func(_ int, ent Entry, _ error) error {
+		if got := ent.Value.([]interface{})[1]; got != int64(i) {
+			t.Fatalf("row %d count mismatch. got: %v", i, got)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != count {
+		t.Errorf("row count mismatch. expected: %d, got: %d", count, i)
+	}
+}
+
+const avroNestedSchema = `{
+	"type": "record",
+	"name": "event",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "int"},
+		{"name": "kind", "type": {"type": "enum", "name": "kind", "symbols": ["A", "B"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": ["null", "long"]}},
+		{"name": "parent", "type": ["null", {"type": "record", "name": "ref", "fields": [{"name": "id", "type": "long"}]}]},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "at", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]},
+		{"name": "raw", "type": "bytes", "doc": "raw bytes"}
+	]
+}`
+
+func TestAvroReadNestedTypes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ocfw, err := goavro.NewOCFWriter(goavro.OCFConfig{W: buf, Schema: avroNestedSchema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = ocfw.Append([]interface{}{map[string]interface{}{
+		"id":     int32(1),
+		"kind":   "B",
+		"tags":   []interface{}{"x", "y"},
+		"attrs":  map[string]interface{}{"a": goavro.Union("long", int64(2)), "b": nil},
+		"parent": goavro.Union("com.example.ref", map[string]interface{}{"id": int64(7)}),
+		"day":    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		"at":     goavro.Union("long.timestamp-millis", at),
+		"raw":    []byte("bytes"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewAvroReader(&dataset.Structure{Format: "avro"}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{
+			int64(1),
+			"B",
+			[]interface{}{"x", "y"},
+			map[string]interface{}{"a": int64(2), "b": nil},
+			map[string]interface{}{"id": int64(7)},
+			"2020-01-01",
+			"2020-01-02T03:04:05Z",
+			"bytes",
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	gotSchema, err := JSONSchemaFromAvroSchema(r.AvroSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "id", "type": "integer"},
+				map[string]interface{}{"title": "kind", "type": "string", "enum": []interface{}{"A", "B"}},
+				map[string]interface{}{"title": "tags", "type": "array"},
+				map[string]interface{}{"title": "attrs", "type": "object"},
+				map[string]interface{}{"title": "parent", "type": "object"},
+				map[string]interface{}{"title": "day", "type": "string"},
+				map[string]interface{}{"title": "at", "type": "string"},
+				map[string]interface{}{"title": "raw", "type": "string", "description": "raw bytes"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSchema, gotSchema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAvroSchemaRoundTrip(t *testing.T) {
+	as, err := AvroSchemaFromJSONSchema(avroTestSchema, "entry")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTypes := []string{"string", "long", "double", "boolean", "string", "string"}
+	for i, f := range as["fields"].([]interface{}) {
+		typ := f.(map[string]interface{})["type"].([]interface{})
+		if typ[1] != expectTypes[i] {
+			t.Errorf("field %d type mismatch. expected: %s, got: %s", i, expectTypes[i], typ[1])
+		}
+	}
+
+	got, err := JSONSchemaFromAvroSchema(as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(avroTestSchema, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := AvroSchemaFromJSONSchema(dataset.BaseSchemaArray, "entry"); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+	if _, err := JSONSchemaFromAvroSchema(map[string]interface{}{"type": "string"}); err == nil {
+		t.Error("expected non-record schema to error")
+	}
+}
+
+func TestAvroErrors(t *testing.T) {
+	if _, err := NewAvroWriter(&dataset.Structure{Format: "avro", Schema: dataset.BaseSchemaArray}, &bytes.Buffer{}); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+	if _, err := NewAvroWriter(&dataset.Structure{Format: "avro", Schema: avroTestSchema, FormatConfig: map[string]interface{}{"codec": "nope"}}, &bytes.Buffer{}); err == nil {
+		t.Error("expected unknown codec to error")
+	}
+	badTitle := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": []interface{}{map[string]interface{}{"title": "a b", "type": "string"}},
+		},
+	}
+	if _, err := NewAvroWriter(&dataset.Structure{Format: "avro", Schema: badTitle}, &bytes.Buffer{}); err == nil {
+		t.Error("expected invalid field name to error")
+	}
+	if _, err := NewAvroReader(&dataset.Structure{Format: "avro"}, bytes.NewBufferString("not an avro file")); err == nil {
+		t.Error("expected invalid data to error")
+	}
+
+	st := &dataset.Structure{Format: "avro", Schema: avroTestSchema}
+	w, err := NewAvroWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: "not a row"}); err == nil {
+		t.Error("expected non-array entry to error")
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", "not a number"}}); err == nil {
+		t.Error("expected invalid integer to error")
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", 1, 2, true, nil, nil, "extra"}}); err == nil {
+		t.Error("expected too many values to error")
+	}
+}
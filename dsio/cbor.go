@@ -4,9 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"math/big"
+	"strconv"
+	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/ugorji/go/codec"
@@ -20,6 +24,7 @@ type CBORReader struct {
 	st       *dataset.Structure
 	topLevel byte
 	length   int
+	numbers  dataset.NumberMode
 }
 
 var _ EntryReader = (*CBORReader)(nil)
@@ -36,6 +41,11 @@ func NewCBORReader(st *dataset.Structure, r io.Reader) (*CBORReader, error) {
 		return nil, err
 	}
 
+	opts, err := dataset.NewCBOROptions(st.FormatConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	r, close, err := maybeWrapDecompressor(st, r)
 	if err != nil {
 		return nil, err
@@ -58,6 +68,7 @@ func NewCBORReader(st *dataset.Structure, r io.Reader) (*CBORReader, error) {
 		rdr:      bufio.NewReader(r),
 		topLevel: topLevel,
 		close:    close,
+		numbers:  opts.Numbers,
 	}, nil
 }
 
@@ -141,12 +152,31 @@ const (
 	cborBaseSimple      = 0xe0
 )
 
+// semantic tags, see https://www.iana.org/assignments/cbor-tags
+const (
+	cborTagDateTime        uint64 = 0
+	cborTagEpochDateTime   uint64 = 1
+	cborTagPosBignum       uint64 = 2
+	cborTagNegBignum       uint64 = 3
+	cborTagDecimalFraction uint64 = 4
+	cborTagEmbedded        uint64 = 24
+	cborTagSelfDescribe    uint64 = 55799
+)
+
+// cborSelfDescribe is the encoded self-describe tag, which may prefix CBOR
+// data to mark it as CBOR
+var cborSelfDescribe = []byte{0xd9, 0xd9, 0xf7}
+
 const indefiniteLength int = -1
 
 const cborTypeMask byte = 0xe0
 
 // readTopLevel determines the top-level type, either "object" or "array"
 func (r *CBORReader) readTopLevel() (byte, int, error) {
+	if prefix, err := r.rdr.Peek(len(cborSelfDescribe)); err == nil && bytes.Equal(prefix, cborSelfDescribe) {
+		_, _ = r.rdr.Discard(len(cborSelfDescribe))
+	}
+
 	b, err := r.rdr.ReadByte()
 	if err != nil {
 		return 0, 0, err
@@ -202,9 +232,17 @@ func (r *CBORReader) readValue() (interface{}, error) {
 	}
 
 	if b < 0x1c {
-		return r.getVarLenInt(b)
-	} else if b >= 0x20 && b < 0x38 {
-		return -int64(b - 0x1f), nil
+		n, err := r.getVarLenUint(b)
+		if err != nil {
+			return nil, err
+		}
+		return r.intValue(n, false), nil
+	} else if b >= 0x20 && b < 0x3c {
+		n, err := r.getVarLenUint(b)
+		if err != nil {
+			return nil, err
+		}
+		return r.intValue(n, true), nil
 	}
 
 	switch b {
@@ -214,12 +252,12 @@ func (r *CBORReader) readValue() (interface{}, error) {
 		return false, nil
 	case cborBdTrue:
 		return true, nil
-	case cborBdFloat16:
-		return r.readFloatBytes(2)
-	case cborBdFloat32:
-		return r.readFloatBytes(4)
-	case cborBdFloat64:
-		return r.readFloatBytes(8)
+	case cborBdFloat16, cborBdFloat32, cborBdFloat64:
+		f, err := r.readFloatBytes(2 << (b - cborBdFloat16))
+		if err != nil {
+			return nil, err
+		}
+		return floatValue(f, r.numbers), nil
 	case cborBdIndefiniteBytes:
 		concat := bytes.Buffer{}
 		for {
@@ -302,8 +340,11 @@ func (r *CBORReader) readValue() (interface{}, error) {
 			}
 			return assoc, nil
 		case cborBaseTag:
-			// TODO: Implement me
-			return nil, nil
+			tag, err := r.getVarLenUint(b)
+			if err != nil {
+				return nil, err
+			}
+			return r.readTagged(tag)
 		case cborBaseSimple:
 			// TODO: Implement me
 			return nil, nil
@@ -388,24 +429,31 @@ func (r *CBORReader) readMap(length int) (map[string]interface{}, error) {
 
 // getVarLenInt handles the byte most recently read, and possibly reads more bytes, to get an int
 func (r *CBORReader) getVarLenInt(b byte) (int64, error) {
+	n, err := r.getVarLenUint(b)
+	return int64(n), err
+}
+
+// getVarLenUint handles the byte most recently read, and possibly reads more bytes, to get
+// an unsigned int
+func (r *CBORReader) getVarLenUint(b byte) (uint64, error) {
 	b = b & 0x1f
 	if b < 0x18 {
-		return int64(b), nil
+		return uint64(b), nil
 	} else if b == 0x18 {
-		return r.readIntBytes(1)
+		return r.readUintBytes(1)
 	} else if b == 0x19 {
-		return r.readIntBytes(2)
+		return r.readUintBytes(2)
 	} else if b == 0x1a {
-		return r.readIntBytes(4)
+		return r.readUintBytes(4)
 	} else if b == 0x1b {
-		return r.readIntBytes(8)
+		return r.readUintBytes(8)
 	} else {
 		return 0, fmt.Errorf("Could not decode variable length int: %v", b)
 	}
 }
 
-// readIntBytes returns an int by reading num bytes from the input stream
-func (r *CBORReader) readIntBytes(num int) (int64, error) {
+// readUintBytes returns an unsigned int by reading num bytes from the input stream
+func (r *CBORReader) readUintBytes(num int) (uint64, error) {
 	data, err := r.readBytes(num)
 	if err != nil {
 		return 0, err
@@ -413,21 +461,212 @@ func (r *CBORReader) readIntBytes(num int) (int64, error) {
 	if num < 8 {
 		data = bytes.Join([][]byte{bytes.Repeat([]byte{0}, 8-len(data)), data}, []byte{})
 	}
-	return int64(binary.BigEndian.Uint64(data)), nil
+	return binary.BigEndian.Uint64(data), nil
 }
 
-// readFloatBytes returns a float by reading num bytes from the input stream
+// readFloatBytes returns a float by reading a half, single, or double precision float of num
+// bytes from the input stream
 func (r *CBORReader) readFloatBytes(num int) (float64, error) {
 	data, err := r.readBytes(num)
 	if err != nil {
 		return 0.0, err
 	}
-	if num < 8 {
-		data = bytes.Join([][]byte{bytes.Repeat([]byte{0}, 8-len(data)), data}, []byte{})
+	switch num {
+	case 2:
+		return float16(binary.BigEndian.Uint16(data)), nil
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
 	}
 	return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
 }
 
+// float16 converts the bits of a half precision float to a float64
+func float16(bits uint16) float64 {
+	exp := int(bits>>10) & 0x1f
+	frac := float64(bits & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(frac+1024, exp-25)
+	}
+	if bits&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+// intValue converts an integer read from the input stream to the reader's number mode. neg
+// integers have the value -1 - n
+func (r *CBORReader) intValue(n uint64, neg bool) interface{} {
+	if r.numbers == dataset.NumbersNative && n <= math.MaxInt64 {
+		if neg {
+			return -1 - int64(n)
+		}
+		return int64(n)
+	}
+
+	i := new(big.Int).SetUint64(n)
+	if neg {
+		i.Neg(i).Sub(i, big.NewInt(1))
+	}
+	return bigIntValue(i, r.numbers)
+}
+
+// readTagged reads the data item following a semantic tag. Date-times are read as time.Time
+// values, numeric tags are converted to the reader's number mode, and embedded CBOR is
+// decoded. Other tags are dropped, leaving the tagged data item
+func (r *CBORReader) readTagged(tag uint64) (interface{}, error) {
+	switch tag {
+	case cborTagDateTime:
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("date-time must be a string")
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case cborTagEpochDateTime:
+		v, err := r.readNativeValue()
+		if err != nil {
+			return nil, err
+		}
+		switch x := v.(type) {
+		case int64:
+			return time.Unix(x, 0).UTC(), nil
+		case float64:
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				return nil, fmt.Errorf("invalid epoch date-time: %v", x)
+			}
+			sec, frac := math.Modf(x)
+			return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), nil
+		}
+		return nil, fmt.Errorf("epoch date-time must be a number")
+	case cborTagEmbedded:
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		data, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("embedded cbor must be a byte string")
+		}
+		br := bytes.NewReader(data)
+		embedded := &CBORReader{
+			st:      r.st,
+			rdr:     bufio.NewReader(br),
+			numbers: r.numbers,
+		}
+		v, err = embedded.readValue()
+		if err != nil {
+			return nil, err
+		}
+		if n := embedded.rdr.Buffered() + br.Len(); n > 0 {
+			return nil, fmt.Errorf("embedded cbor has %d trailing bytes", n)
+		}
+		return v, nil
+	case cborTagPosBignum, cborTagNegBignum:
+		i, err := r.readBignum(tag)
+		if err != nil {
+			return nil, err
+		}
+		return bigIntValue(i, r.numbers), nil
+	case cborTagDecimalFraction:
+		b, err := r.rdr.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != cborBaseArray|2 {
+			return nil, fmt.Errorf("decimal fraction must be an array of two integers")
+		}
+		exp, err := r.readInteger()
+		if err != nil {
+			return nil, err
+		}
+		if !exp.IsInt64() {
+			return nil, fmt.Errorf("decimal fraction exponent out of range")
+		}
+		mantissa, err := r.readInteger()
+		if err != nil {
+			return nil, err
+		}
+		text := decimalText(mantissa, exp.Int64())
+		switch r.numbers {
+		case dataset.NumbersDecimal:
+			return json.Number(text), nil
+		case dataset.NumbersBig:
+			return parseBigFloat(text)
+		}
+		return strconv.ParseFloat(text, 64)
+	}
+	return r.readValue()
+}
+
+// readNativeValue reads a value with numbers decoded as int64 & float64 values, regardless
+// of the reader's number mode
+func (r *CBORReader) readNativeValue() (interface{}, error) {
+	mode := r.numbers
+	r.numbers = dataset.NumbersNative
+	defer func() { r.numbers = mode }()
+	return r.readValue()
+}
+
+// readBignum reads the byte string of a bignum tag
+func (r *CBORReader) readBignum(tag uint64) (*big.Int, error) {
+	v, err := r.readValue()
+	if err != nil {
+		return nil, err
+	}
+	data, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("bignum must be a byte string")
+	}
+	i := new(big.Int).SetBytes(data)
+	if tag == cborTagNegBignum {
+		i.Neg(i).Sub(i, big.NewInt(1))
+	}
+	return i, nil
+}
+
+// readInteger reads an integer or bignum from the input stream
+func (r *CBORReader) readInteger() (*big.Int, error) {
+	b, err := r.rdr.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b & cborTypeMask {
+	case cborBaseUint, cborBaseNegInt:
+		n, err := r.getVarLenUint(b)
+		if err != nil {
+			return nil, err
+		}
+		i := new(big.Int).SetUint64(n)
+		if b&cborTypeMask == cborBaseNegInt {
+			i.Neg(i).Sub(i, big.NewInt(1))
+		}
+		return i, nil
+	case cborBaseTag:
+		tag, err := r.getVarLenUint(b)
+		if err != nil {
+			return nil, err
+		}
+		if tag == cborTagPosBignum || tag == cborTagNegBignum {
+			return r.readBignum(tag)
+		}
+	}
+	return nil, fmt.Errorf("expected integer")
+}
+
 // readBytes reads a number of bytes from the input stream
 func (r *CBORReader) readBytes(num int) ([]byte, error) {
 	buff, err := r.rdr.Peek(num)
@@ -442,7 +681,8 @@ func (r *CBORReader) readBytes(num int) ([]byte, error) {
 }
 
 // CBORWriter implements the RowWriter interface for
-// CBOR-formatted data
+// CBOR-formatted data. Values read from embedded CBOR (tag 24) are written
+// inline, not re-embedded
 type CBORWriter struct {
 	rowsWritten int
 	tlt         string
@@ -523,6 +763,10 @@ func (w *CBORWriter) Close() error {
 	if w.tlt == "object" {
 		v = w.obj
 	}
+	v, err := exactCBOR(v)
+	if err != nil {
+		return err
+	}
 	if err := enc.Encode(v); err != nil {
 		return err
 	}
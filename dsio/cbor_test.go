@@ -8,6 +8,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
@@ -36,7 +37,6 @@ var (
 	}}
 )
 
-// TODO(dustmop): Tag support.
 // TODO(dustmop): Test illegal chunks.
 // TODO(dustmop): Move indefinite streams to their own test, test that 0xff correctly returns EOF.
 
@@ -89,6 +89,23 @@ func TestCBORReaderOneArrayEntry(t *testing.T) {
 
 		// Top-level array of indetermine size
 		{`9f16ff`, int64(22), ""}, // [22]
+		// self-described cbor
+		{`d9d9f78116`, int64(22), ""}, // [22]
+
+		// tagged date-time string - [0("2013-03-21T20:04:00Z")]
+		{`81C074323031332D30332D32315432303A30343A30305A`, time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), ""},
+		// tagged epoch date-time - [1(1363896240)]
+		{`81C11A514B67B0`, time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), ""},
+		// tagged epoch date-time with fractional seconds - [1(1363896240.5)]
+		{`81C1FB41D452D9EC200000`, time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC), ""},
+		// embedded cbor - [24(h'83010203')]
+		{`81D8184483010203`, []interface{}{int64(1), int64(2), int64(3)}, ""},
+		// unrecognized tags are dropped - [32("a")]
+		{`81D8206161`, "a", ""},
+		{`81C001`, nil, "date-time must be a string"},
+		{`81C16161`, nil, "epoch date-time must be a number"},
+		{`81D81801`, nil, "embedded cbor must be a byte string"},
+		{`81D818458301020304`, nil, "embedded cbor has 1 trailing bytes"},
 	}
 
 	for i, c := range arrCases {
@@ -506,3 +523,42 @@ func BenchmarkCBORReader(b *testing.B) {
 		}
 	}
 }
+
+func TestCBORTagsRoundTrip(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	values := []interface{}{
+		time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC),
+		time.Date(2018, 2, 14, 10, 0, 51, 274376000, est),
+		mustBigInt("-18446744073709551616"),
+		map[string]interface{}{"created": time.Date(2020, 1, 1, 0, 0, 0, 1, time.UTC)},
+	}
+
+	buf := &bytes.Buffer{}
+	st := &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"numbers": "big"}}
+	w, err := NewCBORWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewCBORReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sameTime := cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })
+	if diff := cmp.Diff(values, got, sameTime, bigComparers); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,187 @@
+package dsio
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// colKind is the single storage type a tabular column is written as by
+// columnar formats, which can't store values of mixed types in one column
+type colKind int
+
+const (
+	colString colKind = iota
+	colInteger
+	colNumber
+	colBoolean
+	colJSON
+)
+
+// colKindFromColType maps a tabular column type to a storage type.
+// "null" is ignored because all columns are written as optional. Columns that
+// mix types that can't be represented in a single column are written as strings
+func colKindFromColType(ct *tabular.ColType) colKind {
+	set := map[string]bool{}
+	if ct != nil {
+		for _, t := range *ct {
+			if t != "null" {
+				set[t] = true
+			}
+		}
+	}
+
+	switch {
+	case len(set) == 1 && set["integer"]:
+		return colInteger
+	case len(set) == 1 && set["number"], len(set) == 2 && set["integer"] && set["number"]:
+		return colNumber
+	case len(set) == 1 && set["boolean"]:
+		return colBoolean
+	case len(set) == 1 && (set["object"] || set["array"]), len(set) == 2 && set["object"] && set["array"]:
+		return colJSON
+	default:
+		return colString
+	}
+}
+
+// ColumnStorageType gives the single type columnar formats store values of a
+// tabular column type as: "integer", "number", "boolean", "json" or "string"
+func ColumnStorageType(ct *tabular.ColType) string {
+	return colKindFromColType(ct).String()
+}
+
+// ColumnValue converts a value to the go type columnar formats store for a
+// tabular column type: int64, float64, bool or string, with object & array
+// values encoded as JSON strings. nil values stay nil
+func ColumnValue(v interface{}, ct *tabular.ColType) (interface{}, error) {
+	return colKindValue(v, colKindFromColType(ct))
+}
+
+// String implements the stringer interface
+func (k colKind) String() string {
+	return map[colKind]string{
+		colString:  "string",
+		colInteger: "integer",
+		colNumber:  "number",
+		colBoolean: "boolean",
+		colJSON:    "json",
+	}[k]
+}
+
+// colKindValue converts a go value to the go type for a column kind. Values
+// of the JSON kind are encoded as JSON strings. Decimal & big numbers are
+// converted to int64 or float64 values, which can lose precision
+func colKindValue(v interface{}, k colKind) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch k {
+	case colInteger:
+		switch x := v.(type) {
+		case int:
+			return int64(x), nil
+		case int64:
+			return x, nil
+		case float64:
+			if x == float64(int64(x)) {
+				return int64(x), nil
+			}
+		case json.Number:
+			if i, err := x.Int64(); err == nil {
+				return i, nil
+			}
+		case *big.Int:
+			if x.IsInt64() {
+				return x.Int64(), nil
+			}
+		case *big.Float:
+			if i, acc := x.Int64(); acc == big.Exact {
+				return i, nil
+			}
+		case string:
+			return vals.ParseInteger([]byte(x))
+		}
+	case colNumber:
+		switch x := v.(type) {
+		case int:
+			return float64(x), nil
+		case int64:
+			return float64(x), nil
+		case float64:
+			return x, nil
+		case json.Number:
+			return x.Float64()
+		case *big.Int:
+			f, _ := new(big.Float).SetInt(x).Float64()
+			return f, nil
+		case *big.Float:
+			f, _ := x.Float64()
+			return f, nil
+		case string:
+			return vals.ParseNumber([]byte(x))
+		}
+	case colBoolean:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			return vals.ParseBoolean([]byte(x))
+		}
+	case colJSON:
+		data, err := json.Marshal(exactJSON(v))
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case colString:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case bool:
+			return strconv.FormatBool(x), nil
+		default:
+			strs, err := encode([]interface{}{v})
+			if err != nil {
+				return nil, err
+			}
+			return strs[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot write %T value as %s", v, k)
+}
+
+// colTypeValue gives the JSON schema "type" value for a column type
+func colTypeValue(ct tabular.ColType) interface{} {
+	if len(ct) == 1 {
+		return ct[0]
+	}
+	types := make([]interface{}, len(ct))
+	for i, t := range ct {
+		types[i] = t
+	}
+	return types
+}
+
+// colTypeFromValue reads a JSON schema "type" value as a column type
+func colTypeFromValue(v interface{}) *tabular.ColType {
+	switch x := v.(type) {
+	case string:
+		return &tabular.ColType{x}
+	case []interface{}:
+		ct := tabular.ColType{}
+		for _, t := range x {
+			if s, ok := t.(string); ok {
+				ct = append(ct, s)
+			}
+		}
+		return &ct
+	}
+	return nil
+}
@@ -1,14 +1,15 @@
 package dsio
 
 import (
-	"encoding/csv"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"strconv"
+	"time"
 
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/dataset/dsio/replacecr"
 	"github.com/qri-io/dataset/tabular"
 	"github.com/qri-io/dataset/vals"
 )
@@ -17,15 +18,18 @@ import (
 type CSVReader struct {
 	st         *dataset.Structure
 	readHeader bool
-	r          *csv.Reader
+	r          *csvRecordReader
+	dialect    csvDialect
 	close      func() error
+	lenient    bool
+	quarantine quarantine
 
 	// TODO (b5) - this will create problems if users define schemas that support
 	// mutiple types per column. Should replace with a tabular.Columns field
 	types []string
 }
 
-var _ EntryReader = (*CSVReader)(nil)
+var _ LenientReader = (*CSVReader)(nil)
 
 // NewCSVReader creates a reader from a structure and read source
 func NewCSVReader(st *dataset.Structure, r io.Reader) (*CSVReader, error) {
@@ -45,43 +49,61 @@ func NewCSVReaderSize(st *dataset.Structure, r io.Reader, size int) (*CSVReader,
 		types[i] = []string(*c.Type)[0]
 	}
 
-	dr, close, err := maybeWrapDecompressor(st, r)
+	dialect, err := csvStructureDialect(st)
 	if err != nil {
 		return nil, err
 	}
 
-	csvr := csv.NewReader(replacecr.ReaderWithSize(dr, size))
+	dr, close, err := maybeWrapTextDecoder(st, r)
+	if err != nil {
+		return nil, err
+	}
 
-	if fopts, err := dataset.ParseFormatConfigMap(dataset.CSVDataFormat, st.FormatConfig); err == nil {
-		if opts, ok := fopts.(*dataset.CSVOptions); ok {
-			csvr.LazyQuotes = opts.LazyQuotes
-			if opts.VariadicFields == true {
-				csvr.FieldsPerRecord = -1
-			}
-			if opts.Separator != rune(0) {
-				csvr.Comma = opts.Separator
-			}
-		}
+	rr := newCSVRecordReader(dr, size, dialect)
+	lenient := false
+	if opts, err := dataset.NewCSVOptions(st.FormatConfig); err == nil && opts.Lenient {
+		lenient = true
+		rr.raw = &bytes.Buffer{}
 	}
 
 	return &CSVReader{
-		st:    st,
-		r:     csvr,
-		types: types,
-		close: close,
+		st:         st,
+		r:          rr,
+		dialect:    dialect,
+		types:      types,
+		close:      close,
+		lenient:    lenient,
+		quarantine: quarantine{st: st},
 	}, nil
 }
 
+// csvStructureDialect gets the CSV dialect for a structure. Invalid format
+// configuration falls back to the default dialect
+func csvStructureDialect(st *dataset.Structure) (csvDialect, error) {
+	var opts *dataset.CSVOptions
+	if fopts, err := dataset.ParseFormatConfigMap(dataset.CSVDataFormat, st.FormatConfig); err == nil {
+		opts, _ = fopts.(*dataset.CSVOptions)
+	}
+	d := newCSVDialect(opts)
+	return d, d.validate()
+}
+
 // Structure gives this reader's structure
 func (r *CSVReader) Structure() *dataset.Structure {
 	return r.st
 }
 
-// ReadEntry reads one CSV record from the reader
+// SetQuarantine sets a writer to report the records a lenient reader skips to
+func (r *CSVReader) SetQuarantine(w io.Writer) {
+	r.quarantine.w = w
+}
+
+// ReadEntry reads one CSV record from the reader. Lenient readers skip
+// malformed records
 func (r *CSVReader) ReadEntry() (Entry, error) {
 	if !r.readHeader {
 		if HasHeaderRow(r.st) {
-			if _, err := r.r.Read(); err != nil {
+			if _, _, err := r.r.Read(); err != nil {
 				if err.Error() != "EOF" {
 					log.Debug(err.Error())
 				}
@@ -91,13 +113,23 @@ func (r *CSVReader) ReadEntry() (Entry, error) {
 		r.readHeader = true
 	}
 
-	data, err := r.r.Read()
+	data, quoted, err := r.r.Read()
+	for err != nil && r.lenient {
+		rerr := r.r.skipRecord(err)
+		if rerr == nil {
+			break
+		}
+		if err := r.quarantine.add(rerr); err != nil {
+			return Entry{}, err
+		}
+		data, quoted, err = r.r.Read()
+	}
 	if err != nil {
 		log.Debug(err.Error())
 		return Entry{}, err
 	}
 
-	value, err := r.decode(data)
+	value, err := r.decode(data, quoted)
 	if err != nil {
 		log.Debug(err.Error())
 		return Entry{}, err
@@ -116,8 +148,8 @@ func (r *CSVReader) Close() error {
 
 // decode uses specified types from structure's schema to cast csv string values to their
 // intended types. If casting fails because the data is invalid, it's left as a string instead
-// of causing an error.
-func (r *CSVReader) decode(strings []string) ([]interface{}, error) {
+// of causing an error. Unquoted values that match one of the dialect's null values are nil
+func (r *CSVReader) decode(strings []string, quoted []bool) ([]interface{}, error) {
 	vs := make([]interface{}, len(strings))
 	types := r.types
 	if len(types) < len(strings) {
@@ -130,6 +162,10 @@ func (r *CSVReader) decode(strings []string) ([]interface{}, error) {
 	}
 	for i, str := range strings {
 		vs[i] = str
+		if !quoted[i] && r.dialect.isNull(str) {
+			vs[i] = nil
+			continue
+		}
 
 		switch types[i] {
 		case "number":
@@ -176,7 +212,7 @@ func HasHeaderRow(st *dataset.Structure) bool {
 // CSV-formatted data
 type CSVWriter struct {
 	rowsWritten int
-	w           *csv.Writer
+	w           *csvRecordWriter
 	st          *dataset.Structure
 	close       func() error
 
@@ -198,19 +234,17 @@ func NewCSVWriter(st *dataset.Structure, w io.Writer) (*CSVWriter, error) {
 		types[i] = []string(*c.Type)[0]
 	}
 
-	cw, close, err := maybeWrapCompressor(st, w)
+	dialect, err := csvStructureDialect(st)
 	if err != nil {
 		return nil, err
 	}
 
-	writer := csv.NewWriter(cw)
-	opts, err := dataset.NewCSVOptions(st.FormatConfig)
-	if opts != nil && err == nil {
-		if opts.Separator != rune(0) {
-			writer.Comma = opts.Separator
-		}
+	cw, close, err := maybeWrapTextEncoder(st, w)
+	if err != nil {
+		return nil, err
 	}
 
+	writer := newCSVRecordWriter(cw, dialect)
 	wr := &CSVWriter{
 		st:    st,
 		w:     writer,
@@ -218,10 +252,8 @@ func NewCSVWriter(st *dataset.Structure, w io.Writer) (*CSVWriter, error) {
 		close: close,
 	}
 
-	if opts != nil {
-		if opts.HeaderRow {
-			writer.Write(cols.Titles())
-		}
+	if opts, err := dataset.NewCSVOptions(st.FormatConfig); err == nil && opts.HeaderRow {
+		writer.Write(cols.Titles(), nil)
 	}
 
 	return wr, nil
@@ -240,7 +272,11 @@ func (w *CSVWriter) WriteEntry(ent Entry) error {
 			log.Debug(err.Error())
 			return fmt.Errorf("error encoding entry: %s", err.Error())
 		}
-		return w.w.Write(strs)
+		nulls := make([]bool, len(arr))
+		for i, v := range arr {
+			nulls[i] = v == nil
+		}
+		return w.w.Write(strs, nulls)
 	}
 	return fmt.Errorf("expected array value to write csv row. got: %v", ent)
 }
@@ -260,12 +296,20 @@ func encode(vs []interface{}) ([]string, error) {
 			strings[i] = strconv.Itoa(int(t))
 		case float64:
 			strings[i] = strconv.FormatFloat(t, 'f', -1, 64)
+		case json.Number:
+			strings[i] = t.String()
+		case *big.Int:
+			strings[i] = t.String()
+		case *big.Float:
+			strings[i] = t.Text('g', -1)
+		case time.Time:
+			strings[i] = t.Format(time.RFC3339Nano)
 		case []interface{}:
-			if data, err := json.Marshal(t); err == nil {
+			if data, err := json.Marshal(exactJSON(t)); err == nil {
 				strings[i] = string(data)
 			}
 		case map[string]interface{}:
-			if data, err := json.Marshal(t); err == nil {
+			if data, err := json.Marshal(exactJSON(t)); err == nil {
 				strings[i] = string(data)
 			}
 		case bool:
@@ -285,7 +329,9 @@ func encode(vs []interface{}) ([]string, error) {
 // Close finalizes the writer, indicating no more records
 // will be written
 func (w *CSVWriter) Close() error {
-	w.w.Flush()
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
 	if w.close != nil {
 		return w.close()
 	}
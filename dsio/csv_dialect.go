@@ -0,0 +1,474 @@
+package dsio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+)
+
+// errCSVFieldCount is returned for records with a different number of fields
+// than the first record when variadic fields aren't allowed
+var errCSVFieldCount = errors.New("wrong number of fields")
+
+// csvDialect describes how CSV records are delimited, quoted & escaped. The
+// standard library csv package only handles a configurable separator, so
+// dsio reads & writes CSV with its own record reader & writer
+type csvDialect struct {
+	sep        rune
+	quote      rune
+	escape     rune // zero when quotes are escaped by doubling
+	comment    string
+	lazyQuotes bool
+	variadic   bool
+	trimStart  bool
+	trimEnd    bool
+	nulls      []string
+	skipRows   int
+	terminator string
+}
+
+// newCSVDialect creates a dialect from CSV options, which may be nil
+func newCSVDialect(opts *dataset.CSVOptions) csvDialect {
+	d := csvDialect{sep: ',', quote: '"', terminator: "\n"}
+	if opts == nil {
+		return d
+	}
+	if opts.Separator != rune(0) {
+		d.sep = opts.Separator
+	}
+	if opts.QuoteChar != rune(0) {
+		d.quote = opts.QuoteChar
+	}
+	if opts.EscapeChar != d.quote {
+		d.escape = opts.EscapeChar
+	}
+	if opts.LineTerminator != "" {
+		d.terminator = opts.LineTerminator
+	}
+	d.comment = opts.CommentPrefix
+	d.lazyQuotes = opts.LazyQuotes
+	d.variadic = opts.VariadicFields
+	d.trimStart = opts.Trim == dataset.CSVTrimStart || opts.Trim == dataset.CSVTrimBoth
+	d.trimEnd = opts.Trim == dataset.CSVTrimEnd || opts.Trim == dataset.CSVTrimBoth
+	d.nulls = opts.NullValues
+	d.skipRows = opts.SkipRows
+	return d
+}
+
+// validate checks the dialect's special characters don't collide
+func (d csvDialect) validate() error {
+	switch {
+	case d.sep == d.quote:
+		return fmt.Errorf("csv separator and quote character must differ")
+	case d.escape != 0 && d.escape == d.sep:
+		return fmt.Errorf("csv separator and escape character must differ")
+	case isCSVLineBreak(d.sep) || isCSVLineBreak(d.quote) || isCSVLineBreak(d.escape):
+		return fmt.Errorf("csv separator, quote and escape characters cannot be line breaks")
+	case d.comment != "" && strings.ContainsAny(d.comment, "\r\n"):
+		return fmt.Errorf("csv comment prefix cannot contain line breaks")
+	}
+	return nil
+}
+
+// isNull checks if an unquoted value is one of the dialect's null values
+func (d csvDialect) isNull(val string) bool {
+	for _, n := range d.nulls {
+		if val == n {
+			return true
+		}
+	}
+	return false
+}
+
+func isCSVLineBreak(r rune) bool {
+	return r == '\n' || r == '\r'
+}
+
+// csvParseError is a malformed record
+type csvParseError struct {
+	line int
+	err  error
+}
+
+func (e *csvParseError) Error() string {
+	return fmt.Sprintf("record on line %d: %s", e.line, e.err)
+}
+
+func (e *csvParseError) Unwrap() error {
+	return e.err
+}
+
+// csvRecordReader reads records of a CSV dialect
+type csvRecordReader struct {
+	d       csvDialect
+	r       *bufio.Reader
+	line    int // line number of the last rune read
+	nFields int // fields per record, set by the first record
+	skipped bool
+	field   bytes.Buffer
+
+	offset      int64         // number of bytes read
+	recLine     int           // line number the last record started on
+	recOffset   int64         // byte offset the last record started at
+	raw         *bytes.Buffer // text of the last record, when non-nil
+	lastSize    int           // size of the last rune read
+	lastRawSize int           // bytes the last rune added to raw
+}
+
+func newCSVRecordReader(r io.Reader, size int, d csvDialect) *csvRecordReader {
+	return &csvRecordReader{d: d, r: bufio.NewReaderSize(r, size), line: 1}
+}
+
+// Read reads one record, returning each field and if the field was quoted
+func (cr *csvRecordReader) Read() (fields []string, quoted []bool, err error) {
+	if !cr.skipped {
+		cr.skipped = true
+		for i := 0; i < cr.d.skipRows; i++ {
+			if err := cr.skipLine(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var line int
+	for {
+		line = cr.line
+		cr.recLine, cr.recOffset = cr.line, cr.offset
+		if cr.raw != nil {
+			cr.raw.Reset()
+		}
+		fields, quoted, err = cr.readRecord()
+		if err != nil || fields != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cr.d.variadic {
+		if cr.nFields == 0 {
+			cr.nFields = len(fields)
+		} else if len(fields) != cr.nFields {
+			return fields, quoted, &csvParseError{line: line, err: errCSVFieldCount}
+		}
+	}
+	return fields, quoted, nil
+}
+
+// readRecord reads the next line, giving nil fields for blank & comment lines
+func (cr *csvRecordReader) readRecord() (fields []string, quoted []bool, err error) {
+	if cr.d.comment != "" {
+		if prefix, _ := cr.r.Peek(len(cr.d.comment)); string(prefix) == cr.d.comment {
+			return nil, nil, cr.skipLine()
+		}
+	}
+
+	r, _, err := cr.readRune()
+	if err != nil {
+		return nil, nil, err
+	}
+	if isCSVLineBreak(r) {
+		cr.endLine(r)
+		return nil, nil, nil
+	}
+	cr.unreadRune()
+
+	for {
+		val, q, end, err := cr.readField()
+		if err != nil {
+			return nil, nil, err
+		}
+		fields = append(fields, val)
+		quoted = append(quoted, q)
+		if end {
+			return fields, quoted, nil
+		}
+	}
+}
+
+// readField reads a single field, reporting if the field ends its record
+func (cr *csvRecordReader) readField() (val string, quoted, end bool, err error) {
+	cr.field.Reset()
+	startLine := cr.line
+
+	r, _, err := cr.readRune()
+	if cr.d.trimStart {
+		for err == nil && r != cr.d.sep && unicode.IsSpace(r) && !isCSVLineBreak(r) {
+			r, _, err = cr.readRune()
+		}
+	}
+	if err == io.EOF {
+		return "", false, true, nil
+	} else if err != nil {
+		return "", false, false, err
+	}
+
+	if r == cr.d.quote {
+		quoted = true
+		for {
+			r, _, err = cr.readRune()
+			if err == io.EOF {
+				if cr.d.lazyQuotes {
+					return cr.field.String(), true, true, nil
+				}
+				return "", true, false, &csvParseError{line: startLine, err: fmt.Errorf("extraneous or missing %q in quoted-field", cr.d.quote)}
+			} else if err != nil {
+				return "", true, false, err
+			}
+
+			switch {
+			case r == cr.d.escape && cr.d.escape != 0:
+				next, _, err := cr.readRune()
+				if err != nil {
+					return "", true, false, &csvParseError{line: startLine, err: errors.New("escape character at end of input")}
+				}
+				cr.writeRune(next)
+			case r == cr.d.quote:
+				next, _, err := cr.readRune()
+				if err == io.EOF {
+					return cr.field.String(), true, true, nil
+				} else if err != nil {
+					return "", true, false, err
+				}
+				if cr.d.trimEnd {
+					for next != cr.d.sep && unicode.IsSpace(next) && !isCSVLineBreak(next) {
+						if next, _, err = cr.readRune(); err == io.EOF {
+							return cr.field.String(), true, true, nil
+						} else if err != nil {
+							return "", true, false, err
+						}
+					}
+				}
+
+				switch {
+				case next == cr.d.quote && cr.d.escape == 0:
+					cr.field.WriteRune(r)
+				case next == cr.d.sep:
+					return cr.field.String(), true, false, nil
+				case isCSVLineBreak(next):
+					cr.endLine(next)
+					return cr.field.String(), true, true, nil
+				case cr.d.lazyQuotes:
+					cr.field.WriteRune(r)
+					cr.writeRune(next)
+				default:
+					return "", true, false, &csvParseError{line: cr.line, err: fmt.Errorf("extraneous or missing %q in quoted-field", cr.d.quote)}
+				}
+			default:
+				cr.writeRune(r)
+			}
+		}
+	}
+
+	for {
+		switch {
+		case r == cr.d.sep:
+			return cr.unquotedValue(), false, false, nil
+		case isCSVLineBreak(r):
+			cr.endLine(r)
+			return cr.unquotedValue(), false, true, nil
+		case r == cr.d.escape && cr.d.escape != 0:
+			next, _, err := cr.readRune()
+			if err != nil {
+				return "", false, false, &csvParseError{line: startLine, err: errors.New("escape character at end of input")}
+			}
+			cr.writeRune(next)
+		case r == cr.d.quote && !cr.d.lazyQuotes:
+			return "", false, false, &csvParseError{line: cr.line, err: fmt.Errorf("bare %q in non-quoted-field", cr.d.quote)}
+		default:
+			cr.field.WriteRune(r)
+		}
+
+		r, _, err = cr.readRune()
+		if err == io.EOF {
+			return cr.unquotedValue(), false, true, nil
+		} else if err != nil {
+			return "", false, false, err
+		}
+	}
+}
+
+// writeRune adds a rune to the current field, normalizing line breaks to "\n"
+func (cr *csvRecordReader) writeRune(r rune) {
+	if isCSVLineBreak(r) {
+		cr.endLine(r)
+		r = '\n'
+	}
+	cr.field.WriteRune(r)
+}
+
+func (cr *csvRecordReader) unquotedValue() string {
+	val := cr.field.String()
+	if cr.d.trimEnd {
+		val = strings.TrimRightFunc(val, unicode.IsSpace)
+	}
+	return val
+}
+
+// endLine finishes a line break, consuming the "\n" of a "\r\n" pair
+func (cr *csvRecordReader) endLine(r rune) {
+	cr.line++
+	if r == '\r' {
+		if next, _, err := cr.readRune(); err == nil && next != '\n' {
+			cr.unreadRune()
+		}
+	}
+}
+
+// readRune reads a rune, counting bytes read & recording the rune in the
+// raw text of the record
+func (cr *csvRecordReader) readRune() (rune, int, error) {
+	r, size, err := cr.r.ReadRune()
+	if err != nil {
+		return r, size, err
+	}
+	cr.offset += int64(size)
+	cr.lastSize = size
+	if cr.raw != nil {
+		n, _ := cr.raw.WriteRune(r)
+		cr.lastRawSize = n
+	}
+	return r, size, nil
+}
+
+// unreadRune unreads the last rune read with readRune
+func (cr *csvRecordReader) unreadRune() {
+	if err := cr.r.UnreadRune(); err != nil {
+		return
+	}
+	cr.offset -= int64(cr.lastSize)
+	if cr.raw != nil {
+		cr.raw.Truncate(cr.raw.Len() - cr.lastRawSize)
+	}
+}
+
+// skipRecord finishes a malformed record, discarding input through the end
+// of the line the error occurred on. Errors that aren't caused by malformed
+// records give a nil ReadError
+func (cr *csvRecordReader) skipRecord(err error) *ReadError {
+	var perr *csvParseError
+	if !errors.As(err, &perr) {
+		return nil
+	}
+	if !errors.Is(err, errCSVFieldCount) {
+		cr.skipLine()
+	}
+
+	rerr := &ReadError{Line: cr.recLine, Offset: cr.recOffset, Err: err}
+	if cr.raw != nil {
+		rerr.Raw = strings.TrimRight(cr.raw.String(), "\r\n")
+	}
+	return rerr
+}
+
+// skipLine discards input through the next line break
+func (cr *csvRecordReader) skipLine() error {
+	for {
+		r, _, err := cr.readRune()
+		if err != nil {
+			return err
+		}
+		if isCSVLineBreak(r) {
+			cr.endLine(r)
+			return nil
+		}
+	}
+}
+
+// csvRecordWriter writes records in a CSV dialect
+type csvRecordWriter struct {
+	d csvDialect
+	w *bufio.Writer
+}
+
+func newCSVRecordWriter(w io.Writer, d csvDialect) *csvRecordWriter {
+	return &csvRecordWriter{d: d, w: bufio.NewWriter(w)}
+}
+
+// Write writes a record. fields listed in nulls are written as the dialect's
+// null value
+func (cw *csvRecordWriter) Write(fields []string, nulls []bool) error {
+	for i, field := range fields {
+		if i > 0 {
+			if _, err := cw.w.WriteRune(cw.d.sep); err != nil {
+				return err
+			}
+		}
+
+		if nulls != nil && nulls[i] {
+			if len(cw.d.nulls) > 0 {
+				field = cw.d.nulls[0]
+			}
+			if _, err := cw.w.WriteString(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !cw.needsQuotes(field, i == 0) {
+			if _, err := cw.w.WriteString(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		cw.w.WriteRune(cw.d.quote)
+		for _, r := range field {
+			switch r {
+			case cw.d.quote:
+				if cw.d.escape != 0 {
+					cw.w.WriteRune(cw.d.escape)
+				} else {
+					cw.w.WriteRune(cw.d.quote)
+				}
+			case cw.d.escape:
+				cw.w.WriteRune(cw.d.escape)
+			}
+			cw.w.WriteRune(r)
+		}
+		if _, err := cw.w.WriteRune(cw.d.quote); err != nil {
+			return err
+		}
+	}
+	_, err := cw.w.WriteString(cw.d.terminator)
+	return err
+}
+
+// needsQuotes reports whether a non-null field must be quoted to read back
+// as the same value
+func (cw *csvRecordWriter) needsQuotes(field string, first bool) bool {
+	if field == "" {
+		// empty strings are quoted when empty values are read as null
+		return cw.d.isNull(field)
+	}
+	if field == `\.` ||
+		strings.ContainsRune(field, cw.d.sep) ||
+		strings.ContainsRune(field, cw.d.quote) ||
+		cw.d.escape != 0 && strings.ContainsRune(field, cw.d.escape) ||
+		strings.ContainsAny(field, "\r\n") ||
+		cw.d.isNull(field) {
+		return true
+	}
+	if first && cw.d.comment != "" && strings.HasPrefix(field, cw.d.comment) {
+		return true
+	}
+	r1, _ := utf8.DecodeRuneInString(field)
+	if unicode.IsSpace(r1) {
+		return true
+	}
+	r2, _ := utf8.DecodeLastRuneInString(field)
+	return cw.d.trimEnd && unicode.IsSpace(r2)
+}
+
+// Flush writes any buffered data to the underlying writer
+func (cw *csvRecordWriter) Flush() error {
+	return cw.w.Flush()
+}
@@ -345,6 +345,182 @@ a	12	23	"[""foo"",""bar""]"`
 		t.Errorf("output mismatch. %s != %s", buf.String(), expect)
 	}
 }
+func TestCSVDialectReader(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+			},
+		},
+	}
+
+	cases := []struct {
+		description string
+		opts        map[string]interface{}
+		data        string
+		expect      []interface{}
+		err         string
+	}{
+		{"defaults",
+			nil,
+			"a,1\r\n\"b,\"\"c\"\"\",2\r\n\n\"multi\nline\",3",
+			[]interface{}{
+				[]interface{}{"a", int64(1)},
+				[]interface{}{"b,\"c\"", int64(2)},
+				[]interface{}{"multi\nline", int64(3)},
+			}, ""},
+		{"single quotes & backslash escapes",
+			map[string]interface{}{"quoteChar": "'", "escapeChar": "\\"},
+			"'it\\'s',1\nsemi\\,colon,2\n",
+			[]interface{}{
+				[]interface{}{"it's", int64(1)},
+				[]interface{}{"semi,colon", int64(2)},
+			}, ""},
+		{"comments, preamble & header",
+			map[string]interface{}{"commentPrefix": "#", "skipRows": 2, "headerRow": true, "separator": ";"},
+			"exported 2021-08-01\nby station 4\nname;count\n# a comment\nx;1\n#;2\n",
+			[]interface{}{
+				[]interface{}{"x", int64(1)},
+			}, ""},
+		{"null values",
+			map[string]interface{}{"nullValues": []interface{}{"NA", "", "\\N"}},
+			"NA,\\N\n\"NA\",\n\"\",1\n",
+			[]interface{}{
+				[]interface{}{nil, nil},
+				[]interface{}{"NA", nil},
+				[]interface{}{"", int64(1)},
+			}, ""},
+		{"trim",
+			map[string]interface{}{"trim": true},
+			"  a  , 1 \n \" b \" ,2\n",
+			[]interface{}{
+				[]interface{}{"a", int64(1)},
+				[]interface{}{" b ", int64(2)},
+			}, ""},
+		{"trim start",
+			map[string]interface{}{"trim": "start"},
+			"  a  , 1\n",
+			[]interface{}{
+				[]interface{}{"a  ", int64(1)},
+			}, ""},
+		{"wrong number of fields",
+			nil,
+			"a,1\nb,2,3\n",
+			nil, "entry 2: record on line 2: wrong number of fields"},
+		{"bare quote",
+			nil,
+			"a\"b,1\n",
+			nil, "entry 1: record on line 1: bare '\"' in non-quoted-field"},
+		{"unterminated quote",
+			nil,
+			"\"a,1\n",
+			nil, "entry 1: record on line 1: extraneous or missing '\"' in quoted-field"},
+		{"colliding characters",
+			map[string]interface{}{"separator": "'", "quoteChar": "'"},
+			"",
+			nil, "csv separator and quote character must differ"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			st := &dataset.Structure{Format: "csv", Schema: schema, FormatConfig: c.opts}
+			r, err := NewEntryReader(st, strings.NewReader(c.data))
+			if err == nil {
+				var got []interface{}
+				if got, err = ReadAllArray(r); err == nil {
+					if diff := cmp.Diff(c.expect, got); diff != "" {
+						t.Errorf("result mismatch (-want +got):\n%s", diff)
+					}
+				}
+			}
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Errorf("error mismatch. expected: '%s', got: '%v'", c.err, err)
+			}
+		})
+	}
+}
+
+func TestCSVDialectWriter(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "note", "type": "string"},
+			},
+		},
+	}
+	rows := []interface{}{
+		[]interface{}{"it's", nil},
+		[]interface{}{"NA", ""},
+		[]interface{}{"#hash", " padded "},
+		[]interface{}{"a\\b", "semi;colon"},
+	}
+
+	cases := []struct {
+		description string
+		opts        map[string]interface{}
+		expect      string
+	}{
+		{"defaults",
+			map[string]interface{}{"headerRow": true},
+			"name,note\nit's,\nNA,\n#hash,\" padded \"\na\\b,semi;colon\n"},
+		{"dialect",
+			map[string]interface{}{
+				"headerRow":      true,
+				"separator":      ";",
+				"quoteChar":      "'",
+				"escapeChar":     "\\",
+				"commentPrefix":  "#",
+				"nullValues":     []interface{}{"NA", ""},
+				"trim":           "both",
+				"lineTerminator": "\r\n",
+			},
+			"name;note\r\n'it\\'s';NA\r\n'NA';''\r\n'#hash';' padded '\r\n'a\\\\b';'semi;colon'\r\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			st := &dataset.Structure{Format: "csv", Schema: schema, FormatConfig: c.opts}
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, row := range rows {
+				if err := w.WriteEntry(Entry{Index: i, Value: row}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+
+			if c.opts["nullValues"] == nil {
+				return
+			}
+			r, err := NewEntryReader(st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(rows, got); diff != "" {
+				t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func BenchmarkCSVWriterArrays(b *testing.B) {
 	const NumWrites = 1000
 	st := &dataset.Structure{Format: "csv", Schema: tabular.BaseTabularSchema}
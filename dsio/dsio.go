@@ -3,11 +3,13 @@ package dsio
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 
 	logger "github.com/ipfs/go-log"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
 	"github.com/qri-io/dataset/compression"
 	"github.com/qri-io/qfs"
 )
@@ -52,76 +54,151 @@ type EntryReadWriter interface {
 
 // NewEntryReader allocates a EntryReader based on a given structure
 func NewEntryReader(st *dataset.Structure, r io.Reader) (EntryReader, error) {
-	switch st.DataFormat() {
-	case dataset.CBORDataFormat:
-		return NewCBORReader(st, r)
-	case dataset.JSONDataFormat:
-		return NewJSONReader(st, r)
-	case dataset.CSVDataFormat:
-		return NewCSVReader(st, r)
-	case dataset.XLSXDataFormat:
-		return NewXLSXReader(st, r)
-	case dataset.NDJSONDataFormat:
-		return NewNDJSONReader(st, r)
-	case dataset.UnknownDataFormat:
+	df := st.DataFormat()
+	if df == dataset.UnknownDataFormat {
 		err := fmt.Errorf("structure must have a data format")
 		log.Debug(err.Error())
 		return nil, err
-	default:
+	}
+
+	f, ok := LookupFormat(df)
+	if !ok {
 		err := fmt.Errorf("invalid format to create reader: %s", st.Format)
+		if pkg, ok := formatPackages[df]; ok {
+			err = fmt.Errorf("%s format isn't registered, import %q to register it", st.Format, pkg)
+		}
 		log.Debug(err.Error())
 		return nil, err
 	}
+	return f.NewReader(st, r)
 }
 
 // NewEntryWriter allocates a EntryWriter based on a given structure
 func NewEntryWriter(st *dataset.Structure, w io.Writer) (EntryWriter, error) {
-	switch st.DataFormat() {
-	case dataset.CBORDataFormat:
-		return NewCBORWriter(st, w)
-	case dataset.JSONDataFormat:
-		return NewJSONWriter(st, w)
-	case dataset.CSVDataFormat:
-		return NewCSVWriter(st, w)
-	case dataset.XLSXDataFormat:
-		return NewXLSXWriter(st, w)
-	case dataset.NDJSONDataFormat:
-		return NewNDJSONWriter(st, w)
-	case dataset.UnknownDataFormat:
+	df := st.DataFormat()
+	if df == dataset.UnknownDataFormat {
 		err := fmt.Errorf("structure must have a data format")
 		log.Debug(err.Error())
 		return nil, err
-	default:
+	}
+
+	f, ok := LookupFormat(df)
+	if !ok {
 		err := fmt.Errorf("invalid format to create writer: %s", st.Format)
+		if pkg, ok := formatPackages[df]; ok {
+			err = fmt.Errorf("%s format isn't registered, import %q to register it", st.Format, pkg)
+		}
 		log.Debug(err.Error())
 		return nil, err
 	}
+	return f.NewWriter(st, w)
 }
 
+// Decompress wraps a read source to decompress it, and to open the body file
+// of an archive, as a structure describes. The returned close func is nil
+// when r is returned as-is, and must otherwise be called once reading is
+// done. Formats registered with RegisterFormat use Decompress & Compress to
+// support compressed bodies
+func Decompress(st *dataset.Structure, r io.Reader) (io.Reader, func() error, error) {
+	return maybeWrapDecompressor(st, r)
+}
+
+// Compress wraps a write destination to compress it as a structure
+// describes. The returned close func is nil when w is returned as-is, and
+// must otherwise be called once writing is done to flush compressed data
+func Compress(st *dataset.Structure, w io.Writer) (io.Writer, func() error, error) {
+	return maybeWrapCompressor(st, w)
+}
+
+// maybeWrapDecompressor decompresses a reader, and opens the body file of an
+// archive when the structure names one
 func maybeWrapDecompressor(st *dataset.Structure, r io.Reader) (io.Reader, func() error, error) {
-	if st.Compression == "" {
+	if st.Compression == "" && st.Archive == "" {
 		return r, nil, nil
 	}
 
-	rc, err := compression.Decompressor(st.Compression, r)
+	var close func() error
+	if st.Compression != "" {
+		opts, err := compressionOptions(st)
+		if err != nil {
+			return nil, nil, err
+		}
+		rc, err := compression.DecompressorWithOptions(st.Compression, r, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, close = rc, rc.Close
+	}
+
+	if st.Archive == "" {
+		return r, close, nil
+	}
+	if st.ArchiveMember == "" {
+		if close != nil {
+			close()
+		}
+		return nil, nil, fmt.Errorf("archiveMember is required to read %s archives", st.Archive)
+	}
+	mr, err := archive.OpenMember(st.Archive, r, st.ArchiveMember)
 	if err != nil {
+		if close != nil {
+			close()
+		}
 		return nil, nil, err
 	}
-	return rc, rc.Close, err
+	return mr, func() error {
+		err := mr.Close()
+		if close != nil {
+			if cerr := close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
 }
 
 func maybeWrapCompressor(st *dataset.Structure, w io.Writer) (io.Writer, func() error, error) {
+	if st.Archive != "" {
+		return nil, nil, errArchiveWrite
+	}
 	if st.Compression == "" {
 		return w, nil, nil
 	}
 
-	wc, err := compression.Compressor(st.Compression, w)
+	opts, err := compressionOptions(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	wc, err := compression.CompressorWithOptions(st.Compression, w, opts)
 	if err != nil {
 		return nil, nil, err
 	}
 	return wc, wc.Close, err
 }
 
+// errArchiveWrite is returned when creating writers for archived bodies
+var errArchiveWrite = fmt.Errorf("writing archived bodies is not supported")
+
+// compressionOptions reads a structure's compression configuration, resolving
+// any dictionary reference from the dictionaries registered with the
+// compression package
+func compressionOptions(st *dataset.Structure) (compression.Options, error) {
+	co, err := dataset.NewCompressionOptions(st.CompressionConfig)
+	if err != nil {
+		return compression.Options{}, err
+	}
+
+	opts := compression.Options{Level: co.Level, Window: co.Window}
+	if co.Dictionary != "" {
+		d, ok := compression.LookupDictionary(co.Dictionary)
+		if !ok {
+			return opts, fmt.Errorf("compression dictionary not found: %q", co.Dictionary)
+		}
+		opts.Dictionary = d
+	}
+	return opts, nil
+}
+
 // GetTopLevelType returns the top-level type of the structure, only if it is
 // a valid type ("array" or "object"), otherwise returns an error
 func GetTopLevelType(st *dataset.Structure) (string, error) {
@@ -189,6 +266,12 @@ func ReadAllArray(r EntryReader) ([]interface{}, error) {
 // ConvertFile takes an input file & structure, and converts a specified selection
 // to the structure specified by out
 func ConvertFile(file qfs.File, in, out *dataset.Structure, limit, offset int, all bool) (data []byte, err error) {
+	return ConvertFileContext(context.Background(), file, in, out, limit, offset, all)
+}
+
+// ConvertFileContext is ConvertFile with a context, stopping the conversion
+// with the context's error when the context is cancelled
+func ConvertFileContext(ctx context.Context, file qfs.File, in, out *dataset.Structure, limit, offset int, all bool) (data []byte, err error) {
 	buf := &bytes.Buffer{}
 
 	w, err := NewEntryWriter(out, buf)
@@ -220,7 +303,9 @@ func ConvertFile(file qfs.File, in, out *dataset.Structure, limit, offset int, a
 			Offset: offset,
 		}
 	}
-	err = Copy(rr, w)
+	if err = CopyContext(ctx, rr, w); err != nil {
+		return nil, err
+	}
 
 	if err := w.Close(); err != nil {
 		return nil, fmt.Errorf("error closing row buffer: %s", err.Error())
@@ -1,13 +1,16 @@
 package dsio
 
 import (
+	"archive/tar"
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
 	"github.com/qri-io/dataset/tabular"
 	"github.com/qri-io/qfs"
 )
@@ -31,6 +34,7 @@ func TestNewEntryReader(t *testing.T) {
 		{&dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray}, ""},
 		{&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, ""},
 		{&dataset.Structure{Format: "csv", Schema: basicTableSchema}, ""},
+		{&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray}, ""},
 		// {&dataset.Structure{Format: "xlsx", Schema: basicTableSchema}, ""},
 	}
 
@@ -52,6 +56,7 @@ func TestNewEntryWriter(t *testing.T) {
 		{&dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray}, ""},
 		{&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, ""},
 		{&dataset.Structure{Format: "csv", Schema: basicTableSchema}, ""},
+		{&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray}, ""},
 		// {&dataset.Structure{Format: "xlsx", Schema: basicTableSchema}, ""},
 	}
 
@@ -201,4 +206,151 @@ func TestConvertFile(t *testing.T) {
 	if !bytes.Equal(got, []byte(`[["a","b","c"]]`)) {
 		t.Error(fmt.Errorf("converted body didn't match, got: %s", got))
 	}
+
+	// invalid JSON -> CSV
+	body = qfs.NewMemfileBytes("", []byte(`[["a","b","c"],["d"`))
+	got, err = ConvertFile(body, jsonStructure, csvStructure, 0, 0, true)
+	if err == nil {
+		t.Errorf("expected converting invalid data to fail. got body: %s", got)
+	}
+}
+
+func TestCompressionConfig(t *testing.T) {
+	var samples [][]byte
+	for day := 1; day <= 28; day++ {
+		buf := &bytes.Buffer{}
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(buf, "{\"station\":\"CENTRAL PARK NEW YORK NY US\",\"date\":\"2021-07-%02d\",\"reading\":%d}\n", day, i)
+		}
+		samples = append(samples, buf.Bytes())
+	}
+	dict, err := compression.TrainZstdDictionary(samples, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compression.RegisterDictionary("test_compression_config", dict); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []interface{}{
+		map[string]interface{}{"station": "CENTRAL PARK NEW YORK NY US", "date": "2021-07-29", "reading": 1.5},
+		map[string]interface{}{"station": "CENTRAL PARK NEW YORK NY US", "date": "2021-07-30", "reading": 2.5},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		err         string
+	}{
+		{"zstd level & dictionary", &dataset.Structure{Format: "ndjson", Compression: "zst", CompressionConfig: map[string]interface{}{"level": float64(19), "dictionary": "test_compression_config"}, Schema: dataset.BaseSchemaArray}, ""},
+		{"gzip level", &dataset.Structure{Format: "json", Compression: "gzip", CompressionConfig: map[string]interface{}{"level": 1}, Schema: dataset.BaseSchemaArray}, ""},
+		{"brotli window", &dataset.Structure{Format: "cbor", Compression: "br", CompressionConfig: map[string]interface{}{"window": 1 << 16}, Schema: dataset.BaseSchemaArray}, ""},
+
+		{"missing dictionary", &dataset.Structure{Format: "json", Compression: "zst", CompressionConfig: map[string]interface{}{"dictionary": "unknown"}, Schema: dataset.BaseSchemaArray}, `compression dictionary not found: "unknown"`},
+		{"invalid config", &dataset.Structure{Format: "json", Compression: "zst", CompressionConfig: map[string]interface{}{"level": "max"}, Schema: dataset.BaseSchemaArray}, "invalid level value: max"},
+		{"unsupported option", &dataset.Structure{Format: "json", Compression: "gzip", CompressionConfig: map[string]interface{}{"dictionary": "test_compression_config"}, Schema: dataset.BaseSchemaArray}, "gzip compression doesn't support dictionaries"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: %q, got: %v", c.err, err)
+			}
+			if c.err != "" {
+				return
+			}
+			for i, ent := range entries {
+				if err := w.WriteEntry(Entry{Index: i, Value: ent}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(entries, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArchivedBody(t *testing.T) {
+	zipData, err := ioutil.ReadFile("../testdata/zip/exported.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tarGz := &bytes.Buffer{}
+	gz, err := compression.Compressor("gzip", tarGz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(gz)
+	body := "movie\nup\nthe incredibles\n"
+	tw.WriteHeader(&tar.Header{Name: "export/body.csv", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(body))})
+	tw.Write([]byte(body))
+	tw.Close()
+	gz.Close()
+
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": []interface{}{map[string]interface{}{"title": "movie", "type": "string"}},
+		},
+	}
+	expect := []interface{}{[]interface{}{"up"}, []interface{}{"the incredibles"}}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		data        []byte
+		err         string
+	}{
+		{"zip", &dataset.Structure{Format: "csv", Archive: "zip", ArchiveMember: "body.csv", FormatConfig: map[string]interface{}{"headerRow": true}, Schema: schema}, zipData, ""},
+		{"tar.gz", &dataset.Structure{Format: "csv", Archive: "tar", ArchiveMember: "export/body.csv", Compression: "gzip", FormatConfig: map[string]interface{}{"headerRow": true}, Schema: schema}, tarGz.Bytes(), ""},
+		{"missing member", &dataset.Structure{Format: "csv", Archive: "zip", ArchiveMember: "missing.csv", Schema: schema}, zipData, `archive member not found: "missing.csv"`},
+		{"no member", &dataset.Structure{Format: "csv", Archive: "zip", Schema: schema}, zipData, "archiveMember is required to read zip archives"},
+		{"invalid archive", &dataset.Structure{Format: "csv", Archive: "rar", ArchiveMember: "body.csv", Schema: schema}, zipData, `invalid archive format "rar"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := NewEntryReader(c.st, bytes.NewReader(c.data))
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: %q, got: %v", c.err, err)
+			}
+			if c.err != "" {
+				return
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+			if err := r.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	for _, format := range []string{"csv", "json", "xlsx", "parquet"} {
+		st := &dataset.Structure{Format: format, Archive: "zip", ArchiveMember: "body." + format, Schema: schema}
+		if _, err := NewEntryWriter(st, &bytes.Buffer{}); err == nil || err.Error() != "writing archived bodies is not supported" {
+			t.Errorf("%s: expected writing an archived body to error, got: %v", format, err)
+		}
+	}
 }
@@ -0,0 +1,103 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// LookupEncoding gives the character encoding for a Structure.Encoding name.
+// Names are case insensitive & ignore punctuation, so "Windows-1252" and
+// "windows1252" are equivalent. UTF-8 and empty names give a nil encoding,
+// meaning data is read & written as-is. "utf-16" reads either byte order
+// from a byte order mark, defaulting to little endian, and writes a little
+// endian byte order mark
+func LookupEncoding(name string) (encoding.Encoding, error) {
+	switch normalizeEncodingName(name) {
+	case "", "utf8":
+		return nil, nil
+	case "latin1", "iso88591", "l1":
+		return charmap.ISO8859_1, nil
+	case "windows1252", "cp1252":
+		return charmap.Windows1252, nil
+	case "utf16":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case "utf16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+	case "utf16be":
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+	case "shiftjis", "sjis", "mskanji":
+		return japanese.ShiftJIS, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %q", name)
+	}
+}
+
+// canonicalEncodingName gives the IANA charset name of a Structure.Encoding
+// name, for declaring the encoding of written documents
+func canonicalEncodingName(name string) (string, error) {
+	enc, err := LookupEncoding(name)
+	if err != nil || enc == nil {
+		return "UTF-8", err
+	}
+	return ianaindex.MIME.Name(enc)
+}
+
+func normalizeEncodingName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// maybeWrapTextDecoder decompresses a reader of text-based data & transcodes
+// it from the structure's encoding to utf-8
+func maybeWrapTextDecoder(st *dataset.Structure, r io.Reader) (io.Reader, func() error, error) {
+	enc, err := LookupEncoding(st.Encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, close, err := maybeWrapDecompressor(st, r)
+	if err != nil || enc == nil {
+		return r, close, err
+	}
+	return transform.NewReader(r, enc.NewDecoder()), close, nil
+}
+
+// maybeWrapTextEncoder transcodes utf-8 text to the structure's encoding &
+// compresses it before writing to w. The returned close func must be called
+// to flush any buffered text
+func maybeWrapTextEncoder(st *dataset.Structure, w io.Writer) (io.Writer, func() error, error) {
+	enc, err := LookupEncoding(st.Encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w, close, err := maybeWrapCompressor(st, w)
+	if err != nil || enc == nil {
+		return w, close, err
+	}
+
+	tw := transform.NewWriter(w, enc.NewEncoder())
+	return tw, func() error {
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		if close != nil {
+			return close()
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,155 @@
+package dsio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestLookupEncoding(t *testing.T) {
+	cases := []struct {
+		name  string
+		isNil bool
+		err   string
+	}{
+		{"", true, ""},
+		{"UTF-8", true, ""},
+		{"latin-1", false, ""},
+		{"ISO-8859-1", false, ""},
+		{"Windows-1252", false, ""},
+		{"cp1252", false, ""},
+		{"utf-16", false, ""},
+		{"UTF-16LE", false, ""},
+		{"utf_16be", false, ""},
+		{"Shift_JIS", false, ""},
+		{"ebcdic", false, `unsupported encoding: "ebcdic"`},
+	}
+
+	for _, c := range cases {
+		enc, err := LookupEncoding(c.name)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%q error mismatch. expected: '%s', got: '%v'", c.name, c.err, err)
+			continue
+		}
+		if c.err == "" && (enc == nil) != c.isNil {
+			t.Errorf("%q expected nil encoding: %t, got: %v", c.name, c.isNil, enc)
+		}
+	}
+}
+
+func TestEncodingReadWrite(t *testing.T) {
+	tabularSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+			},
+		},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		encoded     []byte
+		expect      []interface{}
+	}{
+		{"latin-1 csv",
+			&dataset.Structure{Format: "csv", Encoding: "latin-1", Schema: tabularSchema},
+			[]byte("Z\xfcrich,415367\nS\xe3o Paulo,12330000\n"),
+			[]interface{}{[]interface{}{"Zürich", int64(415367)}, []interface{}{"São Paulo", int64(12330000)}}},
+		{"windows-1252 json",
+			&dataset.Structure{Format: "json", Encoding: "windows-1252", Schema: dataset.BaseSchemaArray},
+			[]byte("[\"\x93quoted\x94\",\"\x80 5\"]"),
+			[]interface{}{"“quoted”", "€ 5"}},
+		{"utf-16 ndjson",
+			&dataset.Structure{Format: "ndjson", Encoding: "utf-16", Schema: dataset.BaseSchemaArray},
+			[]byte("\xff\xfe\"\x00\xe9\x00\"\x00\n\x00"),
+			[]interface{}{"é"}},
+		{"shift-jis xml",
+			&dataset.Structure{Format: "xml", Encoding: "shift_jis", Schema: dataset.BaseSchemaArray},
+			[]byte("<?xml version=\"1.0\" encoding=\"Shift_JIS\"?>\n<entries><entry>\x93\x8c\x8b\x9e</entry></entries>"),
+			[]interface{}{"東京"}},
+		{"latin-1 xml",
+			&dataset.Structure{Format: "xml", Encoding: "latin1", Schema: dataset.BaseSchemaArray},
+			[]byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<entries><entry>caf\xe9</entry></entries>"),
+			[]interface{}{"café"}},
+		{"gzipped latin-1 yaml",
+			&dataset.Structure{Format: "yaml", Encoding: "latin-1", Compression: "gzip", Schema: dataset.BaseSchemaArray},
+			nil,
+			[]interface{}{"Mañana"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range c.expect {
+				if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if c.encoded != nil {
+				if diff := cmp.Diff(c.encoded, buf.Bytes()); diff != "" {
+					t.Errorf("written bytes mismatch (-want +got):\n%s", diff)
+				}
+			}
+
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Encoding: "ebcdic", Schema: dataset.BaseSchemaArray}
+	if _, err := NewEntryReader(st, &bytes.Buffer{}); err == nil {
+		t.Error("expected reading an unsupported encoding to error")
+	}
+	if _, err := NewEntryWriter(st, &bytes.Buffer{}); err == nil {
+		t.Error("expected writing an unsupported encoding to error")
+	}
+
+	st = &dataset.Structure{Format: "ndjson", Encoding: "latin-1", Schema: dataset.BaseSchemaArray}
+	w, err := NewEntryWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: "東京"}); err == nil {
+		t.Error("expected writing characters latin-1 can't represent to error")
+	}
+}
+
+func TestXMLDeclaredEncoding(t *testing.T) {
+	st := &dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray}
+	data := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<entries><item>caf\xe9</item></entries>")
+	r, err := NewEntryReader(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{"café"}, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
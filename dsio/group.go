@@ -0,0 +1,514 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// Aggregate enumerates the ways values of a group are combined
+type Aggregate int
+
+const (
+	// AggCount counts rows of a group, or non-null values when a column is
+	// given
+	AggCount Aggregate = iota
+	// AggSum adds numeric values, giving an integer when all values are
+	// integers. Integer sums that overflow an int64 are *big.Int values
+	AggSum
+	// AggMin gives the least value in the order of a SortedReader
+	AggMin
+	// AggMax gives the greatest value in the order of a SortedReader
+	AggMax
+	// AggMean gives the average of numeric values
+	AggMean
+	// AggDistinctCount counts distinct non-null values
+	AggDistinctCount
+	// AggFirst gives the value of the first row of a group
+	AggFirst
+	// AggLast gives the value of the last row of a group
+	AggLast
+)
+
+// String implements the stringer interface
+func (a Aggregate) String() string {
+	switch a {
+	case AggCount:
+		return "count"
+	case AggSum:
+		return "sum"
+	case AggMin:
+		return "min"
+	case AggMax:
+		return "max"
+	case AggMean:
+		return "mean"
+	case AggDistinctCount:
+		return "distinct_count"
+	case AggFirst:
+		return "first"
+	case AggLast:
+		return "last"
+	}
+	return "unknown"
+}
+
+// AggregateSpec describes a column of aggregated values
+type AggregateSpec struct {
+	// Func is the aggregate to apply
+	Func Aggregate
+	// Column is the title of the column to aggregate. Only AggCount works
+	// without a column
+	Column string
+	// Title is the title of the aggregate column, defaulting to the aggregate
+	// name followed by the column, like "sum_total"
+	Title string
+}
+
+// title gives the title of the aggregate column
+func (spec AggregateSpec) title() string {
+	if spec.Title != "" {
+		return spec.Title
+	}
+	if spec.Column == "" {
+		return spec.Func.String()
+	}
+	return spec.Func.String() + "_" + spec.Column
+}
+
+// GroupReader reads one row per group of rows with equal key columns. Each
+// row holds the key values of the group followed by the value of each
+// aggregate. Keys compare the way a SortedReader orders them, and null keys
+// form a group of their own. With no key columns all rows form a single group.
+// Minimums, maximums, sums & means ignore nulls, and are null for groups
+// without any values
+type GroupReader struct {
+	reader *keyedReader
+	cols   []int
+	specs  []AggregateSpec
+	st     *dataset.Structure
+	sorted bool
+	index  int
+
+	// hash mode state
+	grouped bool
+	groups  []*group
+
+	// sorted mode state
+	current *group
+	done    bool
+}
+
+var _ EntryReader = (*GroupReader)(nil)
+
+// NewHashGroupReader creates a reader that groups rows of unsorted input. The
+// whole input is read into a hash table of groups on the first call to
+// ReadEntry, memory use grows with the number of groups. Groups are read in
+// the order their first row appears
+func NewHashGroupReader(r EntryReader, keys []string, aggs []AggregateSpec) (*GroupReader, error) {
+	return newGroupReader(r, keys, aggs, false)
+}
+
+// NewSortedGroupReader creates a reader that groups rows of input sorted by
+// the key columns, holding only one group in memory. Each run of rows with
+// equal keys is a group, so unsorted input gives repeated groups
+func NewSortedGroupReader(r EntryReader, keys []string, aggs []AggregateSpec) (*GroupReader, error) {
+	return newGroupReader(r, keys, aggs, true)
+}
+
+func newGroupReader(r EntryReader, keys []string, aggs []AggregateSpec, sorted bool) (*GroupReader, error) {
+	kr, cols, err := newKeyedReader(r, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	keyCols := make(tabular.Columns, len(kr.indexes))
+	for i, idx := range kr.indexes {
+		keyCols[i] = cols[idx]
+	}
+	aggCols := make(tabular.Columns, len(aggs))
+	colIndexes := make([]int, len(aggs))
+	for i, spec := range aggs {
+		if spec.Func < AggCount || spec.Func > AggLast {
+			return nil, fmt.Errorf("invalid aggregate: %d", spec.Func)
+		}
+		colIndexes[i] = -1
+		var col tabular.Column
+		if spec.Column != "" {
+			idx, err := columnIndexes(cols, []string{spec.Column})
+			if err != nil {
+				return nil, err
+			}
+			colIndexes[i] = idx[0]
+			col = cols[idx[0]]
+		} else if spec.Func != AggCount {
+			return nil, fmt.Errorf("%s aggregate requires a column", spec.Func)
+		}
+		aggCols[i] = aggregateColumn(spec, col)
+	}
+
+	return &GroupReader{
+		reader: kr,
+		cols:   colIndexes,
+		specs:  aggs,
+		st:     derivedStructure(r.Structure(), keyCols.Merge(aggCols).JSONSchema()),
+		sorted: sorted,
+	}, nil
+}
+
+// aggregateColumn describes the values of an aggregate of col
+func aggregateColumn(spec AggregateSpec, col tabular.Column) tabular.Column {
+	agg := tabular.Column{Title: spec.title()}
+	switch spec.Func {
+	case AggCount, AggDistinctCount:
+		agg.Type = &tabular.ColType{"integer"}
+	case AggSum:
+		if col.Type != nil && len(*col.Type) > 0 && !col.Type.HasType("number") && col.Type.HasType("integer") {
+			agg.Type = &tabular.ColType{"integer", "null"}
+		} else {
+			agg.Type = &tabular.ColType{"number", "null"}
+		}
+	case AggMean:
+		agg.Type = &tabular.ColType{"number", "null"}
+	default:
+		agg.Type = col.Type
+		agg = nullableColumns(tabular.Columns{agg})[0]
+	}
+	return agg
+}
+
+// Structure gives the structure of grouped rows
+func (r *GroupReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads the next group
+func (r *GroupReader) ReadEntry() (Entry, error) {
+	var (
+		g   *group
+		err error
+	)
+	if r.sorted {
+		g, err = r.nextSorted()
+	} else {
+		g, err = r.nextHashed()
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	ent := Entry{Index: r.index, Value: g.row()}
+	r.index++
+	return ent, nil
+}
+
+// Close closes the wrapped reader
+func (r *GroupReader) Close() error {
+	return r.reader.reader.Close()
+}
+
+// nextHashed reads the whole input on the first call, then gives groups in
+// order of appearance
+func (r *GroupReader) nextHashed() (*group, error) {
+	if !r.grouped {
+		r.grouped = true
+		table := map[string]*group{}
+		for {
+			row, err := r.reader.read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			key := row.hashKey()
+			g, ok := table[key]
+			if !ok {
+				g = r.newGroup(row)
+				table[key] = g
+				r.groups = append(r.groups, g)
+			}
+			if err := g.add(row); err != nil {
+				return nil, err
+			}
+		}
+		if len(r.groups) == 0 && len(r.reader.indexes) == 0 {
+			r.groups = append(r.groups, r.newGroup(nil))
+		}
+	}
+
+	if len(r.groups) == 0 {
+		return nil, io.EOF
+	}
+	g := r.groups[0]
+	r.groups[0] = nil
+	r.groups = r.groups[1:]
+	return g, nil
+}
+
+// nextSorted reads rows until the keys change
+func (r *GroupReader) nextSorted() (*group, error) {
+	for !r.done {
+		row, err := r.reader.read()
+		if err == io.EOF {
+			r.done = true
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if r.current == nil {
+			r.current = r.newGroup(row)
+		} else if c, err := compareRowKeys(r.current.first, row); err != nil {
+			return nil, err
+		} else if c != 0 {
+			g := r.current
+			r.current = r.newGroup(row)
+			if err := r.current.add(row); err != nil {
+				return nil, err
+			}
+			return g, nil
+		}
+		if err := r.current.add(row); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.current == nil && r.index == 0 && len(r.reader.indexes) == 0 {
+		r.current = r.newGroup(nil)
+	}
+	if r.current == nil {
+		return nil, io.EOF
+	}
+	g := r.current
+	r.current = nil
+	return g, nil
+}
+
+// group accumulates the aggregates of rows with equal keys
+type group struct {
+	first *keyedRow
+	keys  []interface{}
+	aggs  []aggregator
+	cols  []int
+	specs []AggregateSpec
+}
+
+func (r *GroupReader) newGroup(first *keyedRow) *group {
+	g := &group{
+		first: first,
+		keys:  make([]interface{}, len(r.reader.indexes)),
+		aggs:  make([]aggregator, len(r.specs)),
+		cols:  r.cols,
+		specs: r.specs,
+	}
+	if first != nil {
+		for i, idx := range r.reader.indexes {
+			g.keys[i] = first.values[idx]
+		}
+	}
+	for i, spec := range r.specs {
+		g.aggs[i] = newAggregator(spec.Func)
+	}
+	return g
+}
+
+// add adds a row to each aggregate
+func (g *group) add(row *keyedRow) error {
+	for i, agg := range g.aggs {
+		var v interface{} = row
+		if g.cols[i] >= 0 {
+			v = row.values[g.cols[i]]
+		}
+		if err := agg.add(v); err != nil {
+			return fmt.Errorf("aggregate %q: %w", g.specs[i].title(), err)
+		}
+	}
+	return nil
+}
+
+// row gives the key & aggregate values of the group
+func (g *group) row() []interface{} {
+	row := make([]interface{}, 0, len(g.keys)+len(g.aggs))
+	row = append(row, g.keys...)
+	for _, agg := range g.aggs {
+		row = append(row, agg.value())
+	}
+	return row
+}
+
+// aggregator accumulates values of a group
+type aggregator interface {
+	add(v interface{}) error
+	value() interface{}
+}
+
+func newAggregator(a Aggregate) aggregator {
+	switch a {
+	case AggCount:
+		return &countAgg{}
+	case AggSum:
+		return &sumAgg{}
+	case AggMin:
+		return &extremeAgg{sign: -1}
+	case AggMax:
+		return &extremeAgg{sign: 1}
+	case AggMean:
+		return &sumAgg{mean: true}
+	case AggDistinctCount:
+		return &distinctAgg{seen: map[string]bool{}}
+	case AggFirst:
+		return &positionAgg{first: true}
+	default:
+		return &positionAgg{}
+	}
+}
+
+// countAgg counts non-null values. Counting rows adds the row itself
+type countAgg struct {
+	n int64
+}
+
+func (a *countAgg) add(v interface{}) error {
+	if v != nil {
+		a.n++
+	}
+	return nil
+}
+
+func (a *countAgg) value() interface{} { return a.n }
+
+// sumAgg adds numbers, tracking an integer sum until a non-integer is added.
+// Integer sums that overflow an int64 continue in a big.Int
+type sumAgg struct {
+	mean  bool
+	n     int64
+	f     float64
+	i     int64
+	big   *big.Int
+	float bool
+}
+
+func (a *sumAgg) add(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	sv := newSortValue(v)
+	switch sv.t {
+	case vals.TypeInteger:
+		i, err := strconv.ParseInt(string(sv.data), 10, 64)
+		if err != nil {
+			bi, ok := new(big.Int).SetString(string(sv.data), 10)
+			if !ok {
+				return err
+			}
+			a.addBig(bi)
+			break
+		}
+		if sum := a.i + i; a.big != nil || i > 0 && sum < a.i || i < 0 && sum > a.i {
+			a.addBig(big.NewInt(i))
+		} else {
+			a.i = sum
+			a.f += float64(i)
+		}
+	case vals.TypeNumber:
+		f, err := strconv.ParseFloat(string(sv.data), 64)
+		if err != nil {
+			return err
+		}
+		a.f += f
+		a.float = true
+	default:
+		return fmt.Errorf("non-numeric value %v", v)
+	}
+	a.n++
+	return nil
+}
+
+// addBig adds an integer to the big.Int sum, starting it from the int64 sum
+func (a *sumAgg) addBig(i *big.Int) {
+	if a.big == nil {
+		a.big = big.NewInt(a.i)
+	}
+	a.big.Add(a.big, i)
+	f, _ := new(big.Float).SetInt(i).Float64()
+	a.f += f
+}
+
+func (a *sumAgg) value() interface{} {
+	switch {
+	case a.n == 0:
+		return nil
+	case a.mean:
+		return a.f / float64(a.n)
+	case a.float:
+		return a.f
+	case a.big != nil:
+		if a.big.IsInt64() {
+			return a.big.Int64()
+		}
+		return a.big
+	}
+	return a.i
+}
+
+// extremeAgg keeps the least or greatest value, with sign -1 & 1 respectively
+type extremeAgg struct {
+	sign int
+	v    interface{}
+	sv   sortValue
+}
+
+func (a *extremeAgg) add(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	sv := newSortValue(v)
+	if a.v != nil {
+		c, err := compareSortValues(sv, a.sv)
+		if err != nil {
+			return err
+		}
+		if c*a.sign <= 0 {
+			return nil
+		}
+	}
+	a.v, a.sv = v, sv
+	return nil
+}
+
+func (a *extremeAgg) value() interface{} { return a.v }
+
+// distinctAgg counts distinct non-null values
+type distinctAgg struct {
+	seen map[string]bool
+}
+
+func (a *distinctAgg) add(v interface{}) error {
+	if v != nil {
+		a.seen[hashSortValues([]sortValue{newSortValue(v)})] = true
+	}
+	return nil
+}
+
+func (a *distinctAgg) value() interface{} { return int64(len(a.seen)) }
+
+// positionAgg keeps the first or last value
+type positionAgg struct {
+	first bool
+	set   bool
+	v     interface{}
+}
+
+func (a *positionAgg) add(v interface{}) error {
+	if !a.first || !a.set {
+		a.v, a.set = v, true
+	}
+	return nil
+}
+
+func (a *positionAgg) value() interface{} { return a.v }
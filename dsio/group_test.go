@@ -0,0 +1,175 @@
+package dsio
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset/tabular"
+)
+
+var groupEventsSchema = tabular.Columns{
+	{Title: "user", Type: &tabular.ColType{"string"}},
+	{Title: "kind", Type: &tabular.ColType{"string"}},
+	{Title: "amount", Type: &tabular.ColType{"integer"}},
+	{Title: "score", Type: &tabular.ColType{"number"}},
+}.JSONSchema()
+
+func TestGroupReaders(t *testing.T) {
+	sorted := `[
+		["ada","view",1,0.5],
+		["ada","buy",3,null],
+		["ada","view",2,1.5],
+		["bab","buy",null,2],
+		[null,"view",4,1],
+		[null,"buy",5,1]
+	]`
+	unsorted := `[
+		["ada","view",1,0.5],
+		["bab","buy",null,2],
+		[null,"view",4,1],
+		["ada","buy",3,null],
+		[null,"buy",5,1],
+		["ada","view",2,1.5]
+	]`
+	aggs := []AggregateSpec{
+		{Func: AggCount},
+		{Func: AggCount, Column: "amount"},
+		{Func: AggSum, Column: "amount"},
+		{Func: AggSum, Column: "score"},
+		{Func: AggMin, Column: "score"},
+		{Func: AggMax, Column: "kind"},
+		{Func: AggMean, Column: "amount", Title: "avg"},
+		{Func: AggDistinctCount, Column: "kind"},
+		{Func: AggFirst, Column: "kind"},
+		{Func: AggLast, Column: "amount"},
+	}
+
+	cases := []struct {
+		description string
+		data        string
+		group       func(r EntryReader, keys []string, aggs []AggregateSpec) (*GroupReader, error)
+		expect      []Entry
+	}{
+		{"hash", unsorted, NewHashGroupReader, []Entry{
+			{Index: 0, Value: []interface{}{"ada", int64(3), int64(3), int64(6), 2.0, 0.5, "view", 2.0, int64(2), "view", int64(2)}},
+			{Index: 1, Value: []interface{}{"bab", int64(1), int64(0), nil, int64(2), int64(2), "buy", nil, int64(1), "buy", nil}},
+			{Index: 2, Value: []interface{}{nil, int64(2), int64(2), int64(9), int64(2), int64(1), "view", 4.5, int64(2), "view", int64(5)}},
+		}},
+		{"sorted", sorted, NewSortedGroupReader, []Entry{
+			{Index: 0, Value: []interface{}{"ada", int64(3), int64(3), int64(6), 2.0, 0.5, "view", 2.0, int64(2), "view", int64(2)}},
+			{Index: 1, Value: []interface{}{"bab", int64(1), int64(0), nil, int64(2), int64(2), "buy", nil, int64(1), "buy", nil}},
+			{Index: 2, Value: []interface{}{nil, int64(2), int64(2), int64(9), int64(2), int64(1), "view", 4.5, int64(2), "view", int64(5)}},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := c.group(joinReader(t, groupEventsSchema, c.data), []string{"user"}, aggs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, readEntries(t, r)); diff != "" {
+				t.Errorf("groups mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGroupReaderSchema(t *testing.T) {
+	r, err := NewHashGroupReader(joinReader(t, groupEventsSchema, `[]`), []string{"kind"}, []AggregateSpec{
+		{Func: AggCount},
+		{Func: AggSum, Column: "amount"},
+		{Func: AggSum, Column: "score"},
+		{Func: AggMean, Column: "amount"},
+		{Func: AggMax, Column: "user", Title: "kind"},
+		{Func: AggDistinctCount, Column: "user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := tabular.Columns{
+		{Title: "kind", Type: &tabular.ColType{"string"}},
+		{Title: "count", Type: &tabular.ColType{"integer"}},
+		{Title: "sum_amount", Type: &tabular.ColType{"integer", "null"}},
+		{Title: "sum_score", Type: &tabular.ColType{"number", "null"}},
+		{Title: "mean_amount", Type: &tabular.ColType{"number", "null"}},
+		{Title: "kind_2", Type: &tabular.ColType{"string", "null"}},
+		{Title: "distinct_count_user", Type: &tabular.ColType{"integer"}},
+	}.JSONSchema()
+	if diff := cmp.Diff(expect, r.Structure().Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]Entry{}, readEntries(t, r)); diff != "" {
+		t.Errorf("expected no groups for empty input (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupReaderNoKeys(t *testing.T) {
+	aggs := []AggregateSpec{{Func: AggCount}, {Func: AggSum, Column: "amount"}}
+	for _, group := range []func(r EntryReader, keys []string, aggs []AggregateSpec) (*GroupReader, error){NewHashGroupReader, NewSortedGroupReader} {
+		r, err := group(joinReader(t, groupEventsSchema, `[["a","x",1,1],["b","y",2,2]]`), nil, aggs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]Entry{{Value: []interface{}{int64(2), int64(3)}}}, readEntries(t, r)); diff != "" {
+			t.Errorf("groups mismatch (-want +got):\n%s", diff)
+		}
+
+		if r, err = group(joinReader(t, groupEventsSchema, `[]`), nil, aggs); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]Entry{{Value: []interface{}{int64(0), nil}}}, readEntries(t, r)); diff != "" {
+			t.Errorf("expected a single empty group (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestGroupReaderSumOverflow(t *testing.T) {
+	input := `[
+		["a","x",9223372036854775807,0],
+		["a","x",1,0],
+		["b","x",9223372036854775807,0],
+		["b","x",10,0],
+		["b","x",-10,0]
+	]`
+	r, err := NewSortedGroupReader(joinReader(t, groupEventsSchema, input), []string{"user"}, []AggregateSpec{{Func: AggSum, Column: "amount"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, _ := new(big.Int).SetString("9223372036854775808", 10)
+	expect := []Entry{
+		{Value: []interface{}{"a", sum}},
+		{Index: 1, Value: []interface{}{"b", int64(9223372036854775807)}},
+	}
+	if diff := cmp.Diff(expect, readEntries(t, r), cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 })); diff != "" {
+		t.Errorf("groups mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupReaderErrors(t *testing.T) {
+	cases := []struct {
+		keys []string
+		aggs []AggregateSpec
+		err  string
+	}{
+		{[]string{"who"}, nil, `column "who" not found`},
+		{nil, []AggregateSpec{{Func: AggSum}}, "sum aggregate requires a column"},
+		{nil, []AggregateSpec{{Func: AggMin, Column: "when"}}, `column "when" not found`},
+		{nil, []AggregateSpec{{Func: Aggregate(-1), Column: "amount"}}, "invalid aggregate: -1"},
+	}
+	for i, c := range cases {
+		_, err := NewHashGroupReader(joinReader(t, groupEventsSchema, `[]`), c.keys, c.aggs)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %q", i, c.err, err)
+		}
+	}
+
+	r, err := NewSortedGroupReader(joinReader(t, groupEventsSchema, `[["a","x",1,1]]`), nil, []AggregateSpec{{Func: AggSum, Column: "kind"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil || err.Error() != `aggregate "sum_kind": non-numeric value x` {
+		t.Errorf("expected non-numeric sum error. got: %v", err)
+	}
+}
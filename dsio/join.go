@@ -0,0 +1,495 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// JoinType enumerates the ways unmatched rows are handled in a join
+type JoinType int
+
+const (
+	// InnerJoin reads only rows with matches on both sides
+	InnerJoin JoinType = iota
+	// LeftJoin reads every left row, with null right columns where there's no
+	// match
+	LeftJoin
+	// FullOuterJoin reads every row of both sides, with null columns for the
+	// side without a match
+	FullOuterJoin
+)
+
+// String implements the stringer interface
+func (jt JoinType) String() string {
+	switch jt {
+	case InnerJoin:
+		return "inner"
+	case LeftJoin:
+		return "left"
+	case FullOuterJoin:
+		return "full outer"
+	}
+	return "unknown"
+}
+
+// JoinOn pairs a column of the left reader with a column of the right reader
+// that must be equal for rows to match
+type JoinOn struct {
+	Left  string
+	Right string
+}
+
+// JoinReader combines the rows of two readers of tabular data that have equal
+// key columns. Joined rows hold the columns of the left row followed by the
+// columns of the right row. Key values compare the way a SortedReader orders
+// them, so integer & number keys with the same value match. Rows with a null
+// key never match.
+// The schema of joined rows merges the columns of both sides, suffixing
+// colliding right column titles like "id_2". Columns of a side that can be
+// missing from a joined row accept nulls
+type JoinReader struct {
+	left    EntryReader
+	right   EntryReader
+	st      *dataset.Structure
+	joiner  joiner
+	pending [][]interface{}
+	index   int
+}
+
+var _ EntryReader = (*JoinReader)(nil)
+
+// joiner is a join strategy, reading batches of joined rows
+type joiner interface {
+	next() ([][]interface{}, error)
+}
+
+// NewHashJoinReader creates a reader that joins rows by reading the right side
+// into a hash table in memory, then streaming the left side. Rows are read in
+// the order of the left side, with unmatched right rows of full outer joins
+// read last. Use a hash join when the right side fits in memory
+func NewHashJoinReader(left, right EntryReader, on []JoinOn, jt JoinType) (*JoinReader, error) {
+	jr, sides, err := newJoinReader(left, right, on, jt)
+	if err != nil {
+		return nil, err
+	}
+	jr.joiner = &hashJoiner{jt: jt, left: sides[0], right: sides[1]}
+	return jr, nil
+}
+
+// NewMergeJoinReader creates a reader that joins rows by sorting both sides on
+// their key columns, then merging. Sorting spills to temporary files as a
+// SortedReader does, so only rows that share a key are held in memory at
+// once. Rows are read in key order. Use a merge join when neither side fits in
+// memory
+func NewMergeJoinReader(left, right EntryReader, on []JoinOn, jt JoinType) (*JoinReader, error) {
+	leftKeys := make([]SortKey, len(on))
+	rightKeys := make([]SortKey, len(on))
+	for i, o := range on {
+		leftKeys[i] = SortKey{Column: o.Left}
+		rightKeys[i] = SortKey{Column: o.Right}
+	}
+
+	jr, sides, err := newJoinReader(left, right, on, jt)
+	if err != nil {
+		return nil, err
+	}
+	if sides[0].reader, err = NewSortedReader(left, leftKeys); err != nil {
+		return nil, err
+	}
+	if sides[1].reader, err = NewSortedReader(right, rightKeys); err != nil {
+		return nil, err
+	}
+	jr.left, jr.right = sides[0].reader, sides[1].reader
+	jr.joiner = &mergeJoiner{jt: jt, left: sides[0], right: sides[1]}
+	return jr, nil
+}
+
+// newJoinReader checks key columns & builds the structure of joined rows
+func newJoinReader(left, right EntryReader, on []JoinOn, jt JoinType) (*JoinReader, [2]*keyedReader, error) {
+	sides := [2]*keyedReader{}
+	if len(on) == 0 {
+		return nil, sides, fmt.Errorf("join requires at least one pair of key columns")
+	}
+	if jt < InnerJoin || jt > FullOuterJoin {
+		return nil, sides, fmt.Errorf("invalid join type: %d", jt)
+	}
+
+	leftNames := make([]string, len(on))
+	rightNames := make([]string, len(on))
+	for i, o := range on {
+		leftNames[i], rightNames[i] = o.Left, o.Right
+	}
+	leftSide, leftCols, err := newKeyedReader(left, leftNames)
+	if err != nil {
+		return nil, sides, fmt.Errorf("left side of join: %w", err)
+	}
+	rightSide, rightCols, err := newKeyedReader(right, rightNames)
+	if err != nil {
+		return nil, sides, fmt.Errorf("right side of join: %w", err)
+	}
+
+	if jt == FullOuterJoin {
+		leftCols = nullableColumns(leftCols)
+	}
+	if jt != InnerJoin {
+		rightCols = nullableColumns(rightCols)
+	}
+
+	jr := &JoinReader{
+		left:  left,
+		right: right,
+		st:    derivedStructure(left.Structure(), leftCols.Merge(rightCols).JSONSchema()),
+	}
+	sides[0], sides[1] = leftSide, rightSide
+	return jr, sides, nil
+}
+
+// nullableColumns gives a copy of cols with null added to column types
+func nullableColumns(cols tabular.Columns) tabular.Columns {
+	nullable := make(tabular.Columns, len(cols))
+	for i, col := range cols {
+		if col.Type != nil && len(*col.Type) > 0 && !col.Type.HasType("null") {
+			ct := append(append(tabular.ColType{}, *col.Type...), "null")
+			col.Type = &ct
+		}
+		nullable[i] = col
+	}
+	return nullable
+}
+
+// Structure gives the structure of joined rows
+func (r *JoinReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads the next joined row
+func (r *JoinReader) ReadEntry() (Entry, error) {
+	for len(r.pending) == 0 {
+		rows, err := r.joiner.next()
+		if err != nil {
+			return Entry{}, err
+		}
+		r.pending = rows
+	}
+
+	ent := Entry{Index: r.index, Value: r.pending[0]}
+	r.pending = r.pending[1:]
+	r.index++
+	return ent, nil
+}
+
+// Close closes both sides of the join
+func (r *JoinReader) Close() error {
+	err := r.left.Close()
+	if rErr := r.right.Close(); rErr != nil && err == nil {
+		err = rErr
+	}
+	return err
+}
+
+// keyedReader reads the rows of tabular data with the values of key columns
+type keyedReader struct {
+	reader  EntryReader
+	width   int
+	indexes []int
+}
+
+func newKeyedReader(r EntryReader, names []string) (*keyedReader, tabular.Columns, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	indexes, err := columnIndexes(cols, names)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &keyedReader{reader: r, width: len(cols), indexes: indexes}, cols, nil
+}
+
+// keyedRow is a row with the values of its key columns
+type keyedRow struct {
+	values []interface{}
+	keys   []sortValue
+	// null is true when any key value is null. null keys never join
+	null bool
+}
+
+// read reads the next row, padding or truncating it to the width of the
+// reader's columns
+func (s *keyedReader) read() (*keyedRow, error) {
+	ent, err := s.reader.ReadEntry()
+	if err != nil {
+		return nil, err
+	}
+	row, ok := ent.Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("entry %d: expected array row, got %T", ent.Index, ent.Value)
+	}
+
+	jr := &keyedRow{
+		values: make([]interface{}, s.width),
+		keys:   make([]sortValue, len(s.indexes)),
+	}
+	copy(jr.values, row)
+	for i, idx := range s.indexes {
+		jr.keys[i] = newSortValue(jr.values[idx])
+		if jr.keys[i].t == vals.TypeNull {
+			jr.null = true
+		}
+	}
+	return jr, nil
+}
+
+// joinRows concatenates left & right rows, nil rows give null columns
+func joinRows(left, right *keyedRow, leftWidth, rightWidth int) []interface{} {
+	row := make([]interface{}, leftWidth+rightWidth)
+	if left != nil {
+		copy(row, left.values)
+	}
+	if right != nil {
+		copy(row[leftWidth:], right.values)
+	}
+	return row
+}
+
+// compareRowKeys compares the keys of two rows
+func compareRowKeys(a, b *keyedRow) (int, error) {
+	for i := range a.keys {
+		c, err := compareSortValues(a.keys[i], b.keys[i])
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+	return 0, nil
+}
+
+// hashKey encodes the keys of a row as a string that's equal for keys that
+// compare as equal
+func (jr *keyedRow) hashKey() string {
+	return hashSortValues(jr.keys)
+}
+
+// hashSortValues encodes sort values as a string that's equal for values that
+// compare as equal
+func hashSortValues(values []sortValue) string {
+	sb := &strings.Builder{}
+	for _, key := range values {
+		t, data := key.t, string(key.data)
+		if t == vals.TypeNumber {
+			t, data = numberHashKey(key)
+		}
+		fmt.Fprintf(sb, "%d:%d:%s", sortTypeRanks[t], len(data), data)
+	}
+	return sb.String()
+}
+
+// numberHashKey gives the hash form of a number by its exact value. Integral
+// numbers hash as integers so 2.0 matches 2
+func numberHashKey(v sortValue) (vals.Type, string) {
+	r := v.rat()
+	if r == nil {
+		return v.t, string(v.data)
+	}
+	if r.IsInt() {
+		return vals.TypeInteger, r.Num().String()
+	}
+	if f, exact := r.Float64(); exact {
+		return vals.TypeNumber, strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return vals.TypeNumber, r.RatString()
+}
+
+// hashJoiner joins rows by looking up left rows in a table of right rows
+type hashJoiner struct {
+	jt          JoinType
+	left, right *keyedReader
+
+	built    bool
+	rows     []*keyedRow
+	table    map[string][]int
+	matched  []bool
+	leftDone bool
+}
+
+func (h *hashJoiner) build() error {
+	h.table = map[string][]int{}
+	for {
+		row, err := h.right.read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if !row.null {
+			key := row.hashKey()
+			h.table[key] = append(h.table[key], len(h.rows))
+		}
+		h.rows = append(h.rows, row)
+	}
+	h.matched = make([]bool, len(h.rows))
+	return nil
+}
+
+func (h *hashJoiner) next() ([][]interface{}, error) {
+	if !h.built {
+		h.built = true
+		if err := h.build(); err != nil {
+			return nil, err
+		}
+	}
+
+	for !h.leftDone {
+		row, err := h.left.read()
+		if err == io.EOF {
+			h.leftDone = true
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		var matches []int
+		if !row.null {
+			matches = h.table[row.hashKey()]
+		}
+		if len(matches) == 0 {
+			if h.jt == InnerJoin {
+				continue
+			}
+			return [][]interface{}{joinRows(row, nil, h.left.width, h.right.width)}, nil
+		}
+		joined := make([][]interface{}, len(matches))
+		for i, idx := range matches {
+			h.matched[idx] = true
+			joined[i] = joinRows(row, h.rows[idx], h.left.width, h.right.width)
+		}
+		return joined, nil
+	}
+
+	if h.jt == FullOuterJoin && h.rows != nil {
+		var joined [][]interface{}
+		for i, row := range h.rows {
+			if !h.matched[i] {
+				joined = append(joined, joinRows(nil, row, h.left.width, h.right.width))
+			}
+		}
+		h.rows = nil
+		if len(joined) > 0 {
+			return joined, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// mergeJoiner joins rows of two sides sorted by their key columns
+type mergeJoiner struct {
+	jt          JoinType
+	left, right *keyedReader
+
+	started bool
+	l, r    *keyedRow
+	// group holds right rows that share a key with the current left row
+	group []*keyedRow
+}
+
+// advance reads the next row of a side, giving nil at the end of input
+func advance(s *keyedReader) (*keyedRow, error) {
+	row, err := s.read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return row, err
+}
+
+func (m *mergeJoiner) next() ([][]interface{}, error) {
+	var err error
+	if !m.started {
+		m.started = true
+		if m.l, err = advance(m.left); err != nil {
+			return nil, err
+		}
+		if m.r, err = advance(m.right); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if len(m.group) > 0 {
+			c := 1
+			if m.l != nil {
+				if c, err = compareRowKeys(m.l, m.group[0]); err != nil {
+					return nil, err
+				}
+			}
+			if c != 0 {
+				m.group = nil
+				continue
+			}
+			row := m.l
+			if m.l, err = advance(m.left); err != nil {
+				return nil, err
+			}
+			joined := make([][]interface{}, len(m.group))
+			for i, g := range m.group {
+				joined[i] = joinRows(row, g, m.left.width, m.right.width)
+			}
+			return joined, nil
+		}
+
+		if m.l == nil && m.r == nil {
+			return nil, io.EOF
+		}
+
+		// rows with null keys & rows past the end of the other side have no
+		// match
+		c := 0
+		switch {
+		case m.r == nil || m.l != nil && m.l.null:
+			c = -1
+		case m.l == nil || m.r.null:
+			c = 1
+		default:
+			if c, err = compareRowKeys(m.l, m.r); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case c < 0:
+			row := m.l
+			if m.l, err = advance(m.left); err != nil {
+				return nil, err
+			}
+			if m.jt != InnerJoin {
+				return [][]interface{}{joinRows(row, nil, m.left.width, m.right.width)}, nil
+			}
+		case c > 0:
+			row := m.r
+			if m.r, err = advance(m.right); err != nil {
+				return nil, err
+			}
+			if m.jt == FullOuterJoin {
+				return [][]interface{}{joinRows(nil, row, m.left.width, m.right.width)}, nil
+			}
+		default:
+			m.group = []*keyedRow{m.r}
+			for {
+				if m.r, err = advance(m.right); err != nil || m.r == nil {
+					break
+				}
+				if c, err = compareRowKeys(m.r, m.group[0]); err != nil || c != 0 {
+					break
+				}
+				m.group = append(m.group, m.r)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package dsio
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+var (
+	joinUsersSchema = tabular.Columns{
+		{Title: "id", Type: &tabular.ColType{"integer"}},
+		{Title: "name", Type: &tabular.ColType{"string"}},
+	}.JSONSchema()
+	joinOrdersSchema = tabular.Columns{
+		{Title: "id", Type: &tabular.ColType{"integer"}},
+		{Title: "user_id", Type: &tabular.ColType{"number"}},
+		{Title: "total", Type: &tabular.ColType{"number"}},
+	}.JSONSchema()
+)
+
+func joinReader(t *testing.T, schema map[string]interface{}, data string) EntryReader {
+	st := &dataset.Structure{Format: "json", Schema: schema}
+	r, err := NewJSONReader(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// joinedRows reads all rows of a join as sorted JSON strings, hash & merge
+// joins read rows in different orders
+func joinedRows(t *testing.T, r EntryReader) []string {
+	rows := []string{}
+	for i, ent := range readEntries(t, r) {
+		if ent.Index != i {
+			t.Errorf("entry %d: unexpected index %d", i, ent.Index)
+		}
+		data, err := json.Marshal(ent.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, string(data))
+	}
+	sort.Strings(rows)
+	return rows
+}
+
+func TestJoinReaders(t *testing.T) {
+	users := `[[1,"ada"],[2,"bab"],[3,"cat"],[null,"nil"]]`
+	orders := `[[10,1,5.5],[11,2.0,3],[12,1,1.25],[13,4,9],[14,null,0]]`
+	on := []JoinOn{{Left: "id", Right: "user_id"}}
+
+	cases := []struct {
+		jt     JoinType
+		expect []string
+	}{
+		{InnerJoin, []string{
+			`[1,"ada",10,1,5.5]`,
+			`[1,"ada",12,1,1.25]`,
+			`[2,"bab",11,2,3]`,
+		}},
+		{LeftJoin, []string{
+			`[1,"ada",10,1,5.5]`,
+			`[1,"ada",12,1,1.25]`,
+			`[2,"bab",11,2,3]`,
+			`[3,"cat",null,null,null]`,
+			`[null,"nil",null,null,null]`,
+		}},
+		{FullOuterJoin, []string{
+			`[1,"ada",10,1,5.5]`,
+			`[1,"ada",12,1,1.25]`,
+			`[2,"bab",11,2,3]`,
+			`[3,"cat",null,null,null]`,
+			`[null,"nil",null,null,null]`,
+			`[null,null,13,4,9]`,
+			`[null,null,14,null,0]`,
+		}},
+	}
+
+	strategies := []struct {
+		name string
+		join func(left, right EntryReader, on []JoinOn, jt JoinType) (*JoinReader, error)
+	}{
+		{"hash", NewHashJoinReader},
+		{"merge", NewMergeJoinReader},
+	}
+
+	for _, s := range strategies {
+		for _, c := range cases {
+			t.Run(s.name+" "+c.jt.String(), func(t *testing.T) {
+				r, err := s.join(joinReader(t, joinUsersSchema, users), joinReader(t, joinOrdersSchema, orders), on, c.jt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(c.expect, joinedRows(t, r)); diff != "" {
+					t.Errorf("rows mismatch (-want +got):\n%s", diff)
+				}
+				if err := r.Close(); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+func TestJoinReaderMultipleKeys(t *testing.T) {
+	left := `[[1,"a"],[1,"b"],[2,"a"]]`
+	right := `[[1,"a",1],[1,"b",1],[1,"a",2],[2,"b",3]]`
+	schema := tabular.Columns{{Title: "x"}, {Title: "y"}, {Title: "z"}}.JSONSchema()
+	on := []JoinOn{{Left: "id", Right: "x"}, {Left: "name", Right: "y"}}
+	expect := []string{`[1,"a",1,"a",1]`, `[1,"a",1,"a",2]`, `[1,"b",1,"b",1]`}
+
+	for _, join := range []func(left, right EntryReader, on []JoinOn, jt JoinType) (*JoinReader, error){NewHashJoinReader, NewMergeJoinReader} {
+		r, err := join(joinReader(t, joinUsersSchema, left), joinReader(t, schema, right), on, InnerJoin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expect, joinedRows(t, r)); diff != "" {
+			t.Errorf("rows mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestJoinReaderExactKeys(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: joinUsersSchema, FormatConfig: map[string]interface{}{"numbers": "decimal"}}
+	reader := func(data string) EntryReader {
+		r, err := NewJSONReader(st, strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	left := `[[12345678901234567890,"a"],[12345678901234567891,"b"]]`
+	right := `[[12345678901234567891,"y"],[1.2345678901234567890e19,"x"]]`
+	on := []JoinOn{{Left: "id", Right: "id"}}
+	expect := []string{`[12345678901234567890,"a",1.2345678901234567890e19,"x"]`, `[12345678901234567891,"b",12345678901234567891,"y"]`}
+
+	for _, join := range []func(left, right EntryReader, on []JoinOn, jt JoinType) (*JoinReader, error){NewHashJoinReader, NewMergeJoinReader} {
+		r, err := join(reader(left), reader(right), on, InnerJoin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expect, joinedRows(t, r)); diff != "" {
+			t.Errorf("rows mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestJoinReaderSchema(t *testing.T) {
+	r, err := NewHashJoinReader(joinReader(t, joinUsersSchema, `[]`), joinReader(t, joinOrdersSchema, `[]`), []JoinOn{{Left: "id", Right: "user_id"}}, LeftJoin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := tabular.Columns{
+		{Title: "id", Type: &tabular.ColType{"integer"}},
+		{Title: "name", Type: &tabular.ColType{"string"}},
+		{Title: "id_2", Type: &tabular.ColType{"integer", "null"}},
+		{Title: "user_id", Type: &tabular.ColType{"number", "null"}},
+		{Title: "total", Type: &tabular.ColType{"number", "null"}},
+	}.JSONSchema()
+	if diff := cmp.Diff(expect, r.Structure().Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if r.Structure().Format != "json" {
+		t.Errorf("expected joined structure to keep the left format. got: %q", r.Structure().Format)
+	}
+}
+
+func TestJoinReaderErrors(t *testing.T) {
+	cases := []struct {
+		on  []JoinOn
+		jt  JoinType
+		err string
+	}{
+		{nil, InnerJoin, "join requires at least one pair of key columns"},
+		{[]JoinOn{{Left: "id", Right: "user_id"}}, JoinType(7), "invalid join type: 7"},
+		{[]JoinOn{{Left: "uid", Right: "user_id"}}, InnerJoin, `left side of join: column "uid" not found`},
+		{[]JoinOn{{Left: "id", Right: "uid"}}, InnerJoin, `right side of join: column "uid" not found`},
+	}
+	for i, c := range cases {
+		_, err := NewMergeJoinReader(joinReader(t, joinUsersSchema, `[]`), joinReader(t, joinOrdersSchema, `[]`), c.on, c.jt)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %q", i, c.err, err)
+		}
+	}
+
+	r, err := NewHashJoinReader(joinReader(t, joinUsersSchema, `[{"id":1}]`), joinReader(t, joinOrdersSchema, `[]`), []JoinOn{{Left: "id", Right: "user_id"}}, InnerJoin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil || err.Error() != "entry 0: expected array row, got map[string]interface {}" {
+		t.Errorf("expected non-row error. got: %v", err)
+	}
+}
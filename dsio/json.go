@@ -22,9 +22,14 @@ type JSONReader struct {
 	reader      *bufio.Reader
 	close       func() error // close func from wrapped reader
 	prevSize    int          // when buffer is extended, remember how much of the old buffer to discard
+	pointer     []string     // reference tokens of the value holding entries, if nested
+	numbers     dataset.NumberMode
+	lenient     bool
+	quarantine  quarantine
+	counter     *lineCounter // counts bytes & lines read by lenient readers
 }
 
-var _ EntryReader = (*JSONReader)(nil)
+var _ LenientReader = (*JSONReader)(nil)
 
 // NewJSONReader creates a reader from a structure and read source
 func NewJSONReader(st *dataset.Structure, r io.Reader) (*JSONReader, error) {
@@ -45,16 +50,44 @@ func NewJSONReaderSize(st *dataset.Structure, r io.Reader, size int) (*JSONReade
 		return nil, err
 	}
 
-	r, close, err := maybeWrapDecompressor(st, r)
+	var (
+		pointer []string
+		numbers dataset.NumberMode
+		lenient bool
+	)
+	if st.FormatConfig != nil {
+		opts, err := dataset.NewJSONOptions(st.FormatConfig)
+		if err != nil {
+			return nil, err
+		}
+		if pointer, err = opts.EntriesPointer(); err != nil {
+			return nil, err
+		}
+		numbers = opts.Numbers()
+		lenient = opts.Lenient()
+	}
+
+	r, close, err := maybeWrapTextDecoder(st, r)
 	if err != nil {
 		return nil, err
 	}
 
+	var counter *lineCounter
+	if lenient {
+		counter = &lineCounter{r: r}
+		r = counter
+	}
+
 	jr := &JSONReader{
-		st:     st,
-		reader: bufio.NewReaderSize(r, size),
-		close:  close,
-		tlt:    tlt,
+		st:         st,
+		reader:     bufio.NewReaderSize(r, size),
+		close:      close,
+		tlt:        tlt,
+		pointer:    pointer,
+		numbers:    numbers,
+		lenient:    lenient,
+		quarantine: quarantine{st: st},
+		counter:    counter,
 	}
 	return jr, nil
 }
@@ -64,10 +97,29 @@ func (r *JSONReader) Structure() *dataset.Structure {
 	return r.st
 }
 
+// SetQuarantine sets a writer to report the entries a lenient reader skips to
+func (r *JSONReader) SetQuarantine(w io.Writer) {
+	r.quarantine.w = w
+}
+
 const blockSize = 4096
 
-// ReadEntry reads one JSON record from the reader
+// ReadEntry reads one JSON record from the reader. Lenient readers skip
+// malformed entries
 func (r *JSONReader) ReadEntry() (Entry, error) {
+	for {
+		ent, err := r.readEntry()
+		if rerr, ok := err.(*ReadError); ok && r.lenient {
+			if err := r.quarantine.add(rerr); err != nil {
+				return Entry{}, err
+			}
+			continue
+		}
+		return ent, err
+	}
+}
+
+func (r *JSONReader) readEntry() (Entry, error) {
 	ent := Entry{}
 
 	// Fill up buffer.
@@ -75,6 +127,9 @@ func (r *JSONReader) ReadEntry() (Entry, error) {
 
 	// Open JSON container the first time this is called.
 	if !r.initialized {
+		if err := r.seekEntries(); err != nil {
+			return ent, err
+		}
 		if r.tlt == "object" {
 			if !r.readTokenChar('{') {
 				return ent, fmt.Errorf("Expected: opening object '{'")
@@ -100,11 +155,19 @@ func (r *JSONReader) ReadEntry() (Entry, error) {
 	// Need a separator between elements, but not before the very first.
 	if r.initialized {
 		if !r.readTokenChar(',') {
+			if r.lenient && r.peekNextChar() == 0 {
+				// the body ends without closing the container
+				return ent, io.EOF
+			}
 			return ent, fmt.Errorf("Expected: separator ','")
 		}
 	}
 	r.initialized = true
 
+	if r.lenient {
+		return r.readLenientEntry()
+	}
+
 	// Read actual entry, format depends depends upon mode.
 	if r.tlt == "object" {
 		key, val, err := r.readKeyValuePair()
@@ -125,6 +188,153 @@ func (r *JSONReader) ReadEntry() (Entry, error) {
 	return ent, nil
 }
 
+// readLenientEntry reads the text of an entry before parsing it, so a
+// malformed entry can be skipped by resuming at the next separator. Parse
+// errors are returned as a *ReadError
+func (r *JSONReader) readLenientEntry() (Entry, error) {
+	raw, line, offset := r.readRawEntry()
+
+	sub := &JSONReader{
+		st:      r.st,
+		tlt:     r.tlt,
+		reader:  bufio.NewReader(bytes.NewReader(raw)),
+		numbers: r.numbers,
+	}
+	ent := Entry{}
+	var err error
+	switch {
+	case len(raw) == 0:
+		err = fmt.Errorf("Expected: value")
+	case r.tlt == "object":
+		ent.Key, ent.Value, err = sub.readKeyValuePair()
+	default:
+		ent.Index = r.entriesRead
+		ent.Value, err = sub.readValue()
+	}
+	if err == nil {
+		if ch := sub.peekNextChar(); ch != 0 {
+			err = fmt.Errorf("unexpected %q after value", ch)
+		}
+	}
+	if err != nil {
+		return Entry{}, &ReadError{Line: line, Offset: offset, Raw: string(raw), Err: err}
+	}
+
+	r.entriesRead++
+	return ent, nil
+}
+
+// readRawEntry reads the text of the next entry without parsing it, up to the
+// separator or closing bracket of the container that ends it. It reports the
+// line number & byte offset the entry starts at
+func (r *JSONReader) readRawEntry() (raw []byte, line int, offset int64) {
+	closer := byte(']')
+	if r.tlt == "object" {
+		closer = '}'
+	}
+
+	buff := r.currentBuffer()
+	depth, inString, escaped := 0, false, false
+	i := 0
+scan:
+	for ; ; i++ {
+		if i >= len(buff) {
+			var more bool
+			if buff, more = r.extendBuffer(buff); !more {
+				break
+			}
+		}
+		ch := buff[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if ch == '\\' {
+				escaped = true
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '{' || ch == '[':
+			depth++
+		case depth == 0 && (ch == ',' || ch == closer):
+			break scan
+		case ch == '}' || ch == ']':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	raw = append([]byte(nil), r.extractBytesFromBuffer(buff, i)...)
+
+	// everything up to the end of the entry has been consumed, the unread
+	// bytes are those still buffered
+	buffered, _ := r.reader.Peek(r.reader.Buffered())
+	offset = r.counter.n - int64(len(buffered)) - int64(len(raw))
+	line = r.counter.lines - bytes.Count(buffered, newline) - bytes.Count(raw, newline) + 1
+	return bytes.TrimRight(raw, " \t\r\n"), line, offset
+}
+
+// seekEntries skips to the value the entries pointer refers to, reading past
+// any values that come before it. Values after the entries are never read
+func (r *JSONReader) seekEntries() error {
+	for _, tok := range r.pointer {
+		switch r.peekNextChar() {
+		case '{':
+			r.readTokenChar('{')
+			for i := 0; ; i++ {
+				if r.readTokenChar('}') {
+					return r.errEntriesNotFound()
+				}
+				if i > 0 && !r.readTokenChar(',') {
+					return fmt.Errorf("Expected: ',' to separate elements")
+				}
+				key, err := r.readString()
+				if err != nil {
+					return err
+				}
+				if !r.readTokenChar(':') {
+					return fmt.Errorf("Expected: ':' to separate key and value")
+				}
+				if key == tok {
+					break
+				}
+				if _, err := r.readValue(); err != nil {
+					return err
+				}
+			}
+		case '[':
+			idx, err := strconv.Atoi(tok)
+			if err != nil || idx < 0 {
+				return r.errEntriesNotFound()
+			}
+			r.readTokenChar('[')
+			for i := 0; ; i++ {
+				if r.readTokenChar(']') {
+					return r.errEntriesNotFound()
+				}
+				if i > 0 && !r.readTokenChar(',') {
+					return fmt.Errorf("Expected: ',' to separate elements")
+				}
+				if i == idx {
+					break
+				}
+				if _, err := r.readValue(); err != nil {
+					return err
+				}
+			}
+		default:
+			return r.errEntriesNotFound()
+		}
+	}
+	return nil
+}
+
+func (r *JSONReader) errEntriesNotFound() error {
+	return fmt.Errorf("entriesPointer %q not found", r.st.FormatConfig["entriesPointer"])
+}
+
 // Close finalizes the reader
 func (r *JSONReader) Close() error {
 	if r.close != nil {
@@ -399,6 +609,9 @@ func (r *JSONReader) readNumber() (interface{}, error) {
 		}
 	}
 	if i > 0 {
+		if r.numbers != dataset.NumbersNative {
+			return decodeNumberText(r.extractFromBuffer(buff, i), isFloat, r.numbers)
+		}
 		if isFloat {
 			return strconv.ParseFloat(r.extractFromBuffer(buff, i), 64)
 		}
@@ -500,7 +713,9 @@ type JSONWriter struct {
 	keysWritten map[string]bool
 }
 
-// NewJSONWriter creates a Writer from a structure and write destination
+// NewJSONWriter creates a Writer from a structure and write destination.
+// Entries are always written as the top level value, so structures with an
+// entriesPointer are rejected: their bodies couldn't be read back
 func NewJSONWriter(st *dataset.Structure, w io.Writer) (*JSONWriter, error) {
 	if st.Schema == nil {
 		err := fmt.Errorf("schema required for JSON writer")
@@ -513,7 +728,21 @@ func NewJSONWriter(st *dataset.Structure, w io.Writer) (*JSONWriter, error) {
 		return nil, err
 	}
 
-	w, close, err := maybeWrapCompressor(st, w)
+	if st.FormatConfig != nil {
+		opts, err := dataset.NewJSONOptions(st.FormatConfig)
+		if err != nil {
+			return nil, err
+		}
+		pointer, err := opts.EntriesPointer()
+		if err != nil {
+			return nil, err
+		}
+		if len(pointer) > 0 {
+			return nil, fmt.Errorf("JSON writer doesn't support entriesPointer %q, entries are written as the top level value", st.FormatConfig["entriesPointer"])
+		}
+	}
+
+	w, close, err := maybeWrapTextEncoder(st, w)
 	if err != nil {
 		return nil, err
 	}
@@ -582,6 +811,7 @@ func (w *JSONWriter) WriteEntry(ent Entry) error {
 }
 
 func (w *JSONWriter) valBytes(ent Entry) (data []byte, err error) {
+	ent.Value = exactJSON(ent.Value)
 	if w.tlt == "array" {
 		// TODO - add test that checks this is recording values & not entries
 		if w.indent != "" {
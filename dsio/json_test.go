@@ -129,6 +129,83 @@ func TestJSONReaderBasicParsing(t *testing.T) {
 	}
 }
 
+func TestJSONReaderEntriesPointer(t *testing.T) {
+	envelope := `{
+		"meta": {"page": 1, "items": ["not", "these"]},
+		"data": {"items": [{"id": 1}, {"id": 2}], "total": 2},
+		"results": [[1, 2], {"a~b": {"x/y": {"k": "v"}}}]
+	}`
+
+	cases := []struct {
+		pointer string
+		schema  map[string]interface{}
+		expect  []Entry
+		err     string
+	}{
+		{"/data/items", dataset.BaseSchemaArray, []Entry{
+			{Index: 0, Value: map[string]interface{}{"id": int64(1)}},
+			{Index: 1, Value: map[string]interface{}{"id": int64(2)}},
+		}, ""},
+		{"/meta", dataset.BaseSchemaObject, []Entry{
+			{Key: "page", Value: int64(1)},
+			{Key: "items", Value: []interface{}{"not", "these"}},
+		}, ""},
+		{"/results/0", dataset.BaseSchemaArray, []Entry{
+			{Index: 0, Value: int64(1)},
+			{Index: 1, Value: int64(2)},
+		}, ""},
+		{"/results/1/a~0b/x~1y", dataset.BaseSchemaObject, []Entry{
+			{Key: "k", Value: "v"},
+		}, ""},
+		{"/data/missing", dataset.BaseSchemaArray, nil, `entriesPointer "/data/missing" not found`},
+		{"/results/2", dataset.BaseSchemaArray, nil, `entriesPointer "/results/2" not found`},
+		{"/results/first", dataset.BaseSchemaArray, nil, `entriesPointer "/results/first" not found`},
+		{"/meta/page/value", dataset.BaseSchemaArray, nil, `entriesPointer "/meta/page/value" not found`},
+		{"/data", dataset.BaseSchemaArray, nil, "Expected: opening array '['"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pointer, func(t *testing.T) {
+			st := &dataset.Structure{
+				Format:       "json",
+				FormatConfig: map[string]interface{}{"entriesPointer": c.pointer},
+				Schema:       c.schema,
+			}
+			r, err := NewJSONReader(st, strings.NewReader(envelope))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []Entry
+			for {
+				ent, err := r.ReadEntry()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					if err.Error() != c.err {
+						t.Fatalf("error mismatch. expected: %q, got: %q", c.err, err)
+					}
+					return
+				}
+				got = append(got, ent)
+			}
+			if c.err != "" {
+				t.Fatalf("expected error: %q", c.err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := NewJSONReader(&dataset.Structure{
+		Format:       "json",
+		FormatConfig: map[string]interface{}{"entriesPointer": "data"},
+		Schema:       dataset.BaseSchemaArray,
+	}, strings.NewReader(envelope)); err == nil {
+		t.Error("expected invalid entriesPointer to error")
+	}
+}
+
 func TestJSONReaderSmallerBufferForHugeToken(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -301,6 +378,8 @@ func TestJSONWriter(t *testing.T) {
 	}{
 		{&dataset.Structure{}, []Entry{}, "[]", "schema required for JSON writer"},
 		{&dataset.Structure{Schema: map[string]interface{}{"type": "string"}}, []Entry{}, "[]", "invalid schema. root must be either an array or object type"},
+		{&dataset.Structure{Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"entriesPointer": "/data/items"}}, []Entry{}, "[]", `JSON writer doesn't support entriesPointer "/data/items", entries are written as the top level value`},
+		{&dataset.Structure{Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"entriesPointer": ""}}, []Entry{}, "[]", ""},
 
 		{arrst, []Entry{}, "[]", ""},
 		{objst, []Entry{}, "{}", ""},
@@ -0,0 +1,105 @@
+package dsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+)
+
+// ReadError describes a malformed record a lenient reader skipped
+type ReadError struct {
+	// Line is the line number the record starts on, counting from 1
+	Line int
+	// Offset is the number of bytes before the record in the body, after
+	// decompression & character decoding
+	Offset int64
+	// Raw is the text of the record
+	Raw string
+	// Err is the reason the record couldn't be read
+	Err error
+}
+
+// Error implements the error interface
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("line %d, byte %d: %s", e.Line, e.Offset, e.Err)
+}
+
+// Unwrap gives the underlying error
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON writes a read error as a quarantine record
+func (e *ReadError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Line   int    `json:"line"`
+		Offset int64  `json:"offset"`
+		Error  string `json:"error"`
+		Raw    string `json:"raw"`
+	}{e.Line, e.Offset, e.Err.Error(), e.Raw})
+}
+
+// LenientReader is an EntryReader that can skip malformed records instead of
+// failing. Readers are lenient when the "lenient" format config option is
+// set. Each skipped record adds to the ErrCount of the reader's structure
+type LenientReader interface {
+	EntryReader
+	// SetQuarantine sets a writer to report skipped records to, one JSON
+	// object per line with "line", "offset", "error" & "raw" fields
+	SetQuarantine(w io.Writer)
+}
+
+// SetQuarantine sets the quarantine writer of a lenient reader, returning
+// false if the reader can't skip records
+func SetQuarantine(r EntryReader, w io.Writer) bool {
+	if lr, ok := r.(LenientReader); ok {
+		lr.SetQuarantine(w)
+		return true
+	}
+	return false
+}
+
+// quarantine records the malformed records a reader skips
+type quarantine struct {
+	st *dataset.Structure
+	w  io.Writer
+}
+
+// add counts a skipped record, reporting it to the quarantine writer if one
+// is set
+func (q *quarantine) add(e *ReadError) error {
+	log.Debug(e.Error())
+	q.st.ErrCount++
+	if q.w == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := q.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing to quarantine: %w", err)
+	}
+	return nil
+}
+
+var newline = []byte{'\n'}
+
+// lineCounter counts the bytes & line breaks read from a reader
+type lineCounter struct {
+	r     io.Reader
+	n     int64
+	lines int
+}
+
+// Read implements the io.Reader interface
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	c.lines += bytes.Count(p[:n], newline)
+	return n, err
+}
@@ -0,0 +1,171 @@
+package dsio
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+var lenientCSVSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "name", "type": "string"},
+			map[string]interface{}{"title": "count", "type": "integer"},
+		},
+	},
+}
+
+func TestLenientReaders(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		data        string
+		expect      []Entry
+		quarantine  string
+	}{
+		{"csv",
+			&dataset.Structure{Format: "csv", Schema: lenientCSVSchema, FormatConfig: map[string]interface{}{"headerRow": true, "lenient": true}},
+			"name,count\nada,1\nbab\"bage,2\ngrace,3\nturing\nhopper,4\n\"unterminated,5",
+			[]Entry{
+				{Value: []interface{}{"ada", int64(1)}},
+				{Value: []interface{}{"grace", int64(3)}},
+				{Value: []interface{}{"hopper", int64(4)}},
+			},
+			`{"line":3,"offset":17,"error":"record on line 3: bare '\"' in non-quoted-field","raw":"bab\"bage,2"}
+{"line":5,"offset":36,"error":"record on line 5: wrong number of fields","raw":"turing"}
+{"line":7,"offset":52,"error":"record on line 7: extraneous or missing '\"' in quoted-field","raw":"\"unterminated,5"}
+`,
+		},
+		{"ndjson",
+			&dataset.Structure{Format: "ndjson", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"lenient": true}},
+			"{\"a\":1}\n{\"a\":\n\n[2]\n{oops}\n3",
+			[]Entry{
+				{Index: 0, Value: map[string]interface{}{"a": float64(1)}},
+				{Index: 1, Value: []interface{}{float64(2)}},
+				{Index: 2, Value: float64(3)},
+			},
+			`{"line":2,"offset":8,"error":"unexpected end of JSON input","raw":"{\"a\":"}
+{"line":5,"offset":19,"error":"invalid character 'o' looking for beginning of object key string","raw":"{oops}"}
+`,
+		},
+		{"json array",
+			&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"lenient": true}},
+			"[\n  {\"a\": 1},\n  {\"a\" 2, \"b\": [\"],\"]},\n  tru,\n  [3],\n  ,\n  \"four\"\n]",
+			[]Entry{
+				{Index: 0, Value: map[string]interface{}{"a": int64(1)}},
+				{Index: 1, Value: []interface{}{int64(3)}},
+				{Index: 2, Value: "four"},
+			},
+			`{"line":3,"offset":16,"error":"Expected: ':' to separate key and value","raw":"{\"a\" 2, \"b\": [\"],\"]}"}
+{"line":4,"offset":40,"error":"Expected: true","raw":"tru"}
+{"line":6,"offset":54,"error":"Expected: value","raw":""}
+`,
+		},
+		{"json object",
+			&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaObject, FormatConfig: map[string]interface{}{"lenient": true}},
+			`{"a": 1, "b" 2, "c": [3], "d": 4 5}`,
+			[]Entry{
+				{Key: "a", Value: int64(1)},
+				{Key: "c", Value: []interface{}{int64(3)}},
+			},
+			`{"line":1,"offset":9,"error":"Expected: ':' to separate key and value","raw":"\"b\" 2"}
+{"line":1,"offset":26,"error":"unexpected '5' after value","raw":"\"d\": 4 5"}
+`,
+		},
+		{"json missing close",
+			&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"lenient": true}},
+			`[1, 2, {"a": `,
+			[]Entry{
+				{Index: 0, Value: int64(1)},
+				{Index: 1, Value: int64(2)},
+			},
+			`{"line":1,"offset":7,"error":"Expected: ',' to separate elements","raw":"{\"a\":"}
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := NewEntryReader(c.st, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			quarantine := &bytes.Buffer{}
+			if !SetQuarantine(r, quarantine) {
+				t.Fatalf("expected %s reader to be lenient", c.st.Format)
+			}
+
+			got := []Entry{}
+			for {
+				ent, err := r.ReadEntry()
+				if err != nil {
+					if err.Error() != "EOF" {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					break
+				}
+				got = append(got, ent)
+			}
+
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(c.quarantine, quarantine.String()); diff != "" {
+				t.Errorf("quarantine mismatch (-want +got):\n%s", diff)
+			}
+			if expect := strings.Count(c.quarantine, "\n"); c.st.ErrCount != expect {
+				t.Errorf("errCount mismatch. expected: %d, got: %d", expect, c.st.ErrCount)
+			}
+		})
+	}
+}
+
+func TestStrictReadersFail(t *testing.T) {
+	cases := []struct {
+		st   *dataset.Structure
+		data string
+	}{
+		{&dataset.Structure{Format: "csv", Schema: lenientCSVSchema}, "a,1\nc\"d,2\n"},
+		{&dataset.Structure{Format: "ndjson", Schema: dataset.BaseSchemaArray}, "{\"a\":1}\n{oops}\n"},
+		{&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `[1, tru, 3]`},
+	}
+
+	for _, c := range cases {
+		r, err := NewEntryReader(c.st, strings.NewReader(c.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewEntryWriter(&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, &bytes.Buffer{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Copy(r, w); err == nil {
+			t.Errorf("%s: expected copying malformed data to fail", c.st.Format)
+		}
+		if c.st.ErrCount != 0 {
+			t.Errorf("%s: expected strict reader to leave errCount unset. got: %d", c.st.Format, c.st.ErrCount)
+		}
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestQuarantineWriteError(t *testing.T) {
+	st := &dataset.Structure{Format: "ndjson", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"lenient": true}}
+	r, err := NewNDJSONReader(st, strings.NewReader("{oops}\n1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetQuarantine(errWriter{})
+	if _, err := r.ReadEntry(); err == nil || err.Error() != "writing to quarantine: disk full" {
+		t.Errorf("expected quarantine write error. got: %v", err)
+	}
+}
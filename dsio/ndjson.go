@@ -2,6 +2,7 @@ package dsio
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,9 +17,14 @@ type NDJSONReader struct {
 	buf         *bufio.Reader
 	close       func() error // close func from wrapped reader
 	prevSize    int          // when buffer is extended, remember how much of the old buffer to discard
+	numbers     dataset.NumberMode
+	lenient     bool
+	quarantine  quarantine
+	line        int   // number of lines read
+	offset      int64 // number of bytes read
 }
 
-var _ EntryReader = (*NDJSONReader)(nil)
+var _ LenientReader = (*NDJSONReader)(nil)
 
 // NewNDJSONReader creates a reader from a structure and read source
 func NewNDJSONReader(st *dataset.Structure, r io.Reader) (*NDJSONReader, error) {
@@ -36,15 +42,23 @@ func NewNDJSONReader(st *dataset.Structure, r io.Reader) (*NDJSONReader, error)
 		return nil, fmt.Errorf("NDJSON top level type must be 'array'")
 	}
 
-	r, close, err := maybeWrapDecompressor(st, r)
+	opts, err := dataset.NewNDJSONOptions(st.FormatConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	r, close, err := maybeWrapTextDecoder(st, r)
 	if err != nil {
 		return nil, err
 	}
 
 	ndjr := &NDJSONReader{
-		st:    st,
-		buf:   bufio.NewReader(r),
-		close: close,
+		st:         st,
+		buf:        bufio.NewReader(r),
+		close:      close,
+		numbers:    opts.Numbers,
+		lenient:    opts.Lenient,
+		quarantine: quarantine{st: st},
 	}
 	return ndjr, nil
 }
@@ -54,25 +68,68 @@ func (r *NDJSONReader) Structure() *dataset.Structure {
 	return r.st
 }
 
-// ReadEntry reads one JSON record from the reader
+// SetQuarantine sets a writer to report the lines a lenient reader skips to
+func (r *NDJSONReader) SetQuarantine(w io.Writer) {
+	r.quarantine.w = w
+}
+
+// ReadEntry reads one JSON record from the reader. Lenient readers skip
+// blank & malformed lines
 func (r *NDJSONReader) ReadEntry() (Entry, error) {
-	line, err := r.buf.ReadBytes('\n')
-	if err != nil {
-		return Entry{}, err
+	for {
+		line, err := r.buf.ReadBytes('\n')
+		if err != nil && !(err == io.EOF && r.lenient && len(bytes.TrimSpace(line)) > 0) {
+			return Entry{}, err
+		}
+		lineNum, offset := r.line+1, r.offset
+		r.line++
+		r.offset += int64(len(line))
+
+		v, err := r.decodeLine(line)
+		if err != nil {
+			if !r.lenient {
+				return Entry{}, err
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			rerr := &ReadError{
+				Line:   lineNum,
+				Offset: offset,
+				Raw:    string(bytes.TrimRight(line, "\r\n")),
+				Err:    err,
+			}
+			if err := r.quarantine.add(rerr); err != nil {
+				return Entry{}, err
+			}
+			continue
+		}
+
+		ent := Entry{
+			Index: r.entriesRead,
+			Value: v,
+		}
+		r.entriesRead++
+		return ent, nil
 	}
+}
 
-	var v interface{}
-	if err := json.Unmarshal(line, &v); err != nil {
-		return Entry{}, err
+// decodeLine decodes the JSON value of a line
+func (r *NDJSONReader) decodeLine(line []byte) (v interface{}, err error) {
+	if r.numbers == dataset.NumbersNative {
+		err = json.Unmarshal(line, &v)
+		return v, err
 	}
 
-	ent := Entry{
-		Index: r.entriesRead,
-		Value: v,
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
 	}
-
-	r.entriesRead++
-	return ent, nil
+	if r.numbers == dataset.NumbersBig {
+		return bigNumbers(v)
+	}
+	return v, nil
 }
 
 // Close finalizes the reader
@@ -103,7 +160,7 @@ func NewNDJSONWriter(st *dataset.Structure, w io.Writer) (*NDJSONWriter, error)
 		return nil, err
 	}
 
-	w, close, err := maybeWrapCompressor(st, w)
+	w, close, err := maybeWrapTextEncoder(st, w)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +182,7 @@ func (w *NDJSONWriter) Structure() *dataset.Structure {
 
 // WriteEntry writes one JSON entry to the writer
 func (w *NDJSONWriter) WriteEntry(ent Entry) error {
-	return w.enc.Encode(ent.Value)
+	return w.enc.Encode(exactJSON(ent.Value))
 }
 
 // Close finalizes the writer
@@ -0,0 +1,291 @@
+package dsio
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/ugorji/go/codec"
+)
+
+// decodeNumberText converts the text of a number to the go type of a number
+// mode. isFloat reports the number has a fraction or exponent. Native mode
+// is left to callers
+func decodeNumberText(text string, isFloat bool, mode dataset.NumberMode) (interface{}, error) {
+	switch mode {
+	case dataset.NumbersDecimal:
+		return json.Number(text), nil
+	case dataset.NumbersBig:
+		if isFloat {
+			return parseBigFloat(text)
+		}
+		i, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %q", text)
+		}
+		return i, nil
+	}
+	return nil, fmt.Errorf("unsupported number mode: %q", mode)
+}
+
+// parseBigFloat parses decimal text into a big.Float with enough precision
+// for every digit of the text
+func parseBigFloat(text string) (*big.Float, error) {
+	// each decimal digit needs a little less than 4 bits
+	prec := uint(len(text)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(text, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %q", text)
+	}
+	return f, nil
+}
+
+// isFloatText reports if the text of a number has a fraction or exponent
+func isFloatText(text string) bool {
+	return strings.ContainsAny(text, ".eE")
+}
+
+// bigNumbers replaces json.Number values in decoded JSON with *big.Int &
+// *big.Float values
+func bigNumbers(v interface{}) (interface{}, error) {
+	var err error
+	switch x := v.(type) {
+	case json.Number:
+		return decodeNumberText(string(x), isFloatText(string(x)), dataset.NumbersBig)
+	case []interface{}:
+		for i, e := range x {
+			if x[i], err = bigNumbers(e); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for k, e := range x {
+			if x[k], err = bigNumbers(e); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// bigIntValue converts an integer to the go type of a number mode. Native
+// mode falls back to float64 for integers that overflow an int64
+func bigIntValue(i *big.Int, mode dataset.NumberMode) interface{} {
+	switch mode {
+	case dataset.NumbersDecimal:
+		return json.Number(i.String())
+	case dataset.NumbersBig:
+		return i
+	}
+	if i.IsInt64() {
+		return i.Int64()
+	}
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return f
+}
+
+// floatValue converts a float to the go type of a number mode. Infinities &
+// NaN stay float64 values, having no decimal or big.Float representation
+func floatValue(f float64, mode dataset.NumberMode) interface{} {
+	if mode == dataset.NumbersNative || math.IsInf(f, 0) || math.IsNaN(f) {
+		return f
+	}
+	if mode == dataset.NumbersDecimal {
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return new(big.Float).SetFloat64(f)
+}
+
+// decimalText formats mantissa * 10^exp as decimal text, without an exponent
+// unless the number has many trailing zeros
+func decimalText(mantissa *big.Int, exp int64) string {
+	digits := new(big.Int).Abs(mantissa).String()
+	sign := ""
+	if mantissa.Sign() < 0 {
+		sign = "-"
+	}
+
+	switch {
+	case exp == 0:
+		return sign + digits
+	case exp > 0 && exp <= 20:
+		return sign + digits + strings.Repeat("0", int(exp))
+	case exp > 0:
+		return sign + digits + "e+" + strconv.FormatInt(exp, 10)
+	case -exp < int64(len(digits)):
+		point := len(digits) + int(exp)
+		return sign + digits[:point] + "." + digits[point:]
+	case -exp-int64(len(digits)) <= 20:
+		return sign + "0." + strings.Repeat("0", int(-exp)-len(digits)) + digits
+	}
+	return sign + digits + "e" + strconv.FormatInt(exp, 10)
+}
+
+// exactJSONValue prepares a value for JSON encoding. encoding/json quotes
+// *big.Float values as strings, exactJSONValue swaps them for json.Number
+// values. Containers are only copied when they hold a *big.Float, reporting
+// if the value changed
+func exactJSONValue(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case *big.Float:
+		if x == nil {
+			return nil, true
+		}
+		return json.Number(x.Text('g', -1)), true
+	case []interface{}:
+		var cp []interface{}
+		for i, e := range x {
+			if ev, changed := exactJSONValue(e); changed {
+				if cp == nil {
+					cp = append([]interface{}{}, x...)
+				}
+				cp[i] = ev
+			}
+		}
+		if cp != nil {
+			return cp, true
+		}
+	case map[string]interface{}:
+		var cp map[string]interface{}
+		for k, e := range x {
+			if ev, changed := exactJSONValue(e); changed {
+				if cp == nil {
+					cp = make(map[string]interface{}, len(x))
+					for k2, e2 := range x {
+						cp[k2] = e2
+					}
+				}
+				cp[k] = ev
+			}
+		}
+		if cp != nil {
+			return cp, true
+		}
+	}
+	return v, false
+}
+
+// exactJSON prepares a value for JSON encoding, see exactJSONValue
+func exactJSON(v interface{}) interface{} {
+	v, _ = exactJSONValue(v)
+	return v
+}
+
+// exactCBOR prepares a value for CBOR encoding, converting json.Number,
+// *big.Int & *big.Float values to native CBOR numbers when they fit and
+// bignum (tags 2 & 3) or decimal fraction (tag 4) values when they don't
+func exactCBOR(v interface{}) (interface{}, error) {
+	var err error
+	switch x := v.(type) {
+	case json.Number:
+		return cborNumberText(string(x))
+	case *big.Int:
+		if x == nil {
+			return nil, nil
+		}
+		return cborInt(x), nil
+	case *big.Float:
+		if x == nil {
+			return nil, nil
+		}
+		if x.IsInf() {
+			f, _ := x.Float64()
+			return f, nil
+		}
+		if f, acc := x.Float64(); acc == big.Exact {
+			return f, nil
+		}
+		return cborNumberText(x.Text('g', -1))
+	case []interface{}:
+		cp := make([]interface{}, len(x))
+		for i, e := range x {
+			if cp[i], err = exactCBOR(e); err != nil {
+				return nil, err
+			}
+		}
+		return cp, nil
+	case map[string]interface{}:
+		cp := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			if cp[k], err = exactCBOR(e); err != nil {
+				return nil, err
+			}
+		}
+		return cp, nil
+	}
+	return v, nil
+}
+
+// cborNumberText converts the text of a number to a CBOR value that keeps
+// the number's exact value
+func cborNumberText(text string) (interface{}, error) {
+	if !isFloatText(text) {
+		i, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %q", text)
+		}
+		return cborInt(i), nil
+	}
+
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %q", text)
+	}
+	if f, exact := r.Float64(); exact {
+		return f, nil
+	}
+
+	mantissa, exp, err := parseDecimal(text)
+	if err != nil {
+		return nil, err
+	}
+	return &codec.RawExt{Tag: cborTagDecimalFraction, Value: []interface{}{exp, cborInt(mantissa)}}, nil
+}
+
+// cborInt converts an integer to a native CBOR integer if it fits in 64 bits,
+// using bignum tags for larger integers
+func cborInt(i *big.Int) interface{} {
+	if i.IsInt64() {
+		return i.Int64()
+	}
+	if i.IsUint64() {
+		return i.Uint64()
+	}
+	if i.Sign() > 0 {
+		return &codec.RawExt{Tag: cborTagPosBignum, Value: i.Bytes()}
+	}
+	// negative bignums encode -1 - n
+	n := new(big.Int).Neg(i)
+	n.Sub(n, big.NewInt(1))
+	return &codec.RawExt{Tag: cborTagNegBignum, Value: n.Bytes()}
+}
+
+// parseDecimal splits decimal text into an integer mantissa & base 10
+// exponent
+func parseDecimal(text string) (*big.Int, int64, error) {
+	digits, exp := text, int64(0)
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(text[i+1:], 10, 64); err != nil {
+			return nil, 0, fmt.Errorf("invalid number: %q", text)
+		}
+		digits = text[:i]
+	}
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		exp -= int64(len(digits) - i - 1)
+		digits = digits[:i] + digits[i+1:]
+	}
+
+	mantissa, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid number: %q", text)
+	}
+	return mantissa, exp, nil
+}
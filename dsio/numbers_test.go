@@ -0,0 +1,312 @@
+package dsio
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+// bigComparers compare arbitrary-precision numbers by value
+var bigComparers = cmp.Options{
+	cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 }),
+	cmp.Comparer(func(a, b *big.Float) bool { return a.Cmp(b) == 0 }),
+}
+
+func mustBigInt(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big.Int: " + s)
+	}
+	return i
+}
+
+func mustBigFloat(s string) *big.Float {
+	f, err := parseBigFloat(s)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func numbersStructure(format, mode string) *dataset.Structure {
+	return &dataset.Structure{
+		Format:       format,
+		FormatConfig: map[string]interface{}{"numbers": mode},
+		Schema:       dataset.BaseSchemaArray,
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	body := `[12345678901234567890123,-0.1,1.50,{"id":98765432109876543210},[3e400]]`
+
+	cases := []struct {
+		format, mode string
+		expect       []interface{}
+		written      string
+	}{
+		{"json", "decimal", []interface{}{
+			json.Number("12345678901234567890123"),
+			json.Number("-0.1"),
+			json.Number("1.50"),
+			map[string]interface{}{"id": json.Number("98765432109876543210")},
+			[]interface{}{json.Number("3e400")},
+		}, body},
+		{"json", "big", []interface{}{
+			mustBigInt("12345678901234567890123"),
+			mustBigFloat("-0.1"),
+			mustBigFloat("1.5"),
+			map[string]interface{}{"id": mustBigInt("98765432109876543210")},
+			[]interface{}{mustBigFloat("3e400")},
+		}, `[12345678901234567890123,-0.1,1.5,{"id":98765432109876543210},[3e+400]]`},
+		{"ndjson", "decimal", []interface{}{
+			json.Number("12345678901234567890123"),
+			json.Number("-0.1"),
+			json.Number("1.50"),
+			map[string]interface{}{"id": json.Number("98765432109876543210")},
+			[]interface{}{json.Number("3e400")},
+		}, strings.Join([]string{"12345678901234567890123", "-0.1", "1.50", `{"id":98765432109876543210}`, "[3e400]", ""}, "\n")},
+		{"ndjson", "big", []interface{}{
+			mustBigInt("12345678901234567890123"),
+			mustBigFloat("-0.1"),
+			mustBigFloat("1.5"),
+			map[string]interface{}{"id": mustBigInt("98765432109876543210")},
+			[]interface{}{mustBigFloat("3e400")},
+		}, strings.Join([]string{"12345678901234567890123", "-0.1", "1.5", `{"id":98765432109876543210}`, "[3e+400]", ""}, "\n")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format+" "+c.mode, func(t *testing.T) {
+			st := numbersStructure(c.format, c.mode)
+			in := body
+			if c.format == "ndjson" {
+				in = strings.Join([]string{"12345678901234567890123", "-0.1", "1.50", `{"id":98765432109876543210}`, "[3e400]", ""}, "\n")
+			}
+
+			r, err := NewEntryReader(st, strings.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got, bigComparers); diff != "" {
+				t.Fatalf("result mismatch (-want +got):\n%s", diff)
+			}
+
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range got {
+				if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != c.written {
+				t.Errorf("written mismatch.\nexpected: %s\ngot:      %s", c.written, buf.String())
+			}
+		})
+	}
+}
+
+func TestCBORNumbers(t *testing.T) {
+	values := []interface{}{
+		json.Number("12345678901234567890123"),
+		json.Number("-12345678901234567890123"),
+		json.Number("18446744073709551615"),
+		json.Number("-7"),
+		json.Number("1.50"),
+		json.Number("0.1"),
+		mustBigFloat("-2.25"),
+		mustBigInt("-18446744073709551616"),
+		map[string]interface{}{"amount": json.Number("1234567890.123456789")},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewCBORWriter(numbersStructure("cbor", ""), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	cases := []struct {
+		mode   string
+		expect []interface{}
+	}{
+		{"", []interface{}{
+			1.2345678901234568e+22,
+			-1.2345678901234568e+22,
+			float64(math.MaxUint64),
+			int64(-7),
+			1.5,
+			0.1,
+			-2.25,
+			-18446744073709551616.0,
+			map[string]interface{}{"amount": 1234567890.1234567},
+		}},
+		{"decimal", []interface{}{
+			json.Number("12345678901234567890123"),
+			json.Number("-12345678901234567890123"),
+			json.Number("18446744073709551615"),
+			json.Number("-7"),
+			json.Number("1.5"),
+			json.Number("0.1"),
+			json.Number("-2.25"),
+			json.Number("-18446744073709551616"),
+			map[string]interface{}{"amount": json.Number("1234567890.123456789")},
+		}},
+		{"big", []interface{}{
+			mustBigInt("12345678901234567890123"),
+			mustBigInt("-12345678901234567890123"),
+			mustBigInt("18446744073709551615"),
+			big.NewInt(-7),
+			big.NewFloat(1.5),
+			mustBigFloat("0.1"),
+			big.NewFloat(-2.25),
+			mustBigInt("-18446744073709551616"),
+			map[string]interface{}{"amount": mustBigFloat("1234567890.123456789")},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.mode, func(t *testing.T) {
+			r, err := NewCBORReader(numbersStructure("cbor", c.mode), bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got, bigComparers); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCBORReaderNumbers(t *testing.T) {
+	cases := []struct {
+		description string
+		hex         string
+		expect      interface{}
+	}{
+		{"one byte negative int", "3863", int64(-100)},
+		{"eight byte negative int", "3b7fffffffffffffff", int64(math.MinInt64)},
+		{"largest uint", "1bffffffffffffffff", float64(math.MaxUint64)},
+		{"half float", "f93e00", 1.5},
+		{"half float subnormal", "f90001", 5.960464477539063e-08},
+		{"half float infinity", "f9fc00", math.Inf(-1)},
+		{"single float", "fa47c35000", float64(100000)},
+		{"positive bignum", "c249010000000000000000", 18446744073709551616.0},
+		{"negative bignum", "c349010000000000000000", -18446744073709551617.0},
+		{"decimal fraction", "c48221196ab3", 273.15},
+		{"unknown tag", "d82063666f6f", "foo"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			data, err := hex.DecodeString("81" + c.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := NewCBORReader(&dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray}, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ent, err := r.ReadEntry()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, ent.Value); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVWriterNumbers(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "csv",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "account", "type": "integer"},
+					map[string]interface{}{"title": "balance", "type": "number"},
+					map[string]interface{}{"title": "rate", "type": "number"},
+					map[string]interface{}{"title": "history", "type": "array"},
+					map[string]interface{}{"title": "updated", "type": "string"},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewCSVWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := []interface{}{
+		mustBigInt("12345678901234567890"),
+		json.Number("1000000000000.10"),
+		mustBigFloat("0.3"),
+		[]interface{}{mustBigFloat("0.1"), json.Number("2.50")},
+		time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC),
+	}
+	if err := w.WriteEntry(Entry{Value: row}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "12345678901234567890,1000000000000.10,0.3,\"[0.1,2.50]\",2013-03-21T20:04:00Z\n"
+	if buf.String() != expect {
+		t.Errorf("result mismatch.\nexpected: %q\ngot:      %q", expect, buf.String())
+	}
+}
+
+func TestDecimalText(t *testing.T) {
+	cases := []struct {
+		mantissa string
+		exp      int64
+		expect   string
+	}{
+		{"27315", -2, "273.15"},
+		{"-27315", -2, "-273.15"},
+		{"5", -3, "0.005"},
+		{"12", 3, "12000"},
+		{"7", 0, "7"},
+		{"1", 30, "1e+30"},
+		{"1", -30, "1e-30"},
+	}
+
+	for _, c := range cases {
+		if got := decimalText(mustBigInt(c.mantissa), c.exp); got != c.expect {
+			t.Errorf("%se%d mismatch. expected: %s, got: %s", c.mantissa, c.exp, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package dsio
+
+import (
+	"fmt"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+// derivedStructure creates the structure of a transformed stream of entries.
+// The format & format configuration of the source are kept, the schema is
+// replaced, and fields describing the source body are dropped
+func derivedStructure(st *dataset.Structure, schema map[string]interface{}) *dataset.Structure {
+	return &dataset.Structure{
+		Qri:          dataset.KindStructure.String(),
+		Format:       st.Format,
+		FormatConfig: st.FormatConfig,
+		Schema:       schema,
+	}
+}
+
+// FilterFunc reports if an entry should be kept
+type FilterFunc func(ent Entry) (bool, error)
+
+// FilterReader wraps a reader, skipping entries a predicate rejects. Entries
+// of arrays are re-indexed to count only kept entries
+type FilterReader struct {
+	reader EntryReader
+	keep   FilterFunc
+	st     *dataset.Structure
+	index  int
+}
+
+var _ EntryReader = (*FilterReader)(nil)
+
+// NewFilterReader creates a reader of the entries of r that keep returns true
+// for. The structure of a filtered reader has the schema of r
+func NewFilterReader(r EntryReader, keep FilterFunc) *FilterReader {
+	return &FilterReader{
+		reader: r,
+		keep:   keep,
+		st:     derivedStructure(r.Structure(), r.Structure().Schema),
+	}
+}
+
+// Structure gives the structure of filtered entries
+func (r *FilterReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads the next entry that passes the filter
+func (r *FilterReader) ReadEntry() (Entry, error) {
+	for {
+		ent, err := r.reader.ReadEntry()
+		if err != nil {
+			return ent, err
+		}
+		ok, err := r.keep(ent)
+		if err != nil {
+			return Entry{}, err
+		}
+		if ok {
+			if ent.Key == "" {
+				ent.Index = r.index
+			}
+			r.index++
+			return ent, nil
+		}
+	}
+}
+
+// Close closes the wrapped reader
+func (r *FilterReader) Close() error {
+	return r.reader.Close()
+}
+
+// MapFunc transforms an entry
+type MapFunc func(ent Entry) (Entry, error)
+
+// MapReader wraps a reader, transforming each entry with a function
+type MapReader struct {
+	reader EntryReader
+	fn     MapFunc
+	st     *dataset.Structure
+}
+
+var _ EntryReader = (*MapReader)(nil)
+
+// NewMapReader creates a reader of the entries of r transformed by fn. Mapped
+// entries are described by schema, which is the schema of r when nil
+func NewMapReader(r EntryReader, schema map[string]interface{}, fn MapFunc) *MapReader {
+	if schema == nil {
+		schema = r.Structure().Schema
+	}
+	return &MapReader{
+		reader: r,
+		fn:     fn,
+		st:     derivedStructure(r.Structure(), schema),
+	}
+}
+
+// Structure gives the structure of mapped entries
+func (r *MapReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads & transforms the next entry
+func (r *MapReader) ReadEntry() (Entry, error) {
+	ent, err := r.reader.ReadEntry()
+	if err != nil {
+		return ent, err
+	}
+	return r.fn(ent)
+}
+
+// Close closes the wrapped reader
+func (r *MapReader) Close() error {
+	return r.reader.Close()
+}
+
+// ProjectReader wraps a reader of tabular data, selecting & reordering the
+// columns of each row
+type ProjectReader struct {
+	reader  EntryReader
+	indexes []int
+	st      *dataset.Structure
+}
+
+var _ EntryReader = (*ProjectReader)(nil)
+
+// NewProjectReader creates a reader of rows with the named columns of r, in
+// the order given. Columns may be repeated
+func NewProjectReader(r EntryReader, columns []string) (*ProjectReader, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := columnIndexes(cols, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	projected := make(tabular.Columns, len(indexes))
+	for i, idx := range indexes {
+		projected[i] = cols[idx]
+	}
+
+	return &ProjectReader{
+		reader:  r,
+		indexes: indexes,
+		st:      derivedStructure(r.Structure(), projected.JSONSchema()),
+	}, nil
+}
+
+// columnIndexes finds the position of each named column
+func columnIndexes(cols tabular.Columns, names []string) ([]int, error) {
+	positions := make(map[string]int, len(cols))
+	for i := len(cols) - 1; i >= 0; i-- {
+		positions[cols[i].Title] = i
+	}
+
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		idx, ok := positions[name]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+		indexes[i] = idx
+	}
+	return indexes, nil
+}
+
+// Structure gives the structure of projected rows
+func (r *ProjectReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads the next row, keeping only projected columns. Columns past
+// the end of a short row are nil
+func (r *ProjectReader) ReadEntry() (Entry, error) {
+	ent, err := r.reader.ReadEntry()
+	if err != nil {
+		return ent, err
+	}
+	row, ok := ent.Value.([]interface{})
+	if !ok {
+		return Entry{}, fmt.Errorf("entry %d: expected array row, got %T", ent.Index, ent.Value)
+	}
+
+	projected := make([]interface{}, len(r.indexes))
+	for i, idx := range r.indexes {
+		if idx < len(row) {
+			projected[i] = row[idx]
+		}
+	}
+	ent.Value = projected
+	return ent, nil
+}
+
+// Close closes the wrapped reader
+func (r *ProjectReader) Close() error {
+	return r.reader.Close()
+}
+
+// RenameReader wraps a reader of tabular data, renaming columns. Entries are
+// read unchanged
+type RenameReader struct {
+	reader EntryReader
+	st     *dataset.Structure
+}
+
+var _ EntryReader = (*RenameReader)(nil)
+
+// NewRenameReader creates a reader of r with columns renamed, names maps
+// current column titles to new titles. Renaming can't give two columns the
+// same title
+func NewRenameReader(r EntryReader, names map[string]string) (*RenameReader, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make(tabular.Columns, len(cols))
+	copy(renamed, cols)
+	for from := range names {
+		if _, err := columnIndexes(cols, []string{from}); err != nil {
+			return nil, err
+		}
+	}
+	titles := map[string]bool{}
+	for i, col := range renamed {
+		if to, ok := names[col.Title]; ok {
+			renamed[i].Title = to
+		}
+		if titles[renamed[i].Title] {
+			return nil, fmt.Errorf("renaming columns gives duplicate column %q", renamed[i].Title)
+		}
+		titles[renamed[i].Title] = true
+	}
+
+	return &RenameReader{
+		reader: r,
+		st:     derivedStructure(r.Structure(), renamed.JSONSchema()),
+	}, nil
+}
+
+// Structure gives the structure with renamed columns
+func (r *RenameReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads the next entry
+func (r *RenameReader) ReadEntry() (Entry, error) {
+	return r.reader.ReadEntry()
+}
+
+// Close closes the wrapped reader
+func (r *RenameReader) Close() error {
+	return r.reader.Close()
+}
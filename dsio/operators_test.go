@@ -0,0 +1,130 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+var operatorsSchema = tabular.Columns{
+	{Title: "name", Type: &tabular.ColType{"string"}},
+	{Title: "year", Type: &tabular.ColType{"integer"}},
+	{Title: "rating", Type: &tabular.ColType{"number"}},
+}.JSONSchema()
+
+func operatorsReader(t *testing.T) EntryReader {
+	st := &dataset.Structure{Format: "csv", Schema: operatorsSchema}
+	r, err := NewCSVReader(st, strings.NewReader("alien,1979,8.5\nheat,1995,8.3\ncats,2019,2.8\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func readEntries(t *testing.T, r EntryReader) []Entry {
+	t.Helper()
+	ents := []Entry{}
+	for {
+		ent, err := r.ReadEntry()
+		if err == io.EOF {
+			return ents
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		ents = append(ents, ent)
+	}
+}
+
+func TestOperators(t *testing.T) {
+	var r EntryReader = NewFilterReader(operatorsReader(t), func(ent Entry) (bool, error) {
+		return ent.Value.([]interface{})[2].(float64) > 5, nil
+	})
+	r, err := NewProjectReader(r, []string{"rating", "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewRenameReader(r, map[string]string{"rating": "score"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = NewMapReader(r, nil, func(ent Entry) (Entry, error) {
+		row := ent.Value.([]interface{})
+		row[1] = strings.ToUpper(row[1].(string))
+		return ent, nil
+	})
+
+	expectSchema := tabular.Columns{
+		{Title: "score", Type: &tabular.ColType{"number"}},
+		{Title: "name", Type: &tabular.ColType{"string"}},
+	}.JSONSchema()
+	if diff := cmp.Diff(expectSchema, r.Structure().Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if r.Structure().Format != "csv" {
+		t.Errorf("expected derived structure to keep format. got: %q", r.Structure().Format)
+	}
+
+	expect := []Entry{
+		{Index: 0, Value: []interface{}{8.5, "ALIEN"}},
+		{Index: 1, Value: []interface{}{8.3, "HEAT"}},
+	}
+	if diff := cmp.Diff(expect, readEntries(t, r)); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMapReaderSchema(t *testing.T) {
+	schema := tabular.Columns{{Title: "label", Type: &tabular.ColType{"string"}}}.JSONSchema()
+	r := NewMapReader(operatorsReader(t), schema, func(ent Entry) (Entry, error) {
+		row := ent.Value.([]interface{})
+		ent.Value = []interface{}{fmt.Sprintf("%s (%d)", row[0], row[1])}
+		return ent, nil
+	})
+	if diff := cmp.Diff(schema, r.Structure().Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	expect := []Entry{
+		{Value: []interface{}{"alien (1979)"}},
+		{Value: []interface{}{"heat (1995)"}},
+		{Value: []interface{}{"cats (2019)"}},
+	}
+	if diff := cmp.Diff(expect, readEntries(t, r)); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFilterReaderError(t *testing.T) {
+	r := NewFilterReader(operatorsReader(t), func(ent Entry) (bool, error) {
+		return false, fmt.Errorf("oh noes")
+	})
+	if _, err := r.ReadEntry(); err == nil || err.Error() != "oh noes" {
+		t.Errorf("expected filter error. got: %v", err)
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+	if _, err := NewProjectReader(operatorsReader(t), []string{"name", "director"}); err == nil || err.Error() != `column "director" not found` {
+		t.Errorf("expected missing column error. got: %v", err)
+	}
+	if _, err := NewRenameReader(operatorsReader(t), map[string]string{"director": "by"}); err == nil || err.Error() != `column "director" not found` {
+		t.Errorf("expected missing column error. got: %v", err)
+	}
+	if _, err := NewRenameReader(operatorsReader(t), map[string]string{"year": "name"}); err == nil || err.Error() != `renaming columns gives duplicate column "name"` {
+		t.Errorf("expected duplicate column error. got: %v", err)
+	}
+
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := NewJSONReader(st, strings.NewReader(`[1]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewProjectReader(r, []string{"a"}); err == nil {
+		t.Errorf("expected projecting non-tabular data to fail")
+	}
+}
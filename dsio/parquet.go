@@ -0,0 +1,302 @@
+package dsio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetBatchSize is the number of rows read from each column at a time
+const parquetBatchSize = 1024
+
+// ParquetReader implements the EntryReader interface for the Parquet data
+// format. Only flat parquet schemas (no nested or repeated fields) are
+// supported, each row is read as an array entry. Parquet files keep their
+// metadata in a footer at the end of the file, so the entire read source is
+// buffered in memory
+type ParquetReader struct {
+	st       *dataset.Structure
+	pr       *reader.ParquetReader
+	cols     []*parquet.SchemaElement
+	numRows  int64
+	rowsRead int64
+	batch    [][]interface{} // column-major buffer of values
+	batchIdx int
+}
+
+var _ EntryReader = (*ParquetReader)(nil)
+
+// NewParquetReader creates a reader from a structure and read source
+func NewParquetReader(st *dataset.Structure, r io.Reader) (*ParquetReader, error) {
+	if st.Compression != "" && st.Archive == "" {
+		return nil, fmt.Errorf("parquet format does not support compression")
+	}
+
+	if st.Archive != "" {
+		mr, close, err := maybeWrapDecompressor(st, r)
+		if err != nil {
+			return nil, err
+		}
+		defer close()
+		r = mr
+	}
+
+	pr, err := openParquet(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := parquetColumns(pr)
+	if err != nil {
+		pr.ReadStop()
+		return nil, err
+	}
+
+	return &ParquetReader{
+		st:      st,
+		pr:      pr,
+		cols:    cols,
+		numRows: pr.GetNumRows(),
+	}, nil
+}
+
+// openParquet buffers a read source & opens a parquet column reader
+func openParquet(r io.Reader) (*reader.ParquetReader, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	pf, err := buffer.NewBufferFile(data)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		return nil, fmt.Errorf("reading parquet file: %w", err)
+	}
+	return pr, nil
+}
+
+// parquetColumns gives the schema elements for each column of a parquet file
+// in column order, erroring if the file has nested or repeated fields
+func parquetColumns(pr *reader.ParquetReader) ([]*parquet.SchemaElement, error) {
+	sh := pr.SchemaHandler
+	cols := make([]*parquet.SchemaElement, 0, len(sh.ValueColumns))
+	for _, path := range sh.ValueColumns {
+		el := sh.SchemaElements[sh.MapIndex[path]]
+		if len(common.StrToPath(path)) > 2 {
+			return nil, fmt.Errorf("nested parquet fields are not supported: %q", el.GetName())
+		}
+		if el.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+			return nil, fmt.Errorf("repeated parquet fields are not supported: %q", el.GetName())
+		}
+		cols = append(cols, el)
+	}
+	return cols, nil
+}
+
+// Structure gives this reader's structure
+func (r *ParquetReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one parquet row from the reader
+func (r *ParquetReader) ReadEntry() (Entry, error) {
+	if r.rowsRead >= r.numRows {
+		return Entry{}, io.EOF
+	}
+
+	if r.batch == nil || r.batchIdx >= len(r.batch[0]) {
+		if err := r.readBatch(); err != nil {
+			log.Debug(err.Error())
+			return Entry{}, err
+		}
+	}
+
+	row := make([]interface{}, len(r.cols))
+	for i, col := range r.cols {
+		v, err := parquetValue(r.batch[i][r.batchIdx], col)
+		if err != nil {
+			return Entry{}, fmt.Errorf("row %d, column %q: %w", r.rowsRead, col.GetName(), err)
+		}
+		row[i] = v
+	}
+
+	ent := Entry{Index: int(r.rowsRead), Value: row}
+	r.batchIdx++
+	r.rowsRead++
+	return ent, nil
+}
+
+func (r *ParquetReader) readBatch() error {
+	r.batch = make([][]interface{}, len(r.cols))
+	r.batchIdx = 0
+	for i := range r.cols {
+		values, _, _, err := r.pr.ReadColumnByIndex(int64(i), parquetBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		r.batch[i] = values
+	}
+	return nil
+}
+
+// parquetValue converts a value read from a parquet column to a go type
+// that other dsio writers understand
+func parquetValue(v interface{}, el *parquet.SchemaElement) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	isDecimal := el.ConvertedType != nil && *el.ConvertedType == parquet.ConvertedType_DECIMAL
+	switch x := v.(type) {
+	case bool, float64:
+		return x, nil
+	case float32:
+		return float64(x), nil
+	case int32:
+		if isDecimal {
+			return vals.ParseNumber([]byte(types.DECIMAL_INT_ToString(int64(x), int(el.GetPrecision()), int(el.GetScale()))))
+		}
+		return int64(x), nil
+	case int64:
+		if isDecimal {
+			return vals.ParseNumber([]byte(types.DECIMAL_INT_ToString(x, int(el.GetPrecision()), int(el.GetScale()))))
+		}
+		return x, nil
+	case string:
+		if el.GetType() == parquet.Type_INT96 {
+			return types.INT96ToTime(x).Format(time.RFC3339Nano), nil
+		}
+		if isDecimal {
+			return vals.ParseNumber([]byte(types.DECIMAL_BYTE_ARRAY_ToString([]byte(x), int(el.GetPrecision()), int(el.GetScale()))))
+		}
+		if el.ConvertedType != nil && *el.ConvertedType == parquet.ConvertedType_JSON {
+			var val interface{}
+			if err := json.Unmarshal([]byte(x), &val); err != nil {
+				return nil, err
+			}
+			return val, nil
+		}
+		return x, nil
+	default:
+		return nil, fmt.Errorf("unsupported parquet value type: %T", v)
+	}
+}
+
+// Close finalizes the reader
+func (r *ParquetReader) Close() error {
+	r.pr.ReadStop()
+	return nil
+}
+
+// parquetMetadata gives a parquet-go schema tag string for a column
+func parquetMetadata(title string, k colKind) string {
+	typ := map[colKind]string{
+		colString:  "type=BYTE_ARRAY, convertedtype=UTF8",
+		colInteger: "type=INT64",
+		colNumber:  "type=DOUBLE",
+		colBoolean: "type=BOOLEAN",
+		colJSON:    "type=BYTE_ARRAY, convertedtype=JSON",
+	}[k]
+	return fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", title, typ)
+}
+
+// ParquetWriter implements the EntryWriter interface for Parquet-formatted
+// data. Column types are derived from the structure's tabular schema
+type ParquetWriter struct {
+	st    *dataset.Structure
+	pw    *writer.CSVWriter
+	cols  tabular.Columns
+	kinds []colKind
+}
+
+var _ EntryWriter = (*ParquetWriter)(nil)
+
+// NewParquetWriter creates a Writer from a structure and write destination
+func NewParquetWriter(st *dataset.Structure, w io.Writer) (*ParquetWriter, error) {
+	if st.Compression != "" {
+		return nil, fmt.Errorf("parquet format does not support compression")
+	}
+	if st.Archive != "" {
+		return nil, errArchiveWrite
+	}
+
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("parquet writer requires at least one column")
+	}
+
+	kinds := make([]colKind, len(cols))
+	md := make([]string, len(cols))
+	for i, c := range cols {
+		if strings.ContainsAny(c.Title, ",=") {
+			return nil, fmt.Errorf("column %d title %q cannot be used as a parquet field name", i, c.Title)
+		}
+		kinds[i] = colKindFromColType(c.Type)
+		md[i] = parquetMetadata(c.Title, kinds[i])
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParquetWriter{
+		st:    st,
+		pw:    pw,
+		cols:  cols,
+		kinds: kinds,
+	}, nil
+}
+
+// Structure gives this writer's structure
+func (w *ParquetWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one parquet row to the writer
+func (w *ParquetWriter) WriteEntry(ent Entry) error {
+	arr, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value to write parquet row. got: %v", ent)
+	}
+	if len(arr) > len(w.cols) {
+		return fmt.Errorf("row has %d values, schema defines %d columns", len(arr), len(w.cols))
+	}
+
+	row := make([]interface{}, len(w.cols))
+	for i, v := range arr {
+		pv, err := colKindValue(v, w.kinds[i])
+		if err != nil {
+			return fmt.Errorf("column %q: %w", w.cols[i].Title, err)
+		}
+		row[i] = pv
+	}
+	return w.pw.Write(row)
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *ParquetWriter) Close() error {
+	return w.pw.WriteStop()
+}
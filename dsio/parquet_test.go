@@ -0,0 +1,136 @@
+package dsio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+var parquetSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "name", "type": "string"},
+			map[string]interface{}{"title": "count", "type": "integer"},
+			map[string]interface{}{"title": "ratio", "type": []interface{}{"number", "null"}},
+			map[string]interface{}{"title": "ok", "type": "boolean"},
+			map[string]interface{}{"title": "meta", "type": "object"},
+			map[string]interface{}{"title": "mixed", "type": []interface{}{"string", "integer"}},
+		},
+	},
+}
+
+func TestParquetReadWrite(t *testing.T) {
+	st := &dataset.Structure{Format: "parquet", Schema: parquetSchema}
+	entries := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}, int64(2)},
+		[]interface{}{"b", int64(2), nil, false, nil, "two"},
+		[]interface{}{nil, "3", int64(3), "true", map[string]interface{}{}},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range entries {
+		if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewEntryReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}, "2"},
+		[]interface{}{"b", int64(2), nil, false, nil, "two"},
+		[]interface{}{nil, int64(3), float64(3), true, map[string]interface{}{}, nil},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParquetManyRows(t *testing.T) {
+	st := &dataset.Structure{Format: "parquet", Schema: parquetSchema}
+	buf := &bytes.Buffer{}
+	w, err := NewParquetWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := parquetBatchSize*2 + 10
+	for i := 0; i < count; i++ {
+		if err := w.WriteEntry(Entry{Value: []interface{}{"x", i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewParquetReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	err = EachEntry(r, func(_ int, ent Entry, _ error) error {
+		if got := ent.Value.([]interface{})[1]; got != int64(i) {
+			t.Fatalf("row %d count mismatch. got: %v", i, got)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != count {
+		t.Errorf("row count mismatch. expected: %d, got: %d", count, i)
+	}
+}
+
+func TestParquetErrors(t *testing.T) {
+	compressed := &dataset.Structure{Format: "parquet", Compression: "gzip", Schema: parquetSchema}
+	if _, err := NewParquetReader(compressed, &bytes.Buffer{}); err == nil {
+		t.Error("expected compressed reader to error")
+	}
+	if _, err := NewParquetWriter(compressed, &bytes.Buffer{}); err == nil {
+		t.Error("expected compressed writer to error")
+	}
+	if _, err := NewParquetWriter(&dataset.Structure{Format: "parquet", Schema: dataset.BaseSchemaArray}, &bytes.Buffer{}); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+	if _, err := NewParquetReader(&dataset.Structure{Format: "parquet"}, bytes.NewBufferString("not a parquet file")); err == nil {
+		t.Error("expected invalid data to error")
+	}
+
+	st := &dataset.Structure{Format: "parquet", Schema: parquetSchema}
+	w, err := NewParquetWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: "not a row"}); err == nil {
+		t.Error("expected non-array entry to error")
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", "not a number"}}); err == nil {
+		t.Error("expected invalid integer to error")
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a", 1, 2, true, nil, nil, "extra"}}); err == nil {
+		t.Error("expected too many values to error")
+	}
+}
@@ -0,0 +1,185 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/qri-io/dataset"
+)
+
+// Format bundles everything the dataset packages need to work with a data
+// format. Register a Format with RegisterFormat to make it available to
+// NewEntryReader, NewEntryWriter, dataset.ParseFormatConfigMap and the detect
+// package
+type Format struct {
+	// Name is the string representation of the format, eg: "csv"
+	Name string
+	// Aliases are additional strings dataset.ParseDataFormatString accepts
+	// for the format
+	Aliases []string
+	// Extensions are the file extensions detect.FormatFromFilename maps to
+	// the format, including the leading ".", eg: ".csv"
+	Extensions []string
+	// Tabular is true for formats that require a tabular schema
+	Tabular bool
+	// ParseConfig creates the FormatConfig for the format from a map of
+	// options. optional
+	ParseConfig func(opts map[string]interface{}) (dataset.FormatConfig, error)
+	// NewReader creates an EntryReader for the format
+	NewReader func(st *dataset.Structure, r io.Reader) (EntryReader, error)
+	// NewWriter creates an EntryWriter for the format
+	NewWriter func(st *dataset.Structure, w io.Writer) (EntryWriter, error)
+	// DetectSchema determines a json schema from data in the format,
+	// returning the number of bytes read. optional
+	DetectSchema func(st *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error)
+}
+
+// formatPackages names the packages that implement data formats the dataset
+// package defines, but dsio leaves out to avoid their dependencies. Importing
+// a package registers it's format
+var formatPackages = map[dataset.DataFormat]string{
+	dataset.SQLiteDataFormat: "github.com/qri-io/dataset/dsio/sqlite",
+}
+
+var (
+	formatsLk  sync.RWMutex
+	formats    = map[dataset.DataFormat]Format{}
+	extensions = map[string]dataset.DataFormat{}
+)
+
+func init() {
+	builtin := map[dataset.DataFormat]Format{
+		dataset.CBORDataFormat: {
+			Extensions: []string{".cbor"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewCBORReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewCBORWriter(st, w) },
+		},
+		dataset.JSONDataFormat: {
+			Extensions: []string{".json"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewJSONReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewJSONWriter(st, w) },
+		},
+		dataset.CSVDataFormat: {
+			Extensions: []string{".csv"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewCSVReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewCSVWriter(st, w) },
+		},
+		dataset.XLSXDataFormat: {
+			Extensions: []string{".xlsx"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewXLSXReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewXLSXWriter(st, w) },
+		},
+		dataset.NDJSONDataFormat: {
+			Extensions: []string{".ndjson", ".jsonl"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewNDJSONReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewNDJSONWriter(st, w) },
+		},
+		dataset.XMLDataFormat: {
+			Extensions: []string{".xml"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewXMLReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewXMLWriter(st, w) },
+		},
+		dataset.ParquetDataFormat: {
+			Extensions: []string{".parquet"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewParquetReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewParquetWriter(st, w) },
+		},
+		dataset.ArrowDataFormat: {
+			Extensions: []string{".arrow", ".arrows", ".feather"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewArrowReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewArrowWriter(st, w) },
+		},
+		dataset.AvroDataFormat: {
+			Extensions: []string{".avro"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewAvroReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewAvroWriter(st, w) },
+		},
+		dataset.YAMLDataFormat: {
+			Extensions: []string{".yaml", ".yml"},
+			NewReader:  func(st *dataset.Structure, r io.Reader) (EntryReader, error) { return NewYAMLReader(st, r) },
+			NewWriter:  func(st *dataset.Structure, w io.Writer) (EntryWriter, error) { return NewYAMLWriter(st, w) },
+		},
+	}
+
+	for df, f := range builtin {
+		f.Name = df.String()
+		f.Tabular = (&dataset.Structure{Format: f.Name}).RequiresTabularSchema()
+		if err := registerFormat(df, f); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterFormat adds a data format to the dataset packages, returning the
+// DataFormat value assigned to it. Format names, aliases & extensions must be
+// unique, and both NewReader and NewWriter are required. Formats the dataset
+// package defines without an implementation, like sqlite, are implemented by
+// registering a Format with the same name, which keeps the DataFormat value,
+// aliases & ParseConfig of the dataset package
+func RegisterFormat(f Format) (dataset.DataFormat, error) {
+	if f.NewReader == nil || f.NewWriter == nil {
+		return dataset.UnknownDataFormat, fmt.Errorf("format %q requires both a reader and a writer", f.Name)
+	}
+
+	formatsLk.Lock()
+	defer formatsLk.Unlock()
+
+	for _, ext := range f.Extensions {
+		if _, taken := extensions[ext]; taken {
+			return dataset.UnknownDataFormat, fmt.Errorf("file extension %q is already registered", ext)
+		}
+	}
+
+	if df, err := dataset.ParseDataFormatString(f.Name); err == nil && df != dataset.UnknownDataFormat && df.String() == f.Name {
+		if _, ok := formats[df]; ok {
+			return dataset.UnknownDataFormat, fmt.Errorf("data format name %q is already registered", f.Name)
+		}
+		f.Tabular = (&dataset.Structure{Format: f.Name}).RequiresTabularSchema()
+		return df, registerFormat(df, f)
+	}
+
+	df, err := dataset.RegisterDataFormat(dataset.DataFormatDefinition{
+		Name:        f.Name,
+		Aliases:     f.Aliases,
+		ParseConfig: f.ParseConfig,
+		Tabular:     f.Tabular,
+	})
+	if err != nil {
+		return dataset.UnknownDataFormat, err
+	}
+
+	return df, registerFormat(df, f)
+}
+
+// registerFormat adds a format with a given data format value. callers must
+// hold a write lock on formatsLk, or be called from init
+func registerFormat(df dataset.DataFormat, f Format) error {
+	for _, ext := range f.Extensions {
+		if _, taken := extensions[ext]; taken {
+			return fmt.Errorf("file extension %q is already registered", ext)
+		}
+		extensions[ext] = df
+	}
+	formats[df] = f
+	return nil
+}
+
+// LookupFormat gives the registered Format for a data format. Aliases and
+// ParseConfig of formats built into the dataset packages are left to the
+// dataset package, and built-in schema detection lives in the detect package
+func LookupFormat(df dataset.DataFormat) (Format, bool) {
+	formatsLk.RLock()
+	defer formatsLk.RUnlock()
+	f, ok := formats[df]
+	return f, ok
+}
+
+// FormatFromExtension gives the data format registered for a file extension,
+// eg: ".csv"
+func FormatFromExtension(ext string) (dataset.DataFormat, bool) {
+	formatsLk.RLock()
+	defer formatsLk.RUnlock()
+	df, ok := extensions[ext]
+	return df, ok
+}
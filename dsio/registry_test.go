@@ -0,0 +1,117 @@
+package dsio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+// linesReader reads each line of a source as a string entry
+type linesReader struct {
+	st *dataset.Structure
+	sc *bufio.Scanner
+	i  int
+}
+
+func (r *linesReader) Structure() *dataset.Structure { return r.st }
+func (r *linesReader) Close() error                  { return nil }
+func (r *linesReader) ReadEntry() (Entry, error) {
+	if !r.sc.Scan() {
+		return Entry{}, io.EOF
+	}
+	ent := Entry{Index: r.i, Value: r.sc.Text()}
+	r.i++
+	return ent, nil
+}
+
+// linesWriter writes each entry value on its own line
+type linesWriter struct {
+	st *dataset.Structure
+	w  io.Writer
+}
+
+func (w *linesWriter) Structure() *dataset.Structure { return w.st }
+func (w *linesWriter) Close() error                  { return nil }
+func (w *linesWriter) WriteEntry(ent Entry) error {
+	_, err := fmt.Fprintln(w.w, ent.Value)
+	return err
+}
+
+var linesFormat = Format{
+	Name:       "test_lines",
+	Extensions: []string{".test_lines"},
+	NewReader: func(st *dataset.Structure, r io.Reader) (EntryReader, error) {
+		return &linesReader{st: st, sc: bufio.NewScanner(r)}, nil
+	},
+	NewWriter: func(st *dataset.Structure, w io.Writer) (EntryWriter, error) {
+		return &linesWriter{st: st, w: w}, nil
+	},
+}
+
+func TestRegisterFormat(t *testing.T) {
+	df, err := RegisterFormat(linesFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := FormatFromExtension(".test_lines"); !ok || got != df {
+		t.Errorf("extension lookup mismatch. expected: %d, got: %d", df, got)
+	}
+	if f, ok := LookupFormat(df); !ok || f.Name != "test_lines" {
+		t.Errorf("expected registered format to be found")
+	}
+
+	st := &dataset.Structure{Format: "test_lines", Schema: dataset.BaseSchemaArray}
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"a", "b"} {
+		if err := w.WriteEntry(Entry{Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewEntryReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{"a", "b"}, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	sqlite := &dataset.Structure{Format: "sqlite", Schema: dataset.BaseSchemaArray}
+	if _, err := NewEntryReader(sqlite, &bytes.Buffer{}); err == nil || err.Error() != `sqlite format isn't registered, import "github.com/qri-io/dataset/dsio/sqlite" to register it` {
+		t.Errorf("expected unregistered format error. got: %v", err)
+	}
+
+	if f, ok := LookupFormat(dataset.CSVDataFormat); !ok || f.Name != "csv" || !f.Tabular {
+		t.Errorf("expected built-in csv format. got: %#v", f)
+	}
+
+	bad := []struct {
+		f   Format
+		err string
+	}{
+		{Format{Name: "no_reader", NewWriter: linesFormat.NewWriter}, `format "no_reader" requires both a reader and a writer`},
+		{Format{Name: "dup_ext", Extensions: []string{".csv"}, NewReader: linesFormat.NewReader, NewWriter: linesFormat.NewWriter}, `file extension ".csv" is already registered`},
+		{Format{Name: "json", NewReader: linesFormat.NewReader, NewWriter: linesFormat.NewWriter}, `data format name "json" is already registered`},
+	}
+	for i, c := range bad {
+		if _, err := RegisterFormat(c.f); err == nil || err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package dsio
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// DefaultSortMemoryBudget is the number of bytes of entries a SortedReader
+// holds in memory before spilling sorted runs to temporary files
+const DefaultSortMemoryBudget = 64 << 20
+
+// sortItemOverhead estimates the memory an entry takes up beyond the size of
+// its JSON encoding
+const sortItemOverhead = 128
+
+func init() {
+	// values of spilled entries are encoded as interfaces, gob needs concrete
+	// types registered ahead of time
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register(json.Number(""))
+	gob.Register(&big.Int{})
+	gob.Register(&big.Float{})
+	gob.Register(time.Time{})
+}
+
+// SortKey is a value to order entries by. Exactly one of Column or Path must
+// be set
+type SortKey struct {
+	// Column is the title of a column of tabular data
+	Column string
+	// Path is a JSON pointer into the value of each entry, like "/a/0".
+	// Entries without a value at the path sort as null
+	Path string
+	// Descending reverses the order of the key
+	Descending bool
+}
+
+// SortedReader reads the entries of a reader in sorted order. Values of keys
+// are compared with vals.CompareTypeBytes, values of different types order
+// null, boolean, number, string, bytes, array then object. Ties are broken by
+// entry key and then by the canonical JSON encoding of the whole entry value,
+// so the same entries always sort the same way regardless of input order.
+// Entries of arrays are re-indexed in sorted order.
+// The whole input is read on the first call to ReadEntry. Input larger than
+// MemoryBudget is sorted in runs written to temporary files, which are merged
+// as entries are read & removed on Close
+type SortedReader struct {
+	// MemoryBudget is the approximate number of bytes of entries to hold in
+	// memory, set before the first call to ReadEntry. Defaults to
+	// DefaultSortMemoryBudget
+	MemoryBudget int64
+	// TempDir is the directory temporary files are written to, defaulting to
+	// the system temporary directory
+	TempDir string
+
+	reader EntryReader
+	keys   []SortKey
+	paths  [][]string
+	st     *dataset.Structure
+
+	sorted bool
+	runs   []*fileRun
+	merge  *runHeap
+	err    error
+	index  int
+}
+
+var _ EntryReader = (*SortedReader)(nil)
+
+// NewSortedReader creates a reader of the entries of r ordered by keys. With
+// no keys entries are ordered by their whole value
+func NewSortedReader(r EntryReader, keys []SortKey) (*SortedReader, error) {
+	var cols tabular.Columns
+	paths := make([][]string, len(keys))
+	for i, key := range keys {
+		switch {
+		case key.Column != "" && key.Path != "":
+			return nil, fmt.Errorf("sort key %d: only one of column or path may be set", i)
+		case key.Column != "":
+			if cols == nil {
+				var err error
+				if cols, _, err = tabular.ColumnsFromJSONSchema(r.Structure().Schema); err != nil {
+					return nil, err
+				}
+			}
+			idx, err := columnIndexes(cols, []string{key.Column})
+			if err != nil {
+				return nil, err
+			}
+			paths[i] = []string{strconv.Itoa(idx[0])}
+		case key.Path != "":
+			toks, err := dataset.ParseJSONPointer(key.Path)
+			if err != nil {
+				return nil, err
+			}
+			paths[i] = toks
+		default:
+			return nil, fmt.Errorf("sort key %d: one of column or path is required", i)
+		}
+	}
+
+	return &SortedReader{
+		MemoryBudget: DefaultSortMemoryBudget,
+		reader:       r,
+		keys:         keys,
+		paths:        paths,
+		st:           derivedStructure(r.Structure(), r.Structure().Schema),
+	}, nil
+}
+
+// Structure gives the structure of sorted entries
+func (r *SortedReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads the next entry in sorted order
+func (r *SortedReader) ReadEntry() (Entry, error) {
+	if !r.sorted {
+		r.sorted = true
+		if r.err = r.sort(); r.err != nil {
+			return Entry{}, r.err
+		}
+	}
+	if r.err != nil {
+		return Entry{}, r.err
+	}
+	if r.merge.Len() == 0 {
+		return Entry{}, io.EOF
+	}
+
+	head := r.merge.items[0]
+	ent := head.item.ent
+	next, err := head.run.next()
+	if err == io.EOF {
+		heap.Pop(r.merge)
+	} else if err != nil {
+		r.err = err
+		return Entry{}, err
+	} else {
+		head.item = next
+		heap.Fix(r.merge, 0)
+	}
+	if r.merge.err != nil {
+		r.err = r.merge.err
+		return Entry{}, r.err
+	}
+
+	if ent.Key == "" {
+		ent.Index = r.index
+	}
+	r.index++
+	return ent, nil
+}
+
+// Close closes the wrapped reader, removing any temporary files
+func (r *SortedReader) Close() error {
+	err := r.reader.Close()
+	for _, run := range r.runs {
+		if rmErr := run.remove(); rmErr != nil && err == nil {
+			err = rmErr
+		}
+	}
+	r.runs = nil
+	return err
+}
+
+// sort reads all input entries, spilling sorted runs that exceed the memory
+// budget, and prepares runs for merging
+func (r *SortedReader) sort() error {
+	var (
+		items []*sortItem
+		size  int64
+	)
+	for {
+		ent, err := r.reader.ReadEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		item, err := r.newSortItem(ent)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		size += item.size
+		if size > r.MemoryBudget {
+			if err := r.spill(items); err != nil {
+				return err
+			}
+			items, size = nil, 0
+		}
+	}
+
+	if err := r.sortItems(items); err != nil {
+		return err
+	}
+	r.merge = &runHeap{less: r.less}
+	runs := []sortRun{&memRun{items: items}}
+	for _, run := range r.runs {
+		if err := run.rewind(); err != nil {
+			return err
+		}
+		runs = append(runs, run)
+	}
+	for _, run := range runs {
+		item, err := run.next()
+		if err == io.EOF {
+			continue
+		} else if err != nil {
+			return err
+		}
+		heap.Push(r.merge, &runHead{item: item, run: run})
+	}
+	return r.merge.err
+}
+
+// spill writes a sorted run of items to a temporary file
+func (r *SortedReader) spill(items []*sortItem) error {
+	if err := r.sortItems(items); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(r.TempDir, "dsio-sort-")
+	if err != nil {
+		return err
+	}
+	run := &fileRun{f: f, newItem: r.newSortItem}
+	r.runs = append(r.runs, run)
+
+	buf := bufio.NewWriter(f)
+	enc := gob.NewEncoder(buf)
+	for _, item := range items {
+		if err := enc.Encode(&item.ent); err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("writing sorted run: %w", err)
+		}
+	}
+	return buf.Flush()
+}
+
+// sortItems sorts items in place
+func (r *SortedReader) sortItems(items []*sortItem) (err error) {
+	sort.SliceStable(items, func(i, j int) bool {
+		less, cmpErr := r.less(items[i], items[j])
+		if cmpErr != nil && err == nil {
+			err = cmpErr
+		}
+		return less
+	})
+	return err
+}
+
+// less reports if item a sorts before item b
+func (r *SortedReader) less(a, b *sortItem) (bool, error) {
+	for i, key := range r.keys {
+		c, err := compareSortValues(a.keys[i], b.keys[i])
+		if err != nil {
+			return false, err
+		}
+		if key.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0, nil
+		}
+	}
+	if len(r.keys) == 0 {
+		c, err := compareSortValues(newSortValue(a.ent.Value), newSortValue(b.ent.Value))
+		if err != nil || c != 0 {
+			return c < 0, err
+		}
+	}
+	if a.ent.Key != b.ent.Key {
+		return a.ent.Key < b.ent.Key, nil
+	}
+	return string(a.raw) < string(b.raw), nil
+}
+
+// newSortItem resolves the sort keys of an entry
+func (r *SortedReader) newSortItem(ent Entry) (*sortItem, error) {
+	raw, err := json.Marshal(ent.Value)
+	if err != nil {
+		return nil, fmt.Errorf("entry %d: %w", ent.Index, err)
+	}
+	item := &sortItem{
+		ent:  ent,
+		raw:  raw,
+		keys: make([]sortValue, len(r.paths)),
+		size: int64(len(raw)+len(ent.Key)) + sortItemOverhead,
+	}
+	for i, path := range r.paths {
+		item.keys[i] = newSortValue(valueAtPath(ent.Value, path))
+	}
+	return item, nil
+}
+
+// valueAtPath finds the value at a path of reference tokens, giving nil for
+// paths that don't exist
+func valueAtPath(v interface{}, path []string) interface{} {
+	for _, tok := range path {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			v = x[tok]
+		case []interface{}:
+			idx, err := strconv.Atoi(tok)
+			if err != nil || idx < 0 || idx >= len(x) {
+				return nil
+			}
+			v = x[idx]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// sortItem is an entry with resolved sort keys
+type sortItem struct {
+	ent  Entry
+	raw  []byte
+	keys []sortValue
+	size int64
+}
+
+// sortValue is a value in the form vals.CompareTypeBytes compares
+type sortValue struct {
+	t    vals.Type
+	data []byte
+	// exact marks numbers a float64 can't hold without losing precision,
+	// which compare as exact decimals instead
+	exact bool
+}
+
+// newSortValue converts a decoded value to a sort value
+func newSortValue(v interface{}) sortValue {
+	switch x := v.(type) {
+	case nil:
+		return sortValue{t: vals.TypeNull}
+	case bool:
+		return sortValue{t: vals.TypeBoolean, data: []byte(strconv.FormatBool(x))}
+	case int:
+		return sortValue{t: vals.TypeInteger, data: []byte(strconv.Itoa(x))}
+	case int8, int16, int32, int64:
+		return sortValue{t: vals.TypeInteger, data: []byte(fmt.Sprint(x))}
+	case uint8, uint16, uint32:
+		return sortValue{t: vals.TypeInteger, data: []byte(fmt.Sprint(x))}
+	case uint:
+		return newSortValue(uint64(x))
+	case uint64:
+		if x <= math.MaxInt64 {
+			return sortValue{t: vals.TypeInteger, data: []byte(strconv.FormatUint(x, 10))}
+		}
+		return sortValue{t: vals.TypeNumber, data: []byte(strconv.FormatUint(x, 10)), exact: true}
+	case float32:
+		return sortValue{t: vals.TypeNumber, data: []byte(strconv.FormatFloat(float64(x), 'g', -1, 32))}
+	case float64:
+		return sortValue{t: vals.TypeNumber, data: []byte(strconv.FormatFloat(x, 'g', -1, 64))}
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return sortValue{t: vals.TypeInteger, data: []byte(x)}
+		}
+		return sortValue{t: vals.TypeNumber, data: []byte(x), exact: true}
+	case *big.Int:
+		if x.IsInt64() {
+			return sortValue{t: vals.TypeInteger, data: []byte(x.String())}
+		}
+		return sortValue{t: vals.TypeNumber, data: []byte(x.String()), exact: true}
+	case *big.Float:
+		return sortValue{t: vals.TypeNumber, data: []byte(x.Text('g', -1)), exact: true}
+	case string:
+		return sortValue{t: vals.TypeString, data: []byte(x)}
+	case time.Time:
+		return sortValue{t: vals.TypeString, data: []byte(x.UTC().Format(time.RFC3339Nano))}
+	case []byte:
+		return sortValue{t: vals.TypeBytes, data: x}
+	case []interface{}:
+		data, _ := json.Marshal(x)
+		return sortValue{t: vals.TypeArray, data: data}
+	case map[string]interface{}:
+		data, _ := json.Marshal(x)
+		return sortValue{t: vals.TypeObject, data: data}
+	default:
+		return sortValue{t: vals.TypeString, data: []byte(fmt.Sprint(x))}
+	}
+}
+
+// CompareValues compares two decoded values in the order a SortedReader sorts
+// them, giving -1 when a sorts first, 1 when b sorts first & 0 when they're
+// equal
+func CompareValues(a, b interface{}) (int, error) {
+	return compareSortValues(newSortValue(a), newSortValue(b))
+}
+
+// sortTypeRanks orders values of different types
+var sortTypeRanks = map[vals.Type]int{
+	vals.TypeNull:    0,
+	vals.TypeBoolean: 1,
+	vals.TypeInteger: 2,
+	vals.TypeNumber:  2,
+	vals.TypeString:  3,
+	vals.TypeBytes:   4,
+	vals.TypeArray:   5,
+	vals.TypeObject:  6,
+}
+
+// compareSortValues compares two sort values. Integers & numbers compare as
+// numbers
+func compareSortValues(a, b sortValue) (int, error) {
+	t := a.t
+	if a.t != b.t {
+		ar, br := sortTypeRanks[a.t], sortTypeRanks[b.t]
+		if ar != br {
+			if ar < br {
+				return -1, nil
+			}
+			return 1, nil
+		}
+		t = vals.TypeNumber
+	}
+	if t == vals.TypeNumber && (a.exact || b.exact || a.t != b.t) {
+		// compare exactly so large integers & mixed integer, float pairs
+		// don't collapse to the same float64
+		if ar, br := a.rat(), b.rat(); ar != nil && br != nil {
+			return ar.Cmp(br), nil
+		}
+	}
+	return vals.CompareTypeBytes(a.data, b.data, t)
+}
+
+// rat gives the exact value of a numeric sort value, or nil for values that
+// aren't finite numbers
+func (v sortValue) rat() *big.Rat {
+	if v.exact || v.t == vals.TypeInteger {
+		r, ok := new(big.Rat).SetString(string(v.data))
+		if !ok {
+			return nil
+		}
+		return r
+	}
+	f, err := strconv.ParseFloat(string(v.data), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil
+	}
+	return new(big.Rat).SetFloat64(f)
+}
+
+// sortRun is a sorted sequence of items
+type sortRun interface {
+	next() (*sortItem, error)
+}
+
+// memRun is a sorted run held in memory
+type memRun struct {
+	items []*sortItem
+}
+
+func (m *memRun) next() (*sortItem, error) {
+	if len(m.items) == 0 {
+		return nil, io.EOF
+	}
+	item := m.items[0]
+	m.items[0] = nil
+	m.items = m.items[1:]
+	return item, nil
+}
+
+// fileRun is a sorted run spilled to a temporary file
+type fileRun struct {
+	f       *os.File
+	dec     *gob.Decoder
+	newItem func(Entry) (*sortItem, error)
+}
+
+// rewind prepares a written run for reading
+func (f *fileRun) rewind() error {
+	if _, err := f.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f.dec = gob.NewDecoder(bufio.NewReader(f.f))
+	return nil
+}
+
+func (f *fileRun) next() (*sortItem, error) {
+	ent := Entry{}
+	if err := f.dec.Decode(&ent); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("reading sorted run: %w", err)
+	}
+	return f.newItem(ent)
+}
+
+// remove closes & deletes the temporary file
+func (f *fileRun) remove() error {
+	f.f.Close()
+	return os.Remove(f.f.Name())
+}
+
+// runHead is the next item of a run
+type runHead struct {
+	item *sortItem
+	run  sortRun
+}
+
+// runHeap merges runs, implementing heap.Interface. Comparison errors are kept
+// in err
+type runHeap struct {
+	items []*runHead
+	less  func(a, b *sortItem) (bool, error)
+	err   error
+}
+
+func (h *runHeap) Len() int      { return len(h.items) }
+func (h *runHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *runHeap) Less(i, j int) bool {
+	less, err := h.less(h.items[i].item, h.items[j].item)
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	return less
+}
+func (h *runHeap) Push(x interface{}) { h.items = append(h.items, x.(*runHead)) }
+func (h *runHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,271 @@
+package dsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func sortJSONReader(t *testing.T, data string) EntryReader {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := NewJSONReader(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSortedReader(t *testing.T) {
+	films := "alien,1979,8.5\nheat,1995,8.3\ncats,2019,2.8\nbrazil,1985,8.3\nup,2009,8.3\n"
+	cases := []struct {
+		description string
+		data        string
+		keys        []SortKey
+		expect      []interface{}
+	}{
+		{"one column", films, []SortKey{{Column: "year"}},
+			[]interface{}{"alien", "brazil", "heat", "up", "cats"}},
+		{"descending column", films, []SortKey{{Column: "name", Descending: true}},
+			[]interface{}{"up", "heat", "cats", "brazil", "alien"}},
+		{"multiple columns", films, []SortKey{{Column: "rating", Descending: true}, {Column: "year"}},
+			[]interface{}{"alien", "brazil", "heat", "up", "cats"}},
+		{"path", films, []SortKey{{Path: "/2"}, {Path: "/0", Descending: true}},
+			[]interface{}{"cats", "up", "heat", "brazil", "alien"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			st := &dataset.Structure{Format: "csv", Schema: operatorsSchema}
+			cr, err := NewCSVReader(st, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := NewSortedReader(cr, c.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []interface{}{}
+			for i, ent := range readEntries(t, r) {
+				if ent.Index != i {
+					t.Errorf("entry %d: expected re-indexed entry. got index: %d", i, ent.Index)
+				}
+				got = append(got, ent.Value.([]interface{})[0])
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("order mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSortedReaderMixedTypes(t *testing.T) {
+	r, err := NewSortedReader(sortJSONReader(t, `[{"a":"x"},{"a":2.5},{},{"a":true},{"a":[1]},{"a":2},{"a":{"b":1}},{"a":false},{"a":null}]`), []SortKey{{Path: "/a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Entry{
+		{Index: 0, Value: map[string]interface{}{"a": nil}},
+		{Index: 1, Value: map[string]interface{}{}},
+		{Index: 2, Value: map[string]interface{}{"a": false}},
+		{Index: 3, Value: map[string]interface{}{"a": true}},
+		{Index: 4, Value: map[string]interface{}{"a": int64(2)}},
+		{Index: 5, Value: map[string]interface{}{"a": 2.5}},
+		{Index: 6, Value: map[string]interface{}{"a": "x"}},
+		{Index: 7, Value: map[string]interface{}{"a": []interface{}{int64(1)}}},
+		{Index: 8, Value: map[string]interface{}{"a": map[string]interface{}{"b": int64(1)}}},
+	}
+	if diff := cmp.Diff(expect, readEntries(t, r)); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSortedReaderSpill(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewSortedReader(sortJSONReader(t, `[5, 3, null, 9, 1, "a", 7, 2, 8, 3, 6, 4, 0]`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MemoryBudget = 3 * sortItemOverhead
+	r.TempDir = dir
+
+	first, err := r.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Errorf("expected 4 spilled runs. got: %d", len(files))
+	}
+
+	got := []interface{}{first.Value}
+	for _, ent := range readEntries(t, r) {
+		got = append(got, ent.Value)
+	}
+	expect := []interface{}{nil, int64(0), int64(1), int64(2), int64(3), int64(3), int64(4), int64(5), int64(6), int64(7), int64(8), int64(9), "a"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("order mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ = ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected close to remove spilled runs. got %d files", len(files))
+	}
+}
+
+func TestSortedReaderDeterministic(t *testing.T) {
+	inputs := []string{
+		`[{"k":1,"v":"b"},{"k":0,"v":"z"},{"k":1,"v":"a"},{"k":1,"v":[2]}]`,
+		`[{"k":1,"v":[2]},{"k":1,"v":"a"},{"k":0,"v":"z"},{"k":1,"v":"b"}]`,
+	}
+	bodies := []string{}
+	for _, budget := range []int64{DefaultSortMemoryBudget, 1} {
+		for _, data := range inputs {
+			r, err := NewSortedReader(sortJSONReader(t, data), []SortKey{{Path: "/k"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.MemoryBudget = budget
+			r.TempDir = t.TempDir()
+
+			buf := &bytes.Buffer{}
+			w, err := NewJSONWriter(&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Copy(r, w); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+			bodies = append(bodies, buf.String())
+		}
+	}
+
+	expect := `[{"k":0,"v":"z"},{"k":1,"v":"a"},{"k":1,"v":"b"},{"k":1,"v":[2]}]`
+	for i, body := range bodies {
+		if body != expect {
+			t.Errorf("body %d mismatch. expected: %s, got: %s", i, expect, body)
+		}
+	}
+}
+
+func TestSortedReaderObject(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaObject}
+	jr, err := NewJSONReader(st, strings.NewReader(`{"b": 1, "c": 0, "a": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewSortedReader(jr, []SortKey{{Path: ""}})
+	if err == nil {
+		t.Fatal("expected an empty path to be rejected")
+	}
+	if r, err = NewSortedReader(jr, nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := []Entry{
+		{Key: "c", Value: int64(0)},
+		{Key: "a", Value: int64(1)},
+		{Key: "b", Value: int64(1)},
+	}
+	if diff := cmp.Diff(expect, readEntries(t, r)); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSortedReaderErrors(t *testing.T) {
+	cases := []struct {
+		keys []SortKey
+		err  string
+	}{
+		{[]SortKey{{}}, "sort key 0: one of column or path is required"},
+		{[]SortKey{{Column: "name", Path: "/0"}}, "sort key 0: only one of column or path may be set"},
+		{[]SortKey{{Column: "director"}}, `column "director" not found`},
+		{[]SortKey{{Path: "a"}}, `json pointer "a" must start with '/'`},
+	}
+	for i, c := range cases {
+		_, err := NewSortedReader(operatorsReader(t), c.keys)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %q", i, c.err, err)
+		}
+	}
+
+	if _, err := NewSortedReader(sortJSONReader(t, `[1]`), []SortKey{{Column: "a"}}); err == nil {
+		t.Errorf("expected sorting non-tabular data by column to fail")
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	cases := []struct {
+		a, b   interface{}
+		expect int
+	}{
+		{nil, false, -1},
+		{int64(2), 2.0, 0},
+		{json.Number("10"), 9.5, 1},
+		{"b", "a", 1},
+		{"1", int64(2), 1},
+		{[]interface{}{int64(1)}, map[string]interface{}{}, -1},
+		{json.Number("12345678901234567890"), json.Number("12345678901234567891"), -1},
+		{bigInt("12345678901234567891"), bigInt("12345678901234567890"), 1},
+		{bigInt("12345678901234567890"), json.Number("1.2345678901234567890e19"), 0},
+		{uint64(18014398509481985), uint64(18014398509481984), 1},
+		{uint64(12345678901234567891), json.Number("12345678901234567890"), 1},
+		{int64(9007199254740993), 9007199254740992.0, 1},
+		{big.NewFloat(0.5), json.Number("0.5"), 0},
+	}
+	for i, c := range cases {
+		got, err := CompareValues(c.a, c.b)
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if got != c.expect {
+			t.Errorf("case %d: expected: %d, got: %d", i, c.expect, got)
+		}
+	}
+}
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
+
+func TestSortedReaderExactNumbers(t *testing.T) {
+	for _, mode := range []string{"decimal", "big"} {
+		st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"numbers": mode}}
+		jr, err := NewJSONReader(st, strings.NewReader(`[12345678901234567891, 12345678901234567890, 1.2345678901234567892e19]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := NewSortedReader(jr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, ent := range readEntries(t, r) {
+			got = append(got, fmt.Sprint(ent.Value))
+		}
+		expect := []string{"12345678901234567890", "12345678901234567891", "1.2345678901234567892e19"}
+		if mode == "big" {
+			expect[2] = "1.2345678901234567892e+19"
+		}
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("%s numbers order mismatch (-want +got):\n%s", mode, diff)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+// Package sqlite implements the sqlite data format, reading & writing tables
+// of SQLite database files. SQLite is a C library, so the format lives apart
+// from the dsio package to keep cgo out of programs that don't need it.
+// Importing this package registers the format with dsio:
+//
+//	import _ "github.com/qri-io/dataset/dsio/sqlite"
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	logger "github.com/ipfs/go-log"
+	// register the sqlite3 database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
+)
+
+var log = logger.Logger("sqlite")
+
+func init() {
+	_, err := dsio.RegisterFormat(dsio.Format{
+		Name:         dataset.SQLiteDataFormat.String(),
+		Extensions:   []string{".sqlite", ".sqlite3", ".db"},
+		NewReader:    func(st *dataset.Structure, r io.Reader) (dsio.EntryReader, error) { return NewReader(st, r) },
+		NewWriter:    func(st *dataset.Structure, w io.Writer) (dsio.EntryWriter, error) { return NewWriter(st, w) },
+		DetectSchema: DetectSchema,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// defaultSQLiteTable is the name of the table the sqlite writer creates when
+// no table is configured
+const defaultSQLiteTable = "body"
+
+// Reader implements the dsio.EntryReader interface for SQLite database files.
+// Rows of the table or query named by the structure's SQLiteOptions are
+// streamed as array entries. SQLite needs random access to a database, so
+// read sources that aren't uncompressed files are copied to a temporary file
+type Reader struct {
+	st       *dataset.Structure
+	db       *sql.DB
+	rows     *sql.Rows
+	names    []string
+	decls    []string
+	kinds    []string
+	rowsRead int
+	tmpPath  string
+	close    func() error // close func from wrapping
+}
+
+var _ dsio.EntryReader = (*Reader)(nil)
+
+// NewReader creates a reader from a structure and read source
+func NewReader(st *dataset.Structure, r io.Reader) (*Reader, error) {
+	opts := &dataset.SQLiteOptions{}
+	if st.FormatConfig != nil {
+		var err error
+		if opts, err = dataset.NewSQLiteOptions(st.FormatConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	r, close, err := dsio.Decompress(st, r)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &Reader{st: st, close: close}
+	path := ""
+	if f, ok := r.(*os.File); ok {
+		path = f.Name()
+	} else {
+		if sr.tmpPath, err = copyToTempFile(r); err != nil {
+			sr.Close()
+			return nil, err
+		}
+		path = sr.tmpPath
+	}
+
+	if sr.db, err = sql.Open("sqlite3", fileURI(path, "ro")); err != nil {
+		sr.Close()
+		return nil, err
+	}
+
+	query := opts.Query
+	if query == "" {
+		table := opts.Table
+		if table == "" {
+			if table, err = sqliteOnlyTable(sr.db); err != nil {
+				sr.Close()
+				return nil, err
+			}
+		}
+		query = fmt.Sprintf("SELECT * FROM %s", sqliteQuoteIdent(table))
+	}
+
+	if sr.rows, err = sr.db.Query(query); err != nil {
+		sr.Close()
+		return nil, fmt.Errorf("reading sqlite database: %w", err)
+	}
+	types, err := sr.rows.ColumnTypes()
+	if err != nil {
+		sr.Close()
+		return nil, err
+	}
+	sr.names = make([]string, len(types))
+	sr.decls = make([]string, len(types))
+	for i, t := range types {
+		sr.names[i] = t.Name()
+		sr.decls[i] = t.DatabaseTypeName()
+	}
+
+	// prefer column types from a provided schema, falling back to declared types
+	sr.kinds = make([]string, len(types))
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	for i := range sr.kinds {
+		if err == nil && len(cols) == len(types) {
+			sr.kinds[i] = dsio.ColumnStorageType(cols[i].Type)
+		} else {
+			ct := sqliteColType(sr.decls[i])
+			sr.kinds[i] = dsio.ColumnStorageType(&ct)
+		}
+	}
+
+	return sr, nil
+}
+
+// copyToTempFile writes a reader to a new temporary file, returning the path
+func copyToTempFile(r io.Reader) (string, error) {
+	f, err := ioutil.TempFile("", "qri-sqlite-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// fileURI gives the sqlite URI filename of a database path, opened with a
+// sqlite access mode. Paths are escaped, so they can contain "?" and "#"
+func fileURI(path, mode string) string {
+	u := url.URL{Scheme: "file", Path: path, RawQuery: "mode=" + mode}
+	if !strings.HasPrefix(path, "/") {
+		// relative paths have no authority
+		u.Opaque = url.PathEscape(path)
+	}
+	return u.String()
+}
+
+// DetectSchema determines a tabular json schema from the declared column
+// types of the table or query named in a sqlite structure's format config
+func DetectSchema(st *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	r, err := NewReader(st, tr)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, tr.BytesRead(), fmt.Errorf("invalid sqlite data: %s", err.Error())
+	}
+	defer r.Close()
+
+	return r.Schema(), tr.BytesRead(), nil
+}
+
+// sqliteOnlyTable gives the name of the only table in a database, erroring if
+// there isn't exactly one table
+func sqliteOnlyTable(db *sql.DB) (string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return "", fmt.Errorf("reading sqlite database: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(tables) != 1 {
+		return "", fmt.Errorf("sqlite format config must name a table or query, database has %d tables", len(tables))
+	}
+	return tables[0], nil
+}
+
+// sqliteQuoteIdent quotes a table or column name for use in a statement
+func sqliteQuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Schema gives a tabular json schema derived from the declared types of the
+// table or query columns being read
+func (r *Reader) Schema() map[string]interface{} {
+	items := make([]interface{}, len(r.names))
+	for i, name := range r.names {
+		ct := sqliteColType(r.decls[i])
+		var typ interface{} = ct[0]
+		if len(ct) > 1 {
+			types := make([]interface{}, len(ct))
+			for j, t := range ct {
+				types[j] = t
+			}
+			typ = types
+		}
+		items[i] = map[string]interface{}{
+			"title": name,
+			"type":  typ,
+		}
+	}
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}
+}
+
+// Structure gives this reader's structure
+func (r *Reader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one sqlite row from the reader
+func (r *Reader) ReadEntry() (dsio.Entry, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			log.Debug(err.Error())
+			return dsio.Entry{}, err
+		}
+		return dsio.Entry{}, io.EOF
+	}
+
+	row := make([]interface{}, len(r.names))
+	ptrs := make([]interface{}, len(row))
+	for i := range row {
+		ptrs[i] = &row[i]
+	}
+	if err := r.rows.Scan(ptrs...); err != nil {
+		log.Debug(err.Error())
+		return dsio.Entry{}, err
+	}
+	for i, v := range row {
+		val, err := sqliteValue(v, r.kinds[i])
+		if err != nil {
+			return dsio.Entry{}, fmt.Errorf("row %d, column %q: %w", r.rowsRead, r.names[i], err)
+		}
+		row[i] = val
+	}
+
+	ent := dsio.Entry{Index: r.rowsRead, Value: row}
+	r.rowsRead++
+	return ent, nil
+}
+
+// sqliteValue converts a value scanned from a sqlite row to a go type that
+// other dsio writers understand
+func sqliteValue(v interface{}, kind string) (interface{}, error) {
+	switch x := v.(type) {
+	case []byte:
+		v = string(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano), nil
+	}
+
+	switch kind {
+	case "boolean":
+		if i, ok := v.(int64); ok {
+			return i != 0, nil
+		}
+	case "json":
+		if s, ok := v.(string); ok {
+			var val interface{}
+			if err := json.Unmarshal([]byte(s), &val); err != nil {
+				return nil, err
+			}
+			return val, nil
+		}
+	}
+	return v, nil
+}
+
+// Close finalizes the reader
+func (r *Reader) Close() error {
+	if r.rows != nil {
+		r.rows.Close()
+	}
+	if r.db != nil {
+		r.db.Close()
+	}
+	if r.tmpPath != "" {
+		os.Remove(r.tmpPath)
+	}
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+// sqliteColType maps a declared sqlite column type to a tabular column type,
+// following sqlite's column affinity rules with additions for the BOOLEAN and
+// JSON type names
+func sqliteColType(decl string) tabular.ColType {
+	d := strings.ToUpper(decl)
+	has := func(subs ...string) bool {
+		for _, s := range subs {
+			if strings.Contains(d, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("BOOL"):
+		return tabular.ColType{"boolean"}
+	case has("INT"):
+		return tabular.ColType{"integer"}
+	case has("JSON"):
+		return tabular.ColType{"object", "array"}
+	case has("CHAR", "CLOB", "TEXT", "DATE", "TIME"):
+		return tabular.ColType{"string"}
+	case has("REAL", "FLOA", "DOUB", "NUM", "DEC"):
+		return tabular.ColType{"number"}
+	default:
+		return tabular.ColType{"string"}
+	}
+}
+
+// Writer implements the dsio.EntryWriter interface for SQLite database files.
+// A table named by the structure's SQLiteOptions is created from the tabular
+// schema & rows are inserted into it. The database is built in a temporary
+// file that is copied to the write destination on Close
+type Writer struct {
+	st      *dataset.Structure
+	w       io.Writer
+	cols    tabular.Columns
+	kinds   []string
+	tmpPath string
+	db      *sql.DB
+	tx      *sql.Tx
+	insert  *sql.Stmt
+}
+
+var _ dsio.EntryWriter = (*Writer)(nil)
+
+// NewWriter creates a Writer from a structure and write destination
+func NewWriter(st *dataset.Structure, w io.Writer) (*Writer, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("sqlite writer requires at least one column")
+	}
+
+	opts := &dataset.SQLiteOptions{}
+	if st.FormatConfig != nil {
+		if opts, err = dataset.NewSQLiteOptions(st.FormatConfig); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Query != "" {
+		return nil, fmt.Errorf("sqlite writer cannot write to a query")
+	}
+	table := opts.Table
+	if table == "" {
+		table = defaultSQLiteTable
+	}
+
+	f, err := ioutil.TempFile("", "qri-sqlite-*")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	sw := &Writer{
+		st:      st,
+		w:       w,
+		cols:    cols,
+		kinds:   make([]string, len(cols)),
+		tmpPath: f.Name(),
+	}
+
+	defs := make([]string, len(cols))
+	params := make([]string, len(cols))
+	for i, c := range cols {
+		sw.kinds[i] = dsio.ColumnStorageType(c.Type)
+		defs[i] = fmt.Sprintf("%s %s", sqliteQuoteIdent(c.Title), sqliteDeclType(sw.kinds[i]))
+		params[i] = "?"
+	}
+
+	if sw.db, err = sql.Open("sqlite3", fileURI(sw.tmpPath, "rwc")); err != nil {
+		sw.cleanup()
+		return nil, err
+	}
+	create := fmt.Sprintf("CREATE TABLE %s (%s)", sqliteQuoteIdent(table), strings.Join(defs, ", "))
+	if _, err = sw.db.Exec(create); err != nil {
+		sw.cleanup()
+		return nil, err
+	}
+	if sw.tx, err = sw.db.Begin(); err != nil {
+		sw.cleanup()
+		return nil, err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s VALUES (%s)", sqliteQuoteIdent(table), strings.Join(params, ", "))
+	if sw.insert, err = sw.tx.Prepare(insert); err != nil {
+		sw.cleanup()
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+// sqliteDeclType gives the declared sqlite column type for a column storage
+// type
+func sqliteDeclType(kind string) string {
+	switch kind {
+	case "integer":
+		return "INTEGER"
+	case "number":
+		return "REAL"
+	case "boolean":
+		return "BOOLEAN"
+	case "json":
+		return "JSON"
+	default:
+		return "TEXT"
+	}
+}
+
+// Structure gives this writer's structure
+func (w *Writer) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry inserts one row into the sqlite table
+func (w *Writer) WriteEntry(ent dsio.Entry) error {
+	arr, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value to write sqlite row. got: %v", ent)
+	}
+	if len(arr) > len(w.cols) {
+		return fmt.Errorf("row has %d values, schema defines %d columns", len(arr), len(w.cols))
+	}
+
+	row := make([]interface{}, len(w.cols))
+	for i, v := range arr {
+		sv, err := dsio.ColumnValue(v, w.cols[i].Type)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", w.cols[i].Title, err)
+		}
+		row[i] = sv
+	}
+	_, err := w.insert.Exec(row...)
+	return err
+}
+
+// Close finalizes the writer, committing inserted rows & copying the database
+// to the write destination
+func (w *Writer) Close() error {
+	defer w.cleanup()
+
+	w.insert.Close()
+	if err := w.tx.Commit(); err != nil {
+		return err
+	}
+	if err := w.db.Close(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(w.tmpPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dst, close, err := dsio.Compress(w.st, w.w)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, f); err != nil {
+		return err
+	}
+	if close != nil {
+		return close()
+	}
+	return nil
+}
+
+// cleanup closes the database & removes the temporary file
+func (w *Writer) cleanup() {
+	if w.db != nil {
+		w.db.Close()
+	}
+	os.Remove(w.tmpPath)
+}
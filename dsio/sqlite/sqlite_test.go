@@ -0,0 +1,277 @@
+package sqlite
+
+import (
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsio"
+)
+
+var sqliteTestSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "name", "type": "string"},
+			map[string]interface{}{"title": "count", "type": "integer"},
+			map[string]interface{}{"title": "ratio", "type": []interface{}{"number", "null"}},
+			map[string]interface{}{"title": "ok", "type": "boolean"},
+			map[string]interface{}{"title": "meta", "type": "object"},
+		},
+	},
+}
+
+func TestSQLiteReadWrite(t *testing.T) {
+	entries := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}},
+		[]interface{}{"b", int64(2), nil, false, nil},
+		[]interface{}{nil, "3", int64(3), "true"},
+	}
+	expect := []interface{}{
+		[]interface{}{"a", int64(1), 1.5, true, map[string]interface{}{"a": "b"}},
+		[]interface{}{"b", int64(2), nil, false, nil},
+		[]interface{}{nil, int64(3), float64(3), true, nil},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+	}{
+		{"default table", &dataset.Structure{Format: "sqlite", Schema: sqliteTestSchema}},
+		{"named table", &dataset.Structure{Format: "sqlite", Schema: sqliteTestSchema, FormatConfig: map[string]interface{}{"table": "my table"}}},
+		{"gzip", &dataset.Structure{Format: "sqlite", Compression: "gzip", Schema: sqliteTestSchema}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := dsio.NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range entries {
+				if err := w.WriteEntry(dsio.Entry{Index: i, Value: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := dsio.NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dsio.ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSQLiteReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// URI characters in paths must be escaped
+	path := filepath.Join(dir, "test?#1.db")
+	db, err := sql.Open("sqlite3", fileURI(path, "rwc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE cities (name VARCHAR(50), pop BIGINT, area DECIMAL(10,2), founded DATE, extra BLOB);
+		CREATE TABLE other (a TEXT);
+		INSERT INTO cities VALUES ('toronto', 2800000, 630.2, '1834-03-06', x'6869');
+		INSERT INTO cities VALUES ('new york', 8400000, 783.8, NULL, NULL);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := NewReader(&dataset.Structure{Format: "sqlite"}, f); err == nil {
+		t.Error("expected database with multiple tables and no table name to error")
+	}
+
+	st := &dataset.Structure{Format: "sqlite", FormatConfig: map[string]interface{}{"table": "cities"}}
+	r, err := NewReader(st, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+				map[string]interface{}{"title": "area", "type": "number"},
+				map[string]interface{}{"title": "founded", "type": "string"},
+				map[string]interface{}{"title": "extra", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSchema, r.Schema()); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	got, err := dsio.ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	expect := []interface{}{
+		[]interface{}{"toronto", int64(2800000), 630.2, "1834-03-06T00:00:00Z", "hi"},
+		[]interface{}{"new york", int64(8400000), 783.8, nil, nil},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	st = &dataset.Structure{Format: "sqlite", FormatConfig: map[string]interface{}{"query": "SELECT name, pop / 1000000 AS millions FROM cities ORDER BY pop DESC"}}
+	r, err = NewReader(st, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = dsio.ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	expect = []interface{}{
+		[]interface{}{"new york", int64(8)},
+		[]interface{}{"toronto", int64(2)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("query result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSQLiteErrors(t *testing.T) {
+	if _, err := NewWriter(&dataset.Structure{Format: "sqlite", Schema: dataset.BaseSchemaArray}, &bytes.Buffer{}); err == nil {
+		t.Error("expected non-tabular schema to error")
+	}
+	if _, err := NewWriter(&dataset.Structure{Format: "sqlite", Schema: sqliteTestSchema, FormatConfig: map[string]interface{}{"query": "SELECT 1"}}, &bytes.Buffer{}); err == nil {
+		t.Error("expected writing to a query to error")
+	}
+	if _, err := NewReader(&dataset.Structure{Format: "sqlite", FormatConfig: map[string]interface{}{"table": 1}}, &bytes.Buffer{}); err == nil {
+		t.Error("expected invalid format config to error")
+	}
+	if _, err := NewReader(&dataset.Structure{Format: "sqlite"}, bytes.NewBufferString("not a sqlite database")); err == nil {
+		t.Error("expected invalid data to error")
+	}
+
+	st := &dataset.Structure{Format: "sqlite", Schema: sqliteTestSchema}
+	w, err := NewWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.WriteEntry(dsio.Entry{Value: "not a row"}); err == nil {
+		t.Error("expected non-array entry to error")
+	}
+	if err := w.WriteEntry(dsio.Entry{Value: []interface{}{"a", "not a number"}}); err == nil {
+		t.Error("expected invalid integer to error")
+	}
+	if err := w.WriteEntry(dsio.Entry{Value: []interface{}{"a", 1, 2, true, nil, "extra"}}); err == nil {
+		t.Error("expected too many values to error")
+	}
+}
+
+func TestDetectSchema(t *testing.T) {
+	if _, _, err := DetectSchema(&dataset.Structure{}, strings.NewReader("SQLite format 3")); err == nil {
+		t.Error("expected invalid sqlite data to error")
+	}
+
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+				map[string]interface{}{"title": "ratio", "type": "number"},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+				map[string]interface{}{"title": "meta", "type": []interface{}{"object", "array"}},
+			},
+		},
+	}
+
+	st := &dataset.Structure{Format: "sqlite", Schema: schema, FormatConfig: map[string]interface{}{"table": "data"}}
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(dsio.Entry{Value: []interface{}{"a", 1, 1.5, true, nil}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	size := buf.Len()
+
+	got, n, err := DetectSchema(&dataset.Structure{FormatConfig: map[string]interface{}{"table": "data"}}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != size {
+		t.Errorf("bytes read mismatch. expected: %d, got: %d", size, n)
+	}
+	if diff := cmp.Diff(schema, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	cases := []struct {
+		path, mode, expect string
+	}{
+		{"/tmp/body.db", "ro", "file:///tmp/body.db?mode=ro"},
+		{"/tmp/what?#.db", "ro", "file:///tmp/what%3F%23.db?mode=ro"},
+		{"data/body 1.db", "rwc", "file:data%2Fbody%201.db?mode=rwc"},
+	}
+	for _, c := range cases {
+		if got := fileURI(c.path, c.mode); got != c.expect {
+			t.Errorf("%q uri mismatch. expected: %q, got: %q", c.path, c.expect, got)
+		}
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	for _, path := range []string{"body.sqlite", "body.sqlite3", "body.db"} {
+		df, _, err := detect.FormatFromFilename(path)
+		if err != nil || df != dataset.SQLiteDataFormat {
+			t.Errorf("%s: expected sqlite format. got: %s, %v", path, df, err)
+		}
+	}
+	if f, ok := dsio.LookupFormat(dataset.SQLiteDataFormat); !ok || !f.Tabular || f.DetectSchema == nil {
+		t.Errorf("expected registered tabular sqlite format. got: %#v", f)
+	}
+	if _, err := dsio.RegisterFormat(dsio.Format{Name: "sqlite", NewReader: dsio.NewEntryReader, NewWriter: dsio.NewEntryWriter}); err == nil || err.Error() != `data format name "sqlite" is already registered` {
+		t.Errorf("expected registering sqlite twice to error. got: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package dsio
 
 import (
+	"context"
 	"fmt"
 	"io"
 
@@ -43,9 +44,52 @@ func (r *PagedReader) Close() error {
 	return r.Reader.Close()
 }
 
+// ContextReader wraps a reader, ending reads when a context is cancelled or
+// it's deadline passes. Once the context is done ReadEntry returns the
+// context's error
+type ContextReader struct {
+	ctx    context.Context
+	reader EntryReader
+}
+
+var _ EntryReader = (*ContextReader)(nil)
+
+// NewContextReader creates a reader that stops reading when ctx is done
+func NewContextReader(ctx context.Context, r EntryReader) *ContextReader {
+	return &ContextReader{ctx: ctx, reader: r}
+}
+
+// Structure returns the wrapped reader's structure
+func (r *ContextReader) Structure() *dataset.Structure {
+	return r.reader.Structure()
+}
+
+// ReadEntry reads an entry from the wrapped reader, unless the context is done
+func (r *ContextReader) ReadEntry() (Entry, error) {
+	if err := r.ctx.Err(); err != nil {
+		return Entry{}, err
+	}
+	return r.reader.ReadEntry()
+}
+
+// Close closes the wrapped reader
+func (r *ContextReader) Close() error {
+	return r.reader.Close()
+}
+
 // Copy reads all entries from the reader and writes them to the writer
 func Copy(reader EntryReader, writer EntryWriter) error {
+	return CopyContext(context.Background(), reader, writer)
+}
+
+// CopyContext reads all entries from the reader and writes them to the writer,
+// checking the context before each entry. Copying a cancelled context returns
+// ctx.Err()
+func CopyContext(ctx context.Context, reader EntryReader, writer EntryWriter) error {
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		val, err := reader.ReadEntry()
 		if err != nil {
 			if err == io.EOF {
@@ -2,10 +2,12 @@ package dsio
 
 import (
 	"bytes"
+	"context"
 	"strings"
 	"testing"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
 )
 
 func TestCopyJSONToJSON(t *testing.T) {
@@ -190,3 +192,65 @@ bat,3,meh
 		t.Errorf("result mismatch. expected: '%s'\ngot: '%s'", text, got)
 	}
 }
+
+func TestContextReader(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := NewJSONReader(st, strings.NewReader(`[1,2,3,4]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cr := NewContextReader(ctx, r)
+	for i := 0; i < 2; i++ {
+		if _, err := cr.ReadEntry(); err != nil {
+			t.Fatalf("entry %d: unexpected error: %s", i, err)
+		}
+	}
+	cancel()
+	if _, err := cr.ReadEntry(); err != context.Canceled {
+		t.Errorf("expected reading a cancelled context to return context.Canceled. got: %v", err)
+	}
+}
+
+func TestCopyContext(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, err := NewJSONReader(st, strings.NewReader(`[1,2,3,4]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cancel partway through the copy
+	w := &cancelWriter{cancel: cancel, after: 2}
+	if err := CopyContext(ctx, r, w); err != context.Canceled {
+		t.Errorf("expected context.Canceled. got: %v", err)
+	}
+	if w.written != 2 {
+		t.Errorf("expected copying to stop after 2 entries. wrote %d", w.written)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	body := qfs.NewMemfileBytes("body.json", []byte(`[1,2,3,4]`))
+	if _, err := ConvertFileContext(ctx, body, st, st, 0, 0, true); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded. got: %v", err)
+	}
+}
+
+// cancelWriter discards entries, calling cancel after a number of writes
+type cancelWriter struct {
+	cancel  context.CancelFunc
+	after   int
+	written int
+}
+
+func (w *cancelWriter) Structure() *dataset.Structure { return nil }
+func (w *cancelWriter) Close() error                  { return nil }
+func (w *cancelWriter) WriteEntry(Entry) error {
+	w.written++
+	if w.written == w.after {
+		w.cancel()
+	}
+	return nil
+}
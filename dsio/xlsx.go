@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/qri-io/dataset"
@@ -12,36 +17,66 @@ import (
 	"github.com/qri-io/dataset/vals"
 )
 
+// xlsxHeaderStyle is the cell style of header rows written from column titles
+const xlsxHeaderStyle = `{"font":{"bold":true},"fill":{"type":"pattern","color":["#DDDDDD"],"pattern":1},"border":[{"type":"bottom","color":"#000000","style":1}]}`
+
+// spreadsheet number formats for date & date-time columns
+const (
+	xlsxDateFormat     = "yyyy-mm-dd"
+	xlsxDateTimeFormat = "yyyy-mm-dd hh:mm:ss"
+)
+
 // XLSXReader implements the RowReader interface for the XLSX data format
 type XLSXReader struct {
-	err       error
-	st        *dataset.Structure
-	sheetName string
-	file      *excelize.File
-	r         *excelize.Rows
-	idx       int
-	types     []string
+	err  error
+	st   *dataset.Structure
+	opts *dataset.XLSXOptions
+	file *excelize.File
+	rng  cellRange
+	cols xlsxColumns
+	rows [][]string
+	idx  int
+	// sheets & sheetCols are only set when reading all sheets
+	sheets    []string
+	sheetCols map[string]xlsxColumns
 }
 
 // NewXLSXReader creates a reader from a structure and read source
 func NewXLSXReader(st *dataset.Structure, r io.Reader) (*XLSXReader, error) {
-	if st.Compression != "" {
+	if st.Compression != "" && st.Archive == "" {
 		return nil, fmt.Errorf("xlsx format does not support compression")
 	}
 
-	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	opts, err := xlsxOptions(st)
 	if err != nil {
 		return nil, err
 	}
-
-	types := make([]string, len(cols))
-	for i, c := range cols {
-		types[i] = []string(*c.Type)[0]
+	rng, err := parseCellRange(opts.Range)
+	if err != nil {
+		return nil, err
 	}
 
 	rdr := &XLSXReader{
-		st:    st,
-		types: types,
+		st:   st,
+		opts: opts,
+		rng:  rng,
+	}
+
+	if !opts.AllSheets {
+		cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+		if err != nil {
+			return nil, err
+		}
+		rdr.cols = newXLSXColumns(cols)
+	}
+
+	if st.Archive != "" {
+		mr, close, err := maybeWrapDecompressor(st, r)
+		if err != nil {
+			return nil, err
+		}
+		defer close()
+		r = mr
 	}
 
 	rdr.file, rdr.err = excelize.OpenReader(r)
@@ -49,20 +84,80 @@ func NewXLSXReader(st *dataset.Structure, r io.Reader) (*XLSXReader, error) {
 		return rdr, rdr.err
 	}
 
-	if fcg, err := dataset.ParseFormatConfigMap(dataset.XLSXDataFormat, st.FormatConfig); err == nil {
-		if opts, ok := fcg.(*dataset.XLSXOptions); ok {
-			rdr.sheetName = opts.SheetName
-		}
+	if opts.AllSheets {
+		rdr.sheets = sheetNames(rdr.file)
+		rdr.sheetCols = sheetColumns(st.Schema)
+		return rdr, nil
+	}
+
+	rdr.rows, rdr.err = rdr.readSheet(opts.SheetName)
+	return rdr, rdr.err
+}
+
+// xlsxOptions parses the format configuration of a structure, defaulting to
+// the first sheet created in a new workbook
+func xlsxOptions(st *dataset.Structure) (*dataset.XLSXOptions, error) {
+	fcg, err := dataset.ParseFormatConfigMap(dataset.XLSXDataFormat, st.FormatConfig)
+	if err != nil {
+		return nil, err
+	}
+	opts, ok := fcg.(*dataset.XLSXOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid xlsx format config")
 	}
-	if rdr.sheetName == "" {
-		rdr.sheetName = "Sheet1"
+	if opts.SheetName == "" && !opts.AllSheets {
+		opts.SheetName = "Sheet1"
 	}
+	return opts, nil
+}
 
-	if rdr.err == nil {
-		rdr.r, rdr.err = rdr.file.Rows(rdr.sheetName)
+// sheetNames lists the sheets of a workbook in the order they were created
+func sheetNames(f *excelize.File) []string {
+	sheetMap := f.GetSheetMap()
+	idxs := make([]int, 0, len(sheetMap))
+	for i := range sheetMap {
+		idxs = append(idxs, i)
 	}
+	sort.Ints(idxs)
 
-	return rdr, rdr.err
+	names := make([]string, len(idxs))
+	for i, idx := range idxs {
+		names[i] = sheetMap[idx]
+	}
+	return names
+}
+
+// sheetColumns gets columns for each sheet from an object schema with a
+// tabular schema property per sheet. Sheets without a valid tabular schema
+// are read as strings
+func sheetColumns(sch map[string]interface{}) map[string]xlsxColumns {
+	cols := map[string]xlsxColumns{}
+	props, ok := sch["properties"].(map[string]interface{})
+	if !ok {
+		return cols
+	}
+	for name, prop := range props {
+		if propSch, ok := prop.(map[string]interface{}); ok {
+			if c, _, err := tabular.ColumnsFromJSONSchema(propSch); err == nil {
+				cols[name] = newXLSXColumns(c)
+			}
+		}
+	}
+	return cols
+}
+
+// readSheet reads the cells of a sheet within the reader's range, dropping
+// any header row
+func (r *XLSXReader) readSheet(name string) ([][]string, error) {
+	if r.file.GetSheetIndex(name) == 0 {
+		return nil, excelize.ErrSheetNotExist{SheetName: name}
+	}
+
+	rows := r.rng.clip(r.file.GetRows(name))
+	if r.opts.HeaderRow && len(rows) > 0 {
+		rows = rows[1:]
+	}
+	return rows, nil
 }
 
 // Structure gives this reader's structure
@@ -70,31 +165,82 @@ func (r *XLSXReader) Structure() *dataset.Structure {
 	return r.st
 }
 
-// ReadEntry reads one XLSX record from the reader
+// ReadEntry reads one XLSX record from the reader. When reading all sheets
+// each entry is a sheet, keyed by sheet name, with an array of rows as it's
+// value
 func (r *XLSXReader) ReadEntry() (Entry, error) {
 	if r.err != nil {
 		return Entry{}, r.err
 	}
-	if !r.r.Next() {
-		return Entry{}, io.EOF
+
+	if r.opts.AllSheets {
+		if r.idx >= len(r.sheets) {
+			return Entry{}, io.EOF
+		}
+		name := r.sheets[r.idx]
+		rows, err := r.readSheet(name)
+		if err != nil {
+			return Entry{}, err
+		}
+		cols := r.sheetCols[name]
+		sheet := make([]interface{}, len(rows))
+		for i, row := range rows {
+			sheet[i] = cols.decode(row)
+		}
+		ent := Entry{Index: r.idx, Key: name, Value: sheet}
+		r.idx++
+		return ent, nil
 	}
-	cols := r.r.Columns()
-	vals, err := r.decode(cols)
-	if err != nil {
-		return Entry{}, err
+
+	if r.idx >= len(r.rows) {
+		return Entry{}, io.EOF
 	}
-	ent := Entry{Index: r.idx, Value: vals}
+	ent := Entry{Index: r.idx, Value: r.cols.decode(r.rows[r.idx])}
 	r.idx++
 
 	return ent, nil
 }
 
+// Close finalizes the writer, indicating no more records will be read
+func (r *XLSXReader) Close() error {
+	return nil
+}
+
+// xlsxColumns holds the types and string formats of spreadsheet columns
+type xlsxColumns struct {
+	titles  []string
+	types   []string
+	formats []string
+}
+
+func newXLSXColumns(cols tabular.Columns) xlsxColumns {
+	c := xlsxColumns{
+		titles:  make([]string, len(cols)),
+		types:   make([]string, len(cols)),
+		formats: make([]string, len(cols)),
+	}
+	for i, col := range cols {
+		c.titles[i] = col.Title
+		c.types[i] = []string(*col.Type)[0]
+		c.formats[i], _ = col.Validation["format"].(string)
+	}
+	return c
+}
+
+// format gives the string format of column i, if any
+func (c xlsxColumns) format(i int) string {
+	if i < len(c.formats) {
+		return c.formats[i]
+	}
+	return ""
+}
+
 // decode uses specified types from structure's schema to cast xlsx string values to their
 // intended types. If casting fails because the data is invalid, it's left as a string instead
-// of causing an error.
-func (r *XLSXReader) decode(strings []string) ([]interface{}, error) {
+// of causing an error. Date & date-time columns are read from spreadsheet date numbers
+func (c xlsxColumns) decode(strings []string) []interface{} {
 	vs := make([]interface{}, len(strings))
-	types := r.types
+	types := c.types
 	if len(types) < len(strings) {
 		// TODO - fix. for now is types fails to parse we just assume all types
 		// are strings
@@ -107,6 +253,10 @@ func (r *XLSXReader) decode(strings []string) ([]interface{}, error) {
 		vs[i] = str
 
 		switch types[i] {
+		case "string":
+			if t, ok := cellTime(str, c.format(i)); ok {
+				vs[i] = t
+			}
 		case "number":
 			if num, err := vals.ParseNumber([]byte(str)); err == nil {
 				vs[i] = num
@@ -134,12 +284,23 @@ func (r *XLSXReader) decode(strings []string) ([]interface{}, error) {
 		}
 	}
 
-	return vs, nil
+	return vs
 }
 
-// Close finalizes the writer, indicating no more records will be read
-func (r *XLSXReader) Close() error {
-	return nil
+// cellTime formats the date number of a cell in a date or date-time column
+func cellTime(str, format string) (string, bool) {
+	if format != "date" && format != "date-time" {
+		return "", false
+	}
+	serial, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return "", false
+	}
+	t := timeFromExcelDate(serial)
+	if format == "date" {
+		return t.Format("2006-01-02"), true
+	}
+	return t.Format(time.RFC3339Nano), true
 }
 
 // XLSXWriter implements the RowWriter interface for
@@ -147,10 +308,17 @@ func (r *XLSXReader) Close() error {
 type XLSXWriter struct {
 	rowsWritten int
 	sheetName   string
+	opts        *dataset.XLSXOptions
+	rng         cellRange
 	f           *excelize.File
 	st          *dataset.Structure
 	w           io.Writer
-	types       []string
+	cols        xlsxColumns
+	// sheetCols & sheets are only used when writing all sheets
+	sheetCols map[string]xlsxColumns
+	sheets    int
+	// styles caches cell styles by number format
+	styles map[string]int
 }
 
 // NewXLSXWriter creates a Writer from a structure and write destination
@@ -158,39 +326,49 @@ func NewXLSXWriter(st *dataset.Structure, w io.Writer) (*XLSXWriter, error) {
 	if st.Compression != "" {
 		return nil, fmt.Errorf("xlsx format does not support compression")
 	}
+	if st.Archive != "" {
+		return nil, errArchiveWrite
+	}
 
-	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	opts, err := xlsxOptions(st)
 	if err != nil {
 		return nil, err
 	}
-
-	types := make([]string, len(cols))
-	for i, c := range cols {
-		types[i] = []string(*c.Type)[0]
+	rng, err := parseCellRange(opts.Range)
+	if err != nil {
+		return nil, err
 	}
 
 	wr := &XLSXWriter{
-		st:    st,
-		f:     excelize.NewFile(),
-		types: types,
-		w:     w,
+		st:        st,
+		opts:      opts,
+		rng:       rng,
+		f:         excelize.NewFile(),
+		w:         w,
+		sheetName: opts.SheetName,
+		styles:    map[string]int{},
 	}
 
-	if fcg, err := dataset.ParseFormatConfigMap(dataset.XLSXDataFormat, st.FormatConfig); err == nil {
-		if opts, ok := fcg.(*dataset.XLSXOptions); ok {
-			wr.sheetName = opts.SheetName
-		}
-	} else {
-		return nil, err
+	if opts.AllSheets {
+		wr.sheetCols = sheetColumns(st.Schema)
+		return wr, nil
 	}
 
-	if wr.sheetName == "" {
-		wr.sheetName = "Sheet1"
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
 	}
+	wr.cols = newXLSXColumns(cols)
 
 	idx := wr.f.NewSheet(wr.sheetName)
 	wr.f.SetActiveSheet(idx)
 
+	if opts.HeaderRow {
+		if err := wr.writeHeader(wr.sheetName, wr.cols); err != nil {
+			return nil, err
+		}
+	}
+
 	return wr, nil
 }
 
@@ -199,25 +377,179 @@ func (w *XLSXWriter) Structure() *dataset.Structure {
 	return w.st
 }
 
-// WriteEntry writes one XLSX record to the writer
+// WriteEntry writes one XLSX record to the writer. When writing all sheets
+// each entry is written to a new sheet named by the entry key
 func (w *XLSXWriter) WriteEntry(ent Entry) error {
-	if arr, ok := ent.Value.([]interface{}); ok {
-		strs, err := encodeStrings(arr)
-		if err != nil {
-			log.Debug(err.Error())
-			return fmt.Errorf("error encoding entry: %s", err.Error())
+	arr, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value to write xlsx row. got: %v", ent)
+	}
+
+	if w.opts.AllSheets {
+		return w.writeSheet(ent.Key, arr)
+	}
+
+	if err := w.writeRow(w.sheetName, w.rowsWritten, arr, w.cols); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error encoding entry: %s", err.Error())
+	}
+	w.rowsWritten++
+	return nil
+}
+
+// writeSheet adds a sheet of rows to the workbook
+func (w *XLSXWriter) writeSheet(name string, rows []interface{}) error {
+	if name == "" {
+		return fmt.Errorf("entry key is required to name an xlsx sheet")
+	}
+	if w.f.GetSheetIndex(name) != 0 && w.sheets > 0 {
+		return fmt.Errorf("duplicate xlsx sheet name: %q", name)
+	}
+
+	// new workbooks start with an empty sheet, use it for the first entry
+	if w.sheets == 0 {
+		w.f.SetSheetName("Sheet1", name)
+	} else {
+		w.f.NewSheet(name)
+	}
+	w.sheets++
+
+	cols, ok := w.sheetCols[name]
+	rowOffset := 0
+	if w.opts.HeaderRow {
+		if !ok && len(rows) > 0 {
+			// sheets without a schema get default column titles, so readers
+			// skipping the header don't skip data
+			if first, ok := rows[0].([]interface{}); ok {
+				cols.titles = make([]string, len(first))
+				for i := range first {
+					cols.titles[i] = fmt.Sprintf("col_%d", i)
+				}
+			}
+		}
+		if err := w.writeHeader(name, cols); err != nil {
+			return err
+		}
+		rowOffset = 1
+	}
+
+	for i, r := range rows {
+		row, ok := r.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array value to write xlsx row. got: %v", r)
 		}
-		for i, str := range strs {
-			w.f.SetCellValue(w.sheetName, w.axis(i), str)
+		if err := w.writeRow(name, i+rowOffset, row, cols); err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error encoding sheet %q row %d: %s", name, i, err.Error())
 		}
+	}
+	return nil
+}
+
+// writeHeader writes column titles as a styled row, rows written after the
+// header move down to make room for it
+func (w *XLSXWriter) writeHeader(sheet string, cols xlsxColumns) error {
+	if len(cols.titles) == 0 {
+		return nil
+	}
+	for i, title := range cols.titles {
+		w.f.SetCellStr(sheet, w.axis(i, 0), title)
+	}
+
+	style, err := w.f.NewStyle(xlsxHeaderStyle)
+	if err != nil {
+		return err
+	}
+	w.f.SetCellStyle(sheet, w.axis(0, 0), w.axis(len(cols.titles)-1, 0), style)
+
+	if sheet == w.sheetName {
 		w.rowsWritten++
+	}
+	return nil
+}
+
+// writeRow sets the cells of row number rowIdx, counting from the start of
+// the writer's range
+func (w *XLSXWriter) writeRow(sheet string, rowIdx int, row []interface{}, cols xlsxColumns) error {
+	for i, v := range row {
+		if err := w.setCell(sheet, w.axis(i, rowIdx), v, cols.format(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setCell writes a value as a typed cell. numbers & booleans are stored as
+// native spreadsheet values, strings in date & date-time columns as dates,
+// arrays & objects as JSON strings. null values leave the cell empty
+func (w *XLSXWriter) setCell(sheet, axis string, v interface{}, format string) error {
+	switch x := v.(type) {
+	case nil:
 		return nil
+	case string:
+		if serial, numFmt, ok := excelDate(x, format); ok {
+			style, err := w.numberStyle(numFmt)
+			if err != nil {
+				return err
+			}
+			w.f.SetCellDefault(sheet, axis, strconv.FormatFloat(serial, 'f', -1, 64))
+			w.f.SetCellStyle(sheet, axis, axis, style)
+			return nil
+		}
+		w.f.SetCellStr(sheet, axis, x)
+	case time.Time:
+		style, err := w.numberStyle(xlsxDateTimeFormat)
+		if err != nil {
+			return err
+		}
+		w.f.SetCellDefault(sheet, axis, strconv.FormatFloat(excelSerial(x), 'f', -1, 64))
+		w.f.SetCellStyle(sheet, axis, axis, style)
+	case int, int64, float64, bool:
+		w.f.SetCellValue(sheet, axis, x)
+	case json.Number:
+		w.f.SetCellDefault(sheet, axis, x.String())
+	case *big.Int:
+		if x != nil {
+			w.f.SetCellDefault(sheet, axis, x.String())
+		}
+	case *big.Float:
+		if x != nil {
+			w.f.SetCellDefault(sheet, axis, x.Text('g', -1))
+		}
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(exactJSON(x))
+		if err != nil {
+			return err
+		}
+		w.f.SetCellStr(sheet, axis, string(data))
+	default:
+		return fmt.Errorf("unrecognized encoding type: %#v", v)
 	}
-	return fmt.Errorf("expected array value to write xlsx row. got: %v", ent)
+	return nil
 }
 
-func (w *XLSXWriter) axis(colIDx int) string {
-	return ColIndexToLetters(colIDx) + strconv.Itoa(w.rowsWritten+1)
+// numberStyle gets a cell style for a custom number format, creating it on
+// first use
+func (w *XLSXWriter) numberStyle(numFmt string) (int, error) {
+	if style, ok := w.styles[numFmt]; ok {
+		return style, nil
+	}
+	data, err := json.Marshal(map[string]string{"custom_number_format": numFmt})
+	if err != nil {
+		return 0, err
+	}
+	style, err := w.f.NewStyle(string(data))
+	if err != nil {
+		return 0, err
+	}
+	w.styles[numFmt] = style
+	return style, nil
+}
+
+// axis gives the A1-style cell reference of a column & row, offset by the
+// top-left cell of the writer's range
+func (w *XLSXWriter) axis(colIdx, rowIdx int) string {
+	return ColIndexToLetters(w.rng.col+colIdx) + strconv.Itoa(w.rng.row+rowIdx+1)
 }
 
 // Close finalizes the writer, indicating no more records
@@ -227,40 +559,130 @@ func (w *XLSXWriter) Close() error {
 	return err
 }
 
-func encodeStrings(vs []interface{}) (strs []string, err error) {
-	strs = make([]string, len(vs))
-	for i, v := range vs {
-		if v == nil {
-			continue
+// excelEpoch is day zero of spreadsheet date numbers, which count days
+// (and fractions of a day) since the epoch
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// excelDate parses a string in a date or date-time column into a spreadsheet
+// date number and the number format to display it with
+func excelDate(str, format string) (serial float64, numFmt string, ok bool) {
+	var t time.Time
+	var err error
+	switch format {
+	case "date":
+		t, err = time.Parse("2006-01-02", str)
+		numFmt = xlsxDateFormat
+	case "date-time":
+		t, err = time.Parse(time.RFC3339Nano, str)
+		numFmt = xlsxDateTimeFormat
+	default:
+		return 0, "", false
+	}
+	if err != nil {
+		return 0, "", false
+	}
+
+	return excelSerial(t), numFmt, true
+}
+
+// excelSerial converts a time to a spreadsheet date number in UTC
+func excelSerial(t time.Time) float64 {
+	t = t.UTC()
+	secs := float64(t.Unix()-excelEpoch.Unix()) + float64(t.Nanosecond())/1e9
+	return secs / 86400
+}
+
+// timeFromExcelDate converts a spreadsheet date number to a UTC time, rounded
+// to the millisecond to drop floating point error
+func timeFromExcelDate(serial float64) time.Time {
+	ms := int64(math.Round(serial * 86400 * 1000))
+	return excelEpoch.Add(time.Duration(ms/1000) * time.Second).Add(time.Duration(ms%1000) * time.Millisecond)
+}
+
+// cellRange is a block of spreadsheet cells with zero-based, inclusive
+// bounds. An end of -1 extends the range to the edge of the sheet
+type cellRange struct {
+	col, row       int
+	endCol, endRow int
+}
+
+// parseCellRange reads a range in A1 notation like "B3:H200", or a single
+// cell like "B3" that extends to the edge of the sheet. The empty string is
+// the entire sheet
+func parseCellRange(s string) (cellRange, error) {
+	rng := cellRange{endCol: -1, endRow: -1}
+	if s == "" {
+		return rng, nil
+	}
+
+	start, end := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		start, end = s[:i], s[i+1:]
+	}
+
+	var err error
+	if rng.col, rng.row, err = parseCellRef(start); err != nil {
+		return rng, fmt.Errorf("invalid xlsx range %q: %w", s, err)
+	}
+	if end != "" {
+		if rng.endCol, rng.endRow, err = parseCellRef(end); err != nil {
+			return rng, fmt.Errorf("invalid xlsx range %q: %w", s, err)
 		}
-		switch x := v.(type) {
-		case int:
-			strs[i] = strconv.Itoa(x)
-		case int64:
-			strs[i] = strconv.Itoa(int(x))
-		case float64:
-			strs[i] = strconv.FormatFloat(x, 'f', -1, 64)
-		case bool:
-			strs[i] = strconv.FormatBool(x)
-		case string:
-			strs[i] = x
-		case []interface{}:
-			data, err := json.Marshal(x)
-			if err != nil {
-				return strs, err
+		if rng.endCol < rng.col || rng.endRow < rng.row {
+			return rng, fmt.Errorf("invalid xlsx range %q: range end comes before start", s)
+		}
+	}
+	return rng, nil
+}
+
+// parseCellRef converts an A1-style cell reference to zero-based column &
+// row indexes
+func parseCellRef(ref string) (col, row int, err error) {
+	ref = strings.ToUpper(strings.Replace(ref, "$", "", -1))
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 || i > 3 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	row, err = strconv.Atoi(ref[i:])
+	if err != nil || row < 1 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, row - 1, nil
+}
+
+// clip trims rows of cells to the range. Rows in a range with an end column
+// are padded with empty cells to the width of the range
+func (rng cellRange) clip(rows [][]string) [][]string {
+	if rng.row >= len(rows) {
+		return nil
+	}
+	rows = rows[rng.row:]
+	if rng.endRow >= 0 && rng.endRow-rng.row+1 < len(rows) {
+		rows = rows[:rng.endRow-rng.row+1]
+	}
+
+	clipped := make([][]string, len(rows))
+	for i, row := range rows {
+		if rng.col < len(row) {
+			row = row[rng.col:]
+		} else {
+			row = nil
+		}
+		if rng.endCol >= 0 {
+			width := rng.endCol - rng.col + 1
+			if len(row) > width {
+				row = row[:width]
 			}
-			strs[i] = string(data)
-		case map[string]interface{}:
-			data, err := json.Marshal(x)
-			if err != nil {
-				return strs, err
+			for len(row) < width {
+				row = append(row, "")
 			}
-			strs[i] = string(data)
-		default:
-			return strs, fmt.Errorf("unrecognized encoding type: %#v", v)
 		}
+		clipped[i] = row
 	}
-	return
+	return clipped
 }
 
 // ColIndexToLetters is used to convert a zero based, numeric column
@@ -2,9 +2,16 @@ package dsio
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dstest"
 )
@@ -121,6 +128,262 @@ func TestXLSXWriter(t *testing.T) {
 	}
 }
 
+var xlsxTypedSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "name", "type": "string"},
+			map[string]interface{}{"title": "score", "type": "number"},
+			map[string]interface{}{"title": "active", "type": "boolean"},
+			map[string]interface{}{"title": "joined", "type": "string", "format": "date"},
+			map[string]interface{}{"title": "seen", "type": "string", "format": "date-time"},
+		},
+	},
+}
+
+var xlsxStringSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type": "array",
+		"items": []interface{}{
+			map[string]interface{}{"title": "a", "type": "string"},
+			map[string]interface{}{"title": "b", "type": "string"},
+			map[string]interface{}{"title": "c", "type": "string"},
+			map[string]interface{}{"title": "d", "type": "string"},
+			map[string]interface{}{"title": "e", "type": "string"},
+		},
+	},
+}
+
+var xlsxTypedRows = []Entry{
+	{Value: []interface{}{"ada", 1.5, true, "2020-02-03", "2021-04-05T06:07:08Z"}},
+	{Value: []interface{}{"grace", int64(2), false, nil, time.Date(2021, 4, 5, 6, 7, 8, 500000000, time.UTC)}},
+}
+
+// writeXLSX writes entries with a structure, returning the workbook bytes
+func writeXLSX(t *testing.T, st *dataset.Structure, ents []Entry) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := NewXLSXWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ent := range ents {
+		if err := w.WriteEntry(ent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readXLSX reads all entries of a workbook
+func readXLSX(t *testing.T, st *dataset.Structure, data []byte) []Entry {
+	t.Helper()
+	r, err := NewXLSXReader(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ents []Entry
+	for {
+		ent, err := r.ReadEntry()
+		if err == io.EOF {
+			return ents
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		ents = append(ents, ent)
+	}
+}
+
+func TestXLSXTypedCells(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "xlsx",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema:       xlsxTypedSchema,
+	}
+	data := writeXLSX(t, st, xlsxTypedRows)
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := string(f.XLSX["xl/worksheets/sheet1.xml"])
+	for _, cell := range []string{
+		`<c r="A1" s="1" t="str"><v>name</v></c>`,
+		`<c r="B2"><v>1.5</v></c>`,
+		`<c r="C2" t="b"><v>1</v></c>`,
+		`<c r="D2" s="2"><v>43864</v></c>`,
+		`<c r="B3"><v>2</v></c>`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("expected sheet to contain cell %s", cell)
+		}
+	}
+	if strings.Contains(sheet, `r="D3"`) {
+		t.Errorf("expected null value to leave cell empty")
+	}
+
+	expect := []Entry{
+		{Index: 0, Value: []interface{}{"ada", 1.5, true, "2020-02-03", "2021-04-05T06:07:08Z"}},
+		{Index: 1, Value: []interface{}{"grace", float64(2), false, "", "2021-04-05T06:07:08.5Z"}},
+	}
+	if diff := cmp.Diff(expect, readXLSX(t, st, data)); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestXLSXExactNumbers(t *testing.T) {
+	st := &dataset.Structure{Format: "xlsx", Schema: xlsxTypedSchema}
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	data := writeXLSX(t, st, []Entry{
+		{Value: []interface{}{"a", json.Number("0.1")}},
+		{Value: []interface{}{"b", n}},
+		{Value: []interface{}{"c", []interface{}{json.Number("2.50")}}},
+	})
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := string(f.XLSX["xl/worksheets/sheet1.xml"])
+	for _, cell := range []string{
+		`<c r="B1"><v>0.1</v></c>`,
+		`<c r="B2"><v>123456789012345678901234567890</v></c>`,
+		`<c r="B3" t="str"><v>[2.50]</v></c>`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("expected sheet to contain cell %s", cell)
+		}
+	}
+}
+
+func TestXLSXRange(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "xlsx",
+		FormatConfig: map[string]interface{}{"headerRow": true, "range": "C4"},
+		Schema:       xlsxTypedSchema,
+	}
+	data := writeXLSX(t, st, xlsxTypedRows)
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.GetCellValue("Sheet1", "C4"); got != "name" {
+		t.Errorf("expected header to start at range origin. got: %q", got)
+	}
+
+	cases := []struct {
+		rng    string
+		header bool
+		expect []Entry
+	}{
+		{"C4", true, []Entry{
+			{Index: 0, Value: []interface{}{"ada", 1.5, true, "2020-02-03", "2021-04-05T06:07:08Z"}},
+			{Index: 1, Value: []interface{}{"grace", float64(2), false, "", "2021-04-05T06:07:08.5Z"}},
+		}},
+		{"C5:D5", false, []Entry{
+			{Index: 0, Value: []interface{}{"ada", "1.5"}},
+		}},
+		{"$D$5:$H$9", false, []Entry{
+			{Index: 0, Value: []interface{}{"1.5", "1", "43864", "44291.254953703705", ""}},
+			{Index: 1, Value: []interface{}{"2", "0", "", "44291.25495949074", ""}},
+		}},
+		{"A20:B30", false, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rng, func(t *testing.T) {
+			schema := xlsxTypedSchema
+			if !c.header {
+				schema = xlsxStringSchema
+			}
+			st := &dataset.Structure{
+				Format:       "xlsx",
+				FormatConfig: map[string]interface{}{"headerRow": c.header, "range": c.rng},
+				Schema:       schema,
+			}
+			if diff := cmp.Diff(c.expect, readXLSX(t, st, data)); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXLSXAllSheets(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "xlsx",
+		FormatConfig: map[string]interface{}{"allSheets": true, "headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"people": xlsxTypedSchema,
+			},
+		},
+	}
+	ents := []Entry{
+		{Key: "people", Value: []interface{}{xlsxTypedRows[0].Value}},
+		{Key: "notes", Value: []interface{}{
+			[]interface{}{"first", 1},
+			[]interface{}{"second", 2},
+		}},
+	}
+	data := writeXLSX(t, st, ents)
+
+	expect := []Entry{
+		{Index: 0, Key: "people", Value: []interface{}{
+			[]interface{}{"ada", 1.5, true, "2020-02-03", "2021-04-05T06:07:08Z"},
+		}},
+		{Index: 1, Key: "notes", Value: []interface{}{
+			[]interface{}{"first", "1"},
+			[]interface{}{"second", "2"},
+		}},
+	}
+	if diff := cmp.Diff(expect, readXLSX(t, st, data)); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	w, err := NewXLSXWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{}}); err == nil {
+		t.Error("expected writing a sheet without a key to fail")
+	}
+}
+
+func TestParseCellRange(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect cellRange
+		err    string
+	}{
+		{"", cellRange{0, 0, -1, -1}, ""},
+		{"A1", cellRange{0, 0, -1, -1}, ""},
+		{"B3:H200", cellRange{1, 2, 7, 199}, ""},
+		{"aa10:ab12", cellRange{26, 9, 27, 11}, ""},
+		{"$B$3:$C$4", cellRange{1, 2, 2, 3}, ""},
+		{"3B", cellRange{}, `invalid xlsx range "3B": invalid cell reference "3B"`},
+		{"B0", cellRange{}, `invalid xlsx range "B0": invalid cell reference "B0"`},
+		{"C3:B4", cellRange{}, `invalid xlsx range "C3:B4": range end comes before start`},
+	}
+
+	for _, c := range cases {
+		got, err := parseCellRange(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%q error mismatch. expected: %q, got: %v", c.in, c.err, err)
+			continue
+		}
+		if c.err == "" && got != c.expect {
+			t.Errorf("%q result mismatch. expected: %v, got: %v", c.in, c.expect, got)
+		}
+	}
+}
+
 func TestXLSXCompression(t *testing.T) {
 	if _, err := NewXLSXReader(&dataset.Structure{Format: "xlsx", Compression: "gzip"}, nil); err == nil {
 		t.Error("expected xlsx to fail when using compression")
@@ -0,0 +1,593 @@
+package dsio
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+const (
+	// defaultXMLAttributePrefix is prepended to attribute names when no
+	// AttributePrefix is configured
+	defaultXMLAttributePrefix = "@"
+	// defaultXMLTextKey is the object key for element character data when no
+	// TextKey is configured
+	defaultXMLTextKey = "#text"
+	// defaultXMLWrapperElement names wrapping elements the writer can't infer
+	// from a record path
+	defaultXMLWrapperElement = "entries"
+	// defaultXMLRecordElement names entry elements the writer can't infer from
+	// a record path
+	defaultXMLRecordElement = "entry"
+	// defaultXMLItemElement names elements written for array items
+	defaultXMLItemElement = "item"
+)
+
+// xmlRecordPath splits a configured record path into element names. An empty
+// path matches all children of the root element, a single name matches
+// children of the root element with that name. "*" matches any element name
+func xmlRecordPath(recordPath string) []string {
+	recordPath = strings.Trim(recordPath, "/")
+	if recordPath == "" {
+		return []string{"*", "*"}
+	}
+	path := strings.Split(recordPath, "/")
+	if len(path) == 1 {
+		// a document can only have one root element, entries are always
+		// children of it
+		path = append([]string{"*"}, path...)
+	}
+	return path
+}
+
+// xmlOptions pulls XMLOptions from a structure, filling in defaults
+func xmlOptions(st *dataset.Structure) (*dataset.XMLOptions, error) {
+	opts, err := dataset.NewXMLOptions(st.FormatConfig)
+	if err != nil {
+		return nil, err
+	}
+	if opts.AttributePrefix == "" {
+		opts.AttributePrefix = defaultXMLAttributePrefix
+	}
+	if opts.TextKey == "" {
+		opts.TextKey = defaultXMLTextKey
+	}
+	return opts, nil
+}
+
+// XMLReader implements the EntryReader interface for the XML data format.
+// Each element matched by the configured record path is read as one entry.
+// Elements that only contain text become string values, all other elements
+// become objects keyed by child element name, with attributes & text stored
+// under prefixed keys. Repeated child elements are collected into arrays
+type XMLReader struct {
+	entriesRead int
+	st          *dataset.Structure
+	dec         *xml.Decoder
+	close       func() error // close func from wrapped reader
+	opts        *dataset.XMLOptions
+	path        []string
+	depth       int // depth of the currently open element, root is 1
+	matched     int // number of open elements that match the record path
+}
+
+var _ EntryReader = (*XMLReader)(nil)
+
+// NewXMLReader creates a reader from a structure and read source
+func NewXMLReader(st *dataset.Structure, r io.Reader) (*XMLReader, error) {
+	if st.Schema == nil {
+		err := fmt.Errorf("schema required for XML reader")
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	tlt, err := GetTopLevelType(st)
+	if err != nil {
+		return nil, err
+	}
+	if tlt != "array" {
+		return nil, fmt.Errorf("XML top level type must be 'array'")
+	}
+
+	opts, err := xmlOptions(st)
+	if err != nil {
+		return nil, err
+	}
+
+	r, close, err := maybeWrapTextDecoder(st, r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if st.Encoding != "" {
+			// input has already been transcoded to utf-8
+			return input, nil
+		}
+		enc, err := LookupEncoding(label)
+		if err != nil || enc == nil {
+			return input, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+
+	return &XMLReader{
+		st:    st,
+		dec:   dec,
+		close: close,
+		opts:  opts,
+		path:  xmlRecordPath(opts.RecordPath),
+	}, nil
+}
+
+// Structure gives this reader's structure
+func (r *XMLReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one XML record from the reader
+func (r *XMLReader) ReadEntry() (Entry, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if err != io.EOF {
+				log.Debug(err.Error())
+			}
+			return Entry{}, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			r.depth++
+			if !r.onPath(t.Name.Local) {
+				continue
+			}
+			if r.depth < len(r.path) {
+				r.matched++
+				continue
+			}
+
+			val, err := r.readElement(t)
+			r.depth--
+			if err != nil {
+				log.Debug(err.Error())
+				return Entry{}, err
+			}
+			ent := Entry{Index: r.entriesRead, Value: val}
+			r.entriesRead++
+			return ent, nil
+		case xml.EndElement:
+			if r.matched == r.depth {
+				r.matched--
+			}
+			r.depth--
+		}
+	}
+}
+
+// onPath reports weather an element with the given name opened at the current
+// depth continues a match of the record path
+func (r *XMLReader) onPath(name string) bool {
+	if r.depth > len(r.path) || r.matched != r.depth-1 {
+		return false
+	}
+	p := r.path[r.depth-1]
+	return p == "*" || p == name
+}
+
+// readElement consumes tokens up to and including the end of the element
+// opened by start, returning it's value
+func (r *XMLReader) readElement(start xml.StartElement) (interface{}, error) {
+	var (
+		obj  map[string]interface{}
+		text strings.Builder
+	)
+
+	if !r.opts.IgnoreAttributes {
+		for _, attr := range start.Attr {
+			// skip namespace declarations
+			if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+				continue
+			}
+			if obj == nil {
+				obj = map[string]interface{}{}
+			}
+			obj[r.opts.AttributePrefix+attr.Name.Local] = attr.Value
+		}
+	}
+
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("unexpected EOF reading element <%s>", start.Name.Local)
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := r.readElement(t)
+			if err != nil {
+				return nil, err
+			}
+			if obj == nil {
+				obj = map[string]interface{}{}
+			}
+			addXMLChild(obj, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			str := strings.TrimSpace(text.String())
+			if obj == nil {
+				if str == "" {
+					return nil, nil
+				}
+				return str, nil
+			}
+			if str != "" {
+				obj[r.opts.TextKey] = str
+			}
+			return obj, nil
+		}
+	}
+}
+
+// addXMLChild sets a child element value on an object, collecting repeated
+// element names into an array
+func addXMLChild(obj map[string]interface{}, name string, val interface{}) {
+	prev, ok := obj[name]
+	if !ok {
+		obj[name] = val
+		return
+	}
+	if arr, ok := prev.([]interface{}); ok {
+		obj[name] = append(arr, val)
+		return
+	}
+	obj[name] = []interface{}{prev, val}
+}
+
+// Close finalizes the reader
+func (r *XMLReader) Close() error {
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+// XMLWriter implements the EntryWriter interface for XML-formatted data.
+// Entries are written as elements named by the last element of the record
+// path, wrapped in elements named by the rest of the path. Object keys &
+// column titles must be valid XML names. Binary values are written as base64
+type XMLWriter struct {
+	rowsWritten int
+	st          *dataset.Structure
+	wr          io.Writer
+	enc         *xml.Encoder
+	close       func() error // close func from wrapped writer
+	opts        *dataset.XMLOptions
+	wrappers    []string
+	record      string
+	titles      []string
+}
+
+var _ EntryWriter = (*XMLWriter)(nil)
+
+// NewXMLWriter creates a Writer from a structure and write destination
+func NewXMLWriter(st *dataset.Structure, w io.Writer) (*XMLWriter, error) {
+	if st.Schema == nil {
+		err := fmt.Errorf("schema required for XML writer")
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	tlt, err := GetTopLevelType(st)
+	if err != nil {
+		return nil, err
+	}
+	if tlt != "array" {
+		return nil, fmt.Errorf("XML top level type must be 'array'")
+	}
+
+	opts, err := xmlOptions(st)
+	if err != nil {
+		return nil, err
+	}
+
+	w, close, err := maybeWrapTextEncoder(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	path := xmlRecordPath(opts.RecordPath)
+	for i, name := range path {
+		if name == "*" {
+			path[i] = defaultXMLWrapperElement
+		} else if !isXMLName(name) {
+			return nil, fmt.Errorf("recordPath element %q isn't a valid XML name", name)
+		}
+	}
+	record := path[len(path)-1]
+	if record == defaultXMLWrapperElement {
+		record = defaultXMLRecordElement
+	}
+
+	xw := &XMLWriter{
+		st:       st,
+		wr:       w,
+		enc:      xml.NewEncoder(w),
+		close:    close,
+		opts:     opts,
+		wrappers: path[:len(path)-1],
+		record:   record,
+	}
+
+	// tabular rows use column titles as element names
+	if cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema); err == nil {
+		xw.titles = cols.Titles()
+		for _, title := range xw.titles {
+			if !isXMLName(title) {
+				return nil, fmt.Errorf("column title %q isn't a valid XML name", title)
+			}
+		}
+	}
+
+	return xw, nil
+}
+
+// Structure gives this writer's structure
+func (w *XMLWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one XML record to the writer
+func (w *XMLWriter) WriteEntry(ent Entry) error {
+	if w.rowsWritten == 0 {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	w.rowsWritten++
+
+	if arr, ok := ent.Value.([]interface{}); ok && len(w.titles) > 0 {
+		start := xml.StartElement{Name: xml.Name{Local: w.record}}
+		if err := w.enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for i, v := range arr {
+			name := defaultXMLItemElement
+			if i < len(w.titles) {
+				name = w.titles[i]
+			}
+			if err := w.writeValue(name, v); err != nil {
+				return fmt.Errorf("error encoding entry: %w", err)
+			}
+		}
+		return w.enc.EncodeToken(start.End())
+	}
+
+	if err := w.writeValue(w.record, ent.Value); err != nil {
+		return fmt.Errorf("error encoding entry: %w", err)
+	}
+	return nil
+}
+
+// open writes the XML header and opening wrapper elements
+func (w *XMLWriter) open() error {
+	header := xml.Header
+	if w.st.Encoding != "" {
+		name, err := canonicalEncodingName(w.st.Encoding)
+		if err != nil {
+			return err
+		}
+		header = fmt.Sprintf("<?xml version=\"1.0\" encoding=\"%s\"?>\n", name)
+	}
+	if _, err := io.WriteString(w.wr, header); err != nil {
+		return err
+	}
+	for _, name := range w.wrappers {
+		if err := w.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeValue encodes a value as an element with the given name
+func (w *XMLWriter) writeValue(name string, v interface{}) error {
+	if !isXMLName(name) {
+		return fmt.Errorf("key %q isn't a valid XML name", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var (
+			children []string
+			text     interface{}
+		)
+		for _, key := range keys {
+			if key == w.opts.TextKey {
+				text = x[key]
+			} else if strings.HasPrefix(key, w.opts.AttributePrefix) && isXMLScalar(x[key]) {
+				str, err := xmlScalarString(x[key])
+				if err != nil {
+					return err
+				}
+				attr := strings.TrimPrefix(key, w.opts.AttributePrefix)
+				if !isXMLName(attr) {
+					return fmt.Errorf("key %q isn't a valid XML attribute name", key)
+				}
+				start.Attr = append(start.Attr, xml.Attr{
+					Name:  xml.Name{Local: attr},
+					Value: str,
+				})
+			} else {
+				children = append(children, key)
+			}
+		}
+
+		if err := w.enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if text != nil {
+			str, err := xmlScalarString(text)
+			if err != nil {
+				return err
+			}
+			if err := w.enc.EncodeToken(xml.CharData(str)); err != nil {
+				return err
+			}
+		}
+		for _, key := range children {
+			// arrays within objects are written as repeated elements
+			if arr, ok := x[key].([]interface{}); ok {
+				for _, item := range arr {
+					if err := w.writeValue(key, item); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := w.writeValue(key, x[key]); err != nil {
+				return err
+			}
+		}
+		return w.enc.EncodeToken(start.End())
+	case []interface{}:
+		if err := w.enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range x {
+			if err := w.writeValue(defaultXMLItemElement, item); err != nil {
+				return err
+			}
+		}
+		return w.enc.EncodeToken(start.End())
+	default:
+		str, err := xmlScalarString(v)
+		if err != nil {
+			return err
+		}
+		if err := w.enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if str != "" {
+			if err := w.enc.EncodeToken(xml.CharData(str)); err != nil {
+				return err
+			}
+		}
+		return w.enc.EncodeToken(start.End())
+	}
+}
+
+// isXMLName reports if s is a valid XML element or attribute name. Names
+// with a namespace prefix aren't valid, as prefixes are dropped when reading
+func isXMLName(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+	for i, r := range s {
+		if !isXMLNameStartChar(r) && (i == 0 || !isXMLNameChar(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isXMLNameStartChar reports if r can start an XML name, following the
+// NameStartChar production of the XML spec, without ':'
+func isXMLNameStartChar(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r == '_' || r >= 'a' && r <= 'z' ||
+		r >= 0xC0 && r <= 0xD6 || r >= 0xD8 && r <= 0xF6 || r >= 0xF8 && r <= 0x2FF ||
+		r >= 0x370 && r <= 0x37D || r >= 0x37F && r <= 0x1FFF || r >= 0x200C && r <= 0x200D ||
+		r >= 0x2070 && r <= 0x218F || r >= 0x2C00 && r <= 0x2FEF || r >= 0x3001 && r <= 0xD7FF ||
+		r >= 0xF900 && r <= 0xFDCF || r >= 0xFDF0 && r <= 0xFFFD || r >= 0x10000 && r <= 0xEFFFF
+}
+
+// isXMLNameChar reports if r can follow the first character of an XML name
+func isXMLNameChar(r rune) bool {
+	return isXMLNameStartChar(r) || r == '-' || r == '.' || r >= '0' && r <= '9' ||
+		r == 0xB7 || r >= 0x300 && r <= 0x36F || r >= 0x203F && r <= 0x2040
+}
+
+func isXMLScalar(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+// xmlScalarString formats a non-container value as element or attribute text
+func xmlScalarString(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return x, nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int:
+		return strconv.Itoa(x), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case json.Number:
+		return x.String(), nil
+	case *big.Int:
+		return x.String(), nil
+	case *big.Float:
+		return x.Text('g', -1), nil
+	case time.Time:
+		return x.Format(time.RFC3339Nano), nil
+	case []byte:
+		// binary data can hold characters XML can't, it's written as
+		// base64 like the JSON writer writes it
+		return base64.StdEncoding.EncodeToString(x), nil
+	default:
+		return "", fmt.Errorf("unrecognized encoding type: %#v", v)
+	}
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *XMLWriter) Close() error {
+	if w.rowsWritten == 0 {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	for i := len(w.wrappers) - 1; i >= 0; i-- {
+		if err := w.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: w.wrappers[i]}}); err != nil {
+			return fmt.Errorf("error closing writer: %s", err.Error())
+		}
+	}
+	if err := w.enc.Flush(); err != nil {
+		return fmt.Errorf("error closing writer: %s", err.Error())
+	}
+	if w.close != nil {
+		return w.close()
+	}
+	return nil
+}
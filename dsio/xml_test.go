@@ -0,0 +1,305 @@
+package dsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+)
+
+const xmlFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:geo="http://www.w3.org/2003/01/geo/wgs84_pos#">
+	<title>station readings</title>
+	<items>
+		<item id="1">
+			<name>Oak St.</name>
+			<reading unit="c">12.5</reading>
+			<tag>north</tag>
+			<tag>river</tag>
+		</item>
+		<item id="2"><name>Elm St.</name><empty/></item>
+	</items>
+	<item id="not-an-entry"/>
+</feed>`
+
+func TestXMLReader(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		expect      []interface{}
+		err         string
+	}{
+		{"no schema",
+			&dataset.Structure{Format: "xml"},
+			nil, "schema required for XML reader"},
+		{"object top level type",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaObject},
+			nil, "XML top level type must be 'array'"},
+		{"root children",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray},
+			[]interface{}{
+				"station readings",
+				map[string]interface{}{
+					"item": []interface{}{
+						map[string]interface{}{
+							"@id":     "1",
+							"name":    "Oak St.",
+							"reading": map[string]interface{}{"@unit": "c", "#text": "12.5"},
+							"tag":     []interface{}{"north", "river"},
+						},
+						map[string]interface{}{"@id": "2", "name": "Elm St.", "empty": nil},
+					},
+				},
+				map[string]interface{}{"@id": "not-an-entry"},
+			}, ""},
+		{"record path",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{
+				"recordPath":       "/feed/items/item",
+				"ignoreAttributes": true,
+			}},
+			[]interface{}{
+				map[string]interface{}{
+					"name":    "Oak St.",
+					"reading": "12.5",
+					"tag":     []interface{}{"north", "river"},
+				},
+				map[string]interface{}{"name": "Elm St.", "empty": nil},
+			}, ""},
+		{"wildcard record path & custom keys",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{
+				"recordPath":      "*/*/item",
+				"attributePrefix": "-",
+				"textKey":         "value",
+			}},
+			[]interface{}{
+				map[string]interface{}{
+					"-id":     "1",
+					"name":    "Oak St.",
+					"reading": map[string]interface{}{"-unit": "c", "value": "12.5"},
+					"tag":     []interface{}{"north", "river"},
+				},
+				map[string]interface{}{"-id": "2", "name": "Elm St.", "empty": nil},
+			}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := NewEntryReader(c.st, strings.NewReader(xmlFeed))
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: '%s', got: '%v'", c.err, err)
+			} else if c.err != "" {
+				return
+			}
+
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXMLReaderErrors(t *testing.T) {
+	st := &dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray}
+	r, err := NewXMLReader(st, strings.NewReader(`<feed><item><a>b</a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil {
+		t.Error("expected reading a truncated document to error")
+	}
+}
+
+func TestXMLWriter(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		entries     []Entry
+		expect      string
+		// readBack is the result of reading written XML, when set
+		readBack []interface{}
+	}{
+		{"empty",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray},
+			nil,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n<entries></entries>",
+			nil},
+		{"objects",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{
+				"recordPath": "feed/items/item",
+			}},
+			[]Entry{
+				{Value: map[string]interface{}{
+					"@id":     int64(1),
+					"name":    "Oak St. & 5th",
+					"reading": map[string]interface{}{"@unit": "c", "#text": 12.5},
+					"tag":     []interface{}{"north", "river"},
+					"empty":   nil,
+				}},
+				{Value: "plain"},
+			},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<feed><items>` +
+				`<item id="1"><empty></empty><name>Oak St. &amp; 5th</name><reading unit="c">12.5</reading><tag>north</tag><tag>river</tag></item>` +
+				`<item>plain</item>` +
+				`</items></feed>`,
+			nil},
+		{"tabular",
+			&dataset.Structure{Format: "xml", Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "name", "type": "string"},
+						map[string]interface{}{"title": "count", "type": "integer"},
+					},
+				},
+			}, FormatConfig: map[string]interface{}{
+				"recordPath": "row",
+			}},
+			[]Entry{
+				{Value: []interface{}{"a", int64(1)}},
+				{Value: []interface{}{"b", int64(2), true}},
+			},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<entries>` +
+				`<row><name>a</name><count>1</count></row>` +
+				`<row><name>b</name><count>2</count><item>true</item></row>` +
+				`</entries>`,
+			[]interface{}{
+				map[string]interface{}{"name": "a", "count": "1"},
+				map[string]interface{}{"name": "b", "count": "2", "item": "true"},
+			}},
+		{"binary",
+			&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray},
+			[]Entry{
+				{Value: map[string]interface{}{"@id": []byte("<\x00>"), "data": []byte{0x00, 0xff, '<', '&'}}},
+			},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<entries><entry id="PAA+"><data>AP88Jg==</data></entry></entries>`,
+			[]interface{}{
+				map[string]interface{}{"@id": "PAA+", "data": "AP88Jg=="},
+			}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ent := range c.entries {
+				if err := w.WriteEntry(ent); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, buf.String()); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+
+			if c.readBack == nil {
+				return
+			}
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAllArray(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.readBack, got); diff != "" {
+				t.Errorf("read back mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXMLWriterInvalidNames(t *testing.T) {
+	titled := &dataset.Structure{Format: "xml", Schema: tabular.Columns{{Title: "first name"}}.JSONSchema()}
+	if _, err := NewXMLWriter(titled, &bytes.Buffer{}); err == nil || err.Error() != `column title "first name" isn't a valid XML name` {
+		t.Errorf("expected invalid column title error. got: %v", err)
+	}
+
+	path := &dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"recordPath": "feed/1item"}}
+	if _, err := NewXMLWriter(path, &bytes.Buffer{}); err == nil || err.Error() != `recordPath element "1item" isn't a valid XML name` {
+		t.Errorf("expected invalid record path error. got: %v", err)
+	}
+
+	cases := []struct {
+		value interface{}
+		err   string
+	}{
+		{map[string]interface{}{"a b": 1}, `error encoding entry: key "a b" isn't a valid XML name`},
+		{map[string]interface{}{"ns:a": 1}, `error encoding entry: key "ns:a" isn't a valid XML name`},
+		{map[string]interface{}{"@a=b": 1}, `error encoding entry: key "@a=b" isn't a valid XML attribute name`},
+		{map[string]interface{}{"a": map[string]interface{}{"": 1}}, `error encoding entry: key "" isn't a valid XML name`},
+	}
+	for _, c := range cases {
+		w, err := NewXMLWriter(&dataset.Structure{Format: "xml", Schema: dataset.BaseSchemaArray}, &bytes.Buffer{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteEntry(Entry{Value: c.value}); err == nil || err.Error() != c.err {
+			t.Errorf("%v: error mismatch. expected: %q, got: %v", c.value, c.err, err)
+		}
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "xml",
+		Compression:  "gzip",
+		Schema:       dataset.BaseSchemaArray,
+		FormatConfig: map[string]interface{}{"recordPath": "feed/items/item"},
+	}
+
+	r, err := NewEntryReader(&dataset.Structure{
+		Format:       "xml",
+		Schema:       dataset.BaseSchemaArray,
+		FormatConfig: map[string]interface{}{"recordPath": "feed/items/item"},
+	}, strings.NewReader(xmlFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range expect {
+		if err := w.WriteEntry(Entry{Index: i, Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = NewEntryReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,391 @@
+package dsio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLReader implements the EntryReader interface for the YAML data format.
+// By default the body is a single YAML document whose top-level sequence items
+// or mapping keys are entries. In multi-document mode each document in a YAML
+// stream is an entry, like lines of NDJSON. Documents are parsed one at a time
+type YAMLReader struct {
+	st          *dataset.Structure
+	dec         *yaml.Decoder
+	multiDoc    bool
+	topLevel    string
+	root        *yaml.Node // top-level node of a single document body
+	entriesRead int
+	close       func() error // close func from wrapping
+}
+
+var _ EntryReader = (*YAMLReader)(nil)
+
+// NewYAMLReader creates a reader from a structure and read source
+func NewYAMLReader(st *dataset.Structure, r io.Reader) (*YAMLReader, error) {
+	if st.Schema == nil {
+		err := fmt.Errorf("schema required for YAML reader")
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	tlt, err := GetTopLevelType(st)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := yamlOptions(st)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MultiDocument && tlt != "array" {
+		return nil, fmt.Errorf("multi-document YAML top level type must be 'array'")
+	}
+
+	r, close, err := maybeWrapTextDecoder(st, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &YAMLReader{
+		st:       st,
+		dec:      yaml.NewDecoder(r),
+		multiDoc: opts.MultiDocument,
+		topLevel: tlt,
+		close:    close,
+	}, nil
+}
+
+// yamlOptions gets YAMLOptions from a structure
+func yamlOptions(st *dataset.Structure) (*dataset.YAMLOptions, error) {
+	if st.FormatConfig == nil {
+		return &dataset.YAMLOptions{}, nil
+	}
+	return dataset.NewYAMLOptions(st.FormatConfig)
+}
+
+// Structure gives this reader's structure
+func (r *YAMLReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one YAML entry from the reader
+func (r *YAMLReader) ReadEntry() (Entry, error) {
+	if r.multiDoc {
+		doc := &yaml.Node{}
+		if err := r.dec.Decode(doc); err != nil {
+			if err != io.EOF {
+				log.Debug(err.Error())
+			}
+			return Entry{}, err
+		}
+		v, err := yamlValue(doc)
+		if err != nil {
+			return Entry{}, err
+		}
+		ent := Entry{Index: r.entriesRead, Value: v}
+		r.entriesRead++
+		return ent, nil
+	}
+
+	if r.root == nil {
+		if err := r.readRoot(); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	switch r.topLevel {
+	case "array":
+		if r.entriesRead >= len(r.root.Content) {
+			return Entry{}, io.EOF
+		}
+		v, err := yamlValue(r.root.Content[r.entriesRead])
+		if err != nil {
+			return Entry{}, err
+		}
+		ent := Entry{Index: r.entriesRead, Value: v}
+		r.entriesRead++
+		return ent, nil
+	default:
+		if r.entriesRead*2 >= len(r.root.Content) {
+			return Entry{}, io.EOF
+		}
+		key := r.root.Content[r.entriesRead*2]
+		v, err := yamlValue(r.root.Content[r.entriesRead*2+1])
+		if err != nil {
+			return Entry{}, err
+		}
+		ent := Entry{Key: key.Value, Value: v}
+		r.entriesRead++
+		return ent, nil
+	}
+}
+
+// readRoot parses the single document of the body, checking its top level
+// node matches the schema's top level type
+func (r *YAMLReader) readRoot() error {
+	doc := &yaml.Node{}
+	if err := r.dec.Decode(doc); err != nil {
+		if err == io.EOF {
+			// an empty body has no entries
+			r.root = &yaml.Node{}
+			return nil
+		}
+		log.Debug(err.Error())
+		return err
+	}
+
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	for root.Kind == yaml.AliasNode {
+		root = root.Alias
+	}
+
+	switch {
+	case r.topLevel == "array" && root.Kind == yaml.SequenceNode:
+	case r.topLevel == "object" && root.Kind == yaml.MappingNode:
+		if err := yamlExpandMerges(root); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Expected YAML top level to be '%s'", r.topLevel)
+	}
+	r.root = root
+	return nil
+}
+
+// Close finalizes the reader
+func (r *YAMLReader) Close() error {
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+// yamlValue converts a YAML node to the go types other dsio writers
+// understand. Timestamps are kept as the strings they're written as
+func yamlValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(n.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+	case yaml.SequenceNode:
+		arr := make([]interface{}, len(n.Content))
+		for i, c := range n.Content {
+			v, err := yamlValue(c)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return arr, nil
+	case yaml.MappingNode:
+		if err := yamlExpandMerges(n); err != nil {
+			return nil, err
+		}
+		obj := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := yamlValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj[n.Content[i].Value] = v
+		}
+		return obj, nil
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!timestamp" {
+			return n.Value, nil
+		}
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported YAML node at line %d", n.Line)
+	}
+}
+
+// yamlExpandMerges replaces "<<" merge keys in a mapping node with the keys
+// they reference. Keys already present in the mapping take precedence
+func yamlExpandMerges(n *yaml.Node) error {
+	var merged []*yaml.Node
+	present := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].ShortTag() != "!!merge" {
+			present[n.Content[i].Value] = true
+		}
+	}
+
+	add := func(src *yaml.Node) error {
+		for src.Kind == yaml.AliasNode {
+			src = src.Alias
+		}
+		if src.Kind != yaml.MappingNode {
+			return fmt.Errorf("YAML merge at line %d must reference a mapping", src.Line)
+		}
+		if err := yamlExpandMerges(src); err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if key := src.Content[i].Value; !present[key] {
+				present[key] = true
+				merged = append(merged, src.Content[i], src.Content[i+1])
+			}
+		}
+		return nil
+	}
+
+	content := make([]*yaml.Node, 0, len(n.Content))
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].ShortTag() != "!!merge" {
+			content = append(content, n.Content[i], n.Content[i+1])
+			continue
+		}
+		val := n.Content[i+1]
+		if val.Kind == yaml.SequenceNode {
+			for _, src := range val.Content {
+				if err := add(src); err != nil {
+					return err
+				}
+			}
+		} else if err := add(val); err != nil {
+			return err
+		}
+	}
+	n.Content = append(content, merged...)
+	return nil
+}
+
+// YAMLWriter implements the EntryWriter interface for YAML-formatted data
+type YAMLWriter struct {
+	st             *dataset.Structure
+	w              io.Writer
+	enc            *yaml.Encoder // multi-document encoder
+	topLevel       string
+	entriesWritten int
+	close          func() error // close func from wrapping
+}
+
+var _ EntryWriter = (*YAMLWriter)(nil)
+
+// NewYAMLWriter creates a Writer from a structure and write destination
+func NewYAMLWriter(st *dataset.Structure, w io.Writer) (*YAMLWriter, error) {
+	if st.Schema == nil {
+		err := fmt.Errorf("schema required for YAML writer")
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	tlt, err := GetTopLevelType(st)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := yamlOptions(st)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MultiDocument && tlt != "array" {
+		return nil, fmt.Errorf("multi-document YAML top level type must be 'array'")
+	}
+
+	w, close, err := maybeWrapTextEncoder(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	yw := &YAMLWriter{
+		st:       st,
+		w:        w,
+		topLevel: tlt,
+		close:    close,
+	}
+	if opts.MultiDocument {
+		yw.enc = yaml.NewEncoder(w)
+		yw.enc.SetIndent(2)
+	}
+	return yw, nil
+}
+
+// Structure gives this writer's structure
+func (w *YAMLWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one YAML entry to the writer
+func (w *YAMLWriter) WriteEntry(ent Entry) error {
+	if w.enc != nil {
+		w.entriesWritten++
+		return w.enc.Encode(ent.Value)
+	}
+
+	var v interface{}
+	if w.topLevel == "array" {
+		v = []interface{}{ent.Value}
+	} else {
+		if ent.Key == "" {
+			log.Debug("write yaml: entry key cannot be empty")
+			return fmt.Errorf("entry key cannot be empty")
+		}
+		v = map[string]interface{}{ent.Key: ent.Value}
+	}
+
+	data, err := yamlMarshal(v)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+	w.entriesWritten++
+	return nil
+}
+
+// yamlMarshal encodes a value as YAML with two-space indentation
+func yamlMarshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *YAMLWriter) Close() error {
+	if w.enc != nil {
+		if err := w.enc.Close(); err != nil {
+			return err
+		}
+	} else if w.entriesWritten == 0 {
+		empty := "[]\n"
+		if w.topLevel == "object" {
+			empty = "{}\n"
+		}
+		if _, err := io.WriteString(w.w, empty); err != nil {
+			return err
+		}
+	}
+
+	if w.close != nil {
+		return w.close()
+	}
+	return nil
+}
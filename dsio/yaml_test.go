@@ -0,0 +1,225 @@
+package dsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+const yamlCities = `# cities
+defaults: &defaults
+  country: usa
+  active: true
+toronto:
+  pop: 2731571
+  area: 630.2
+  founded: 1793-08-27
+  <<: *defaults
+  country: canada
+new_york:
+  <<: *defaults
+  pop: 8405837
+  tags: [big, apple]
+`
+
+func TestYAMLReader(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		data        string
+		expect      interface{}
+		err         string
+	}{
+		{"no schema",
+			&dataset.Structure{Format: "yaml"},
+			"", nil, "schema required for YAML reader"},
+		{"multi-document object",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaObject, FormatConfig: map[string]interface{}{"multiDocument": true}},
+			"", nil, "multi-document YAML top level type must be 'array'"},
+		{"empty",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray},
+			"", []interface{}{}, ""},
+		{"sequence",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray},
+			"- a\n- 1\n- [2.5, null]\n- {b: false}\n",
+			[]interface{}{"a", 1, []interface{}{2.5, nil}, map[string]interface{}{"b": false}}, ""},
+		{"mapping",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaObject},
+			yamlCities,
+			map[string]interface{}{
+				"defaults": map[string]interface{}{"country": "usa", "active": true},
+				"toronto": map[string]interface{}{
+					"pop": 2731571, "area": 630.2, "founded": "1793-08-27", "country": "canada", "active": true,
+				},
+				"new_york": map[string]interface{}{
+					"pop": 8405837, "country": "usa", "active": true, "tags": []interface{}{"big", "apple"},
+				},
+			}, ""},
+		{"multi-document",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"multiDocument": true}},
+			"a: 1\n---\n- 2\n--- three\n...\n",
+			[]interface{}{map[string]interface{}{"a": 1}, []interface{}{2}, "three"}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := NewEntryReader(c.st, strings.NewReader(c.data))
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: '%s', got: '%v'", c.err, err)
+			} else if c.err != "" {
+				return
+			}
+
+			got, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestYAMLReaderErrors(t *testing.T) {
+	st := &dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray}
+	r, err := NewYAMLReader(st, strings.NewReader("a: b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil {
+		t.Error("expected reading a mapping as an array to error")
+	}
+
+	r, err = NewYAMLReader(st, strings.NewReader("- [a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil {
+		t.Error("expected reading invalid yaml to error")
+	}
+}
+
+func TestYAMLWriter(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		entries     []Entry
+		expect      string
+	}{
+		{"empty array",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray},
+			nil,
+			"[]\n"},
+		{"empty object",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaObject},
+			nil,
+			"{}\n"},
+		{"array",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray},
+			[]Entry{
+				{Value: "1793-08-27"},
+				{Value: map[string]interface{}{"a": []interface{}{int64(1), 2.5}}},
+			},
+			"- \"1793-08-27\"\n- a:\n    - 1\n    - 2.5\n"},
+		{"object",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaObject},
+			[]Entry{
+				{Key: "b", Value: true},
+				{Key: "a", Value: nil},
+			},
+			"b: true\na: null\n"},
+		{"multi-document",
+			&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"multiDocument": true}},
+			[]Entry{
+				{Value: map[string]interface{}{"a": int64(1)}},
+				{Value: "two"},
+			},
+			"a: 1\n---\ntwo\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ent := range c.entries {
+				if err := w.WriteEntry(ent); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, buf.String()); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	w, err := NewYAMLWriter(&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaObject}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: "no key"}); err == nil {
+		t.Error("expected writing an object entry without a key to error")
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+	}{
+		{"object", &dataset.Structure{Format: "yaml", Compression: "gzip", Schema: dataset.BaseSchemaObject}},
+		{"multi-document", &dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaArray, FormatConfig: map[string]interface{}{"multiDocument": true}}},
+	}
+
+	r, err := NewEntryReader(&dataset.Structure{Format: "yaml", Schema: dataset.BaseSchemaObject}, strings.NewReader(yamlCities))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := ReadAllObject(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, key := range []string{"defaults", "toronto", "new_york"} {
+				if err := w.WriteEntry(Entry{Index: i, Key: key, Value: expect[key]}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.st.Schema["type"] == "array" {
+				arr := got.([]interface{})
+				got = map[string]interface{}{"defaults": arr[0], "toronto": arr[1], "new_york": arr[2]}
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,689 @@
+package dsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
+)
+
+// expr is a node of a parsed expression. String gives the expression as query
+// text, which titles unaliased result columns
+type expr interface {
+	String() string
+}
+
+// colRef names a column, optionally qualified by a table
+type colRef struct {
+	table string
+	name  string
+}
+
+func (c *colRef) String() string {
+	if c.table != "" {
+		return c.table + "." + c.name
+	}
+	return c.name
+}
+
+// literal is a constant value: nil, a bool, int64, float64 or string
+type literal struct {
+	value interface{}
+}
+
+func (l *literal) String() string {
+	switch v := l.value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	}
+	return fmt.Sprint(l.value)
+}
+
+// unaryExpr is NOT or negation
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+func (u *unaryExpr) String() string {
+	x := operandString(u.x, precedence(u), false)
+	if u.op == "NOT" {
+		return "NOT " + x
+	}
+	if strings.HasPrefix(x, "-") {
+		// "--" starts a comment
+		x = "(" + x + ")"
+	}
+	return u.op + x
+}
+
+// binaryExpr is a logical, comparison or arithmetic operation
+type binaryExpr struct {
+	op   string
+	l, r expr
+}
+
+func (b *binaryExpr) String() string {
+	prec := precedence(b)
+	// comparisons don't chain, so operands that are comparisons need
+	// parentheses on either side
+	l := operandString(b.l, prec, prec == precComparison)
+	return l + " " + b.op + " " + operandString(b.r, prec, true)
+}
+
+// isNullExpr tests for null
+type isNullExpr struct {
+	x   expr
+	not bool
+}
+
+func (e *isNullExpr) String() string {
+	x := operandString(e.x, precComparison, true)
+	if e.not {
+		return x + " IS NOT NULL"
+	}
+	return x + " IS NULL"
+}
+
+// operator precedences, from loosest to tightest binding
+const (
+	precOr = iota + 1
+	precAnd
+	precNot
+	precComparison
+	precAdditive
+	precMultiplicative
+	precNegation
+	precPrimary
+)
+
+// precedence gives how tightly an expression's operator binds
+func precedence(e expr) int {
+	switch x := e.(type) {
+	case *binaryExpr:
+		switch x.op {
+		case "OR":
+			return precOr
+		case "AND":
+			return precAnd
+		case "+", "-":
+			return precAdditive
+		case "*", "/", "%":
+			return precMultiplicative
+		}
+		return precComparison
+	case *unaryExpr:
+		if x.op == "NOT" {
+			return precNot
+		}
+		return precNegation
+	case *isNullExpr:
+		return precComparison
+	}
+	return precPrimary
+}
+
+// operandString gives the text of an operand of an operator with precedence
+// prec, parenthesized when it binds looser than the operator, or as tightly
+// when equal precedences must be grouped
+func operandString(e expr, prec int, groupEqual bool) string {
+	if p := precedence(e); p < prec || p == prec && groupEqual {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// equalExprs reports if two expressions are the same expression tree
+func equalExprs(a, b expr) bool {
+	switch x := a.(type) {
+	case *colRef:
+		y, ok := b.(*colRef)
+		return ok && *x == *y
+	case *literal:
+		y, ok := b.(*literal)
+		return ok && x.value == y.value
+	case *unaryExpr:
+		y, ok := b.(*unaryExpr)
+		return ok && x.op == y.op && equalExprs(x.x, y.x)
+	case *binaryExpr:
+		y, ok := b.(*binaryExpr)
+		return ok && x.op == y.op && equalExprs(x.l, y.l) && equalExprs(x.r, y.r)
+	case *isNullExpr:
+		y, ok := b.(*isNullExpr)
+		return ok && x.not == y.not && equalExprs(x.x, y.x)
+	case *callExpr:
+		y, ok := b.(*callExpr)
+		if !ok || x.name != y.name || x.star != y.star || x.distinct != y.distinct || len(x.args) != len(y.args) {
+			return false
+		}
+		for i := range x.args {
+			if !equalExprs(x.args[i], y.args[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// callExpr is a call to a function. Names are upper case
+type callExpr struct {
+	name     string
+	args     []expr
+	star     bool
+	distinct bool
+}
+
+func (c *callExpr) String() string {
+	if c.star {
+		return c.name + "(*)"
+	}
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = arg.String()
+	}
+	if c.distinct {
+		return c.name + "(DISTINCT " + strings.Join(args, ", ") + ")"
+	}
+	return c.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// aggregates maps aggregate function names to aggregates
+var aggregates = map[string]dsio.Aggregate{
+	"COUNT": dsio.AggCount,
+	"SUM":   dsio.AggSum,
+	"MIN":   dsio.AggMin,
+	"MAX":   dsio.AggMax,
+	"AVG":   dsio.AggMean,
+	"MEAN":  dsio.AggMean,
+	"FIRST": dsio.AggFirst,
+	"LAST":  dsio.AggLast,
+}
+
+// isAggregate reports if an expression is an aggregate call
+func isAggregate(e expr) bool {
+	call, ok := e.(*callExpr)
+	if !ok {
+		return false
+	}
+	_, ok = aggregates[call.name]
+	return ok
+}
+
+// hasAggregate reports if an expression contains an aggregate call
+func hasAggregate(e expr) bool {
+	switch x := e.(type) {
+	case *callExpr:
+		if isAggregate(x) {
+			return true
+		}
+		for _, arg := range x.args {
+			if hasAggregate(arg) {
+				return true
+			}
+		}
+	case *unaryExpr:
+		return hasAggregate(x.x)
+	case *binaryExpr:
+		return hasAggregate(x.l) || hasAggregate(x.r)
+	case *isNullExpr:
+		return hasAggregate(x.x)
+	}
+	return false
+}
+
+// scopeColumn is a column rows can be read from, qualified by a table name
+type scopeColumn struct {
+	table string
+	col   tabular.Column
+}
+
+// scope is the columns of rows an expression is evaluated against
+type scope []scopeColumn
+
+// resolve finds the row index of a column reference
+func (s scope) resolve(ref *colRef) (int, error) {
+	found := -1
+	for i, sc := range s {
+		if sc.col.Title != ref.name || ref.table != "" && sc.table != ref.table {
+			continue
+		}
+		if found >= 0 {
+			return 0, fmt.Errorf("column reference %q is ambiguous", ref)
+		}
+		found = i
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("column %q not found", ref)
+	}
+	return found, nil
+}
+
+// evalFunc evaluates an expression against a row
+type evalFunc func(row []interface{}) (interface{}, error)
+
+// compile prepares an expression for evaluation against rows of a scope
+func compile(e expr, s scope) (evalFunc, error) {
+	switch x := e.(type) {
+	case *literal:
+		return func([]interface{}) (interface{}, error) { return x.value, nil }, nil
+	case *colRef:
+		idx, err := s.resolve(x)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []interface{}) (interface{}, error) {
+			if idx < len(row) {
+				return row[idx], nil
+			}
+			return nil, nil
+		}, nil
+	case *unaryExpr:
+		arg, err := compile(x.x, s)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []interface{}) (interface{}, error) {
+			v, err := arg(row)
+			if err != nil || v == nil {
+				return nil, err
+			}
+			if x.op == "NOT" {
+				b, ok := v.(bool)
+				if !ok {
+					return nil, fmt.Errorf("NOT: expected boolean, got %v", v)
+				}
+				return !b, nil
+			}
+			return arithmetic("*", int64(-1), v)
+		}, nil
+	case *isNullExpr:
+		arg, err := compile(x.x, s)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []interface{}) (interface{}, error) {
+			v, err := arg(row)
+			return (v == nil) != x.not, err
+		}, nil
+	case *binaryExpr:
+		return compileBinary(x, s)
+	case *callExpr:
+		if isAggregate(x) {
+			return nil, fmt.Errorf("aggregate %s isn't allowed here", x)
+		}
+		return compileCall(x, s)
+	}
+	return nil, fmt.Errorf("unsupported expression %s", e)
+}
+
+func compileBinary(x *binaryExpr, s scope) (evalFunc, error) {
+	l, err := compile(x.l, s)
+	if err != nil {
+		return nil, err
+	}
+	r, err := compile(x.r, s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x.op {
+	case "AND", "OR":
+		return func(row []interface{}) (interface{}, error) {
+			lv, err := logical(x.op, l, row)
+			if err != nil {
+				return nil, err
+			}
+			// short circuit: false AND x, true OR x
+			if lv != nil && lv.(bool) == (x.op == "OR") {
+				return lv, nil
+			}
+			rv, err := logical(x.op, r, row)
+			if err != nil || rv == nil {
+				return nil, err
+			}
+			if lv == nil {
+				if rv.(bool) == (x.op == "OR") {
+					return rv, nil
+				}
+				return nil, nil
+			}
+			return rv, nil
+		}, nil
+	case "LIKE":
+		var patterns = map[string]*regexp.Regexp{}
+		return func(row []interface{}) (interface{}, error) {
+			lv, rv, err := evalPair(l, r, row)
+			if err != nil || lv == nil || rv == nil {
+				return nil, err
+			}
+			str, ok := lv.(string)
+			pattern, pok := rv.(string)
+			if !ok || !pok {
+				return nil, fmt.Errorf("LIKE: expected strings, got %v & %v", lv, rv)
+			}
+			re, ok := patterns[pattern]
+			if !ok {
+				re = likePattern(pattern)
+				patterns[pattern] = re
+			}
+			return re.MatchString(str), nil
+		}, nil
+	}
+
+	if _, ok := comparisonOps[x.op]; ok {
+		return func(row []interface{}) (interface{}, error) {
+			lv, rv, err := evalPair(l, r, row)
+			if err != nil || lv == nil || rv == nil {
+				return nil, err
+			}
+			c, err := dsio.CompareValues(lv, rv)
+			if err != nil {
+				return nil, err
+			}
+			switch x.op {
+			case "=":
+				return c == 0, nil
+			case "!=":
+				return c != 0, nil
+			case "<":
+				return c < 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">":
+				return c > 0, nil
+			}
+			return c >= 0, nil
+		}, nil
+	}
+
+	return func(row []interface{}) (interface{}, error) {
+		lv, rv, err := evalPair(l, r, row)
+		if err != nil || lv == nil || rv == nil {
+			return nil, err
+		}
+		return arithmetic(x.op, lv, rv)
+	}, nil
+}
+
+func evalPair(l, r evalFunc, row []interface{}) (interface{}, interface{}, error) {
+	lv, err := l(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	rv, err := r(row)
+	return lv, rv, err
+}
+
+// logical evaluates an operand of AND or OR, which must be boolean or null
+func logical(op string, fn evalFunc, row []interface{}) (interface{}, error) {
+	v, err := fn(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if _, ok := v.(bool); !ok {
+		return nil, fmt.Errorf("%s: expected boolean, got %v", op, v)
+	}
+	return v, nil
+}
+
+// likePattern converts a LIKE pattern to a regular expression. % matches any
+// run of characters & _ matches a single character
+func likePattern(pattern string) *regexp.Regexp {
+	sb := &strings.Builder{}
+	sb.WriteString("^(?s:")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString(")$")
+	return regexp.MustCompile(sb.String())
+}
+
+// number converts a numeric value to an int64 or float64, reporting false
+// for other values
+func number(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int8:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case uint8:
+		return int64(x), true
+	case uint16:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case uint:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i, true
+		}
+		f, err := x.Float64()
+		return f, err == nil
+	case *big.Int:
+		if x.IsInt64() {
+			return x.Int64(), true
+		}
+		f, _ := new(big.Float).SetInt(x).Float64()
+		return f, true
+	case *big.Float:
+		f, _ := x.Float64()
+		return f, true
+	}
+	return nil, false
+}
+
+// arithmetic applies an arithmetic operator. Integer operands give integers,
+// with division truncated towards zero
+func arithmetic(op string, a, b interface{}) (interface{}, error) {
+	an, ok := number(a)
+	if !ok {
+		return nil, fmt.Errorf("%s: non-numeric value %v", op, a)
+	}
+	bn, ok := number(b)
+	if !ok {
+		return nil, fmt.Errorf("%s: non-numeric value %v", op, b)
+	}
+
+	ai, aInt := an.(int64)
+	bi, bInt := bn.(int64)
+	if aInt && bInt {
+		switch op {
+		case "+":
+			return ai + bi, nil
+		case "-":
+			return ai - bi, nil
+		case "*":
+			return ai * bi, nil
+		}
+		if bi == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			return ai / bi, nil
+		}
+		return ai % bi, nil
+	}
+
+	af, bf := toFloat(an), toFloat(bn)
+	switch op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	}
+	if bf == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if op == "/" {
+		return af / bf, nil
+	}
+	return math.Mod(af, bf), nil
+}
+
+func toFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+// scalarFuncs are functions of one argument evaluated on each row. Null
+// arguments give null
+var scalarFuncs = map[string]func(v interface{}) (interface{}, error){
+	"LOWER": func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("LOWER: expected string, got %v", v)
+		}
+		return strings.ToLower(s), nil
+	},
+	"UPPER": func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("UPPER: expected string, got %v", v)
+		}
+		return strings.ToUpper(s), nil
+	},
+	"LENGTH": func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("LENGTH: expected string, got %v", v)
+		}
+		return int64(utf8.RuneCountInString(s)), nil
+	},
+	"ABS": func(v interface{}) (interface{}, error) {
+		n, ok := number(v)
+		if !ok {
+			return nil, fmt.Errorf("ABS: non-numeric value %v", v)
+		}
+		if i, ok := n.(int64); ok {
+			if i < 0 {
+				return -i, nil
+			}
+			return i, nil
+		}
+		return math.Abs(n.(float64)), nil
+	},
+}
+
+func compileCall(x *callExpr, s scope) (evalFunc, error) {
+	if x.star || x.distinct {
+		return nil, fmt.Errorf("invalid call %s", x)
+	}
+	args := make([]evalFunc, len(x.args))
+	for i, arg := range x.args {
+		fn, err := compile(arg, s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = fn
+	}
+
+	if x.name == "COALESCE" {
+		return func(row []interface{}) (interface{}, error) {
+			for _, arg := range args {
+				v, err := arg(row)
+				if err != nil || v != nil {
+					return v, err
+				}
+			}
+			return nil, nil
+		}, nil
+	}
+
+	fn, ok := scalarFuncs[x.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", x.name)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s takes 1 argument, got %d", x.name, len(args))
+	}
+	return func(row []interface{}) (interface{}, error) {
+		v, err := args[0](row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		return fn(v)
+	}, nil
+}
+
+// exprColumn describes the values of an expression as a result column
+func exprColumn(e expr, s scope) tabular.Column {
+	var t *tabular.ColType
+	switch x := e.(type) {
+	case *colRef:
+		if idx, err := s.resolve(x); err == nil {
+			t = s[idx].col.Type
+		}
+	case *literal:
+		switch x.value.(type) {
+		case bool:
+			t = &tabular.ColType{"boolean"}
+		case int64:
+			t = &tabular.ColType{"integer"}
+		case float64:
+			t = &tabular.ColType{"number"}
+		case string:
+			t = &tabular.ColType{"string"}
+		}
+	case *isNullExpr:
+		t = &tabular.ColType{"boolean"}
+	case *unaryExpr:
+		if x.op == "NOT" {
+			t = &tabular.ColType{"boolean", "null"}
+		} else {
+			t = &tabular.ColType{"number", "null"}
+		}
+	case *binaryExpr:
+		switch x.op {
+		case "+", "-", "*", "/", "%":
+			t = &tabular.ColType{"number", "null"}
+		default:
+			t = &tabular.ColType{"boolean", "null"}
+		}
+	case *callExpr:
+		switch x.name {
+		case "LOWER", "UPPER":
+			t = &tabular.ColType{"string", "null"}
+		case "LENGTH":
+			t = &tabular.ColType{"integer", "null"}
+		case "ABS":
+			t = &tabular.ColType{"number", "null"}
+		}
+	}
+	return tabular.Column{Title: e.String(), Type: t}
+}
@@ -0,0 +1,143 @@
+package dsql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset/tabular"
+)
+
+var exprScope = scope{
+	{table: "t", col: tabular.Column{Title: "name", Type: &tabular.ColType{"string"}}},
+	{table: "t", col: tabular.Column{Title: "n", Type: &tabular.ColType{"integer"}}},
+	{table: "t", col: tabular.Column{Title: "x", Type: &tabular.ColType{"number"}}},
+	{table: "u", col: tabular.Column{Title: "n"}},
+	{table: "u", col: tabular.Column{Title: "missing"}},
+}
+
+func TestCompile(t *testing.T) {
+	row := []interface{}{"Ada", int64(7), 2.5, json.Number("3")}
+	cases := []struct {
+		expr   string
+		expect interface{}
+		err    string
+	}{
+		{"t.n + 1", int64(8), ""},
+		{"t.n / 2", int64(3), ""},
+		{"t.n % 4", int64(3), ""},
+		{"t.n / 2.0", 3.5, ""},
+		{"t.n * x - u.n", 14.5, ""},
+		{"-x", -2.5, ""},
+		{"t.n / 0", nil, "division by zero"},
+		{"name + 1", nil, "+: non-numeric value Ada"},
+		{"u.n = 3.0", true, ""},
+		{"name > 'B'", false, ""},
+		{"t.n != 7", false, ""},
+		{"missing = 1", nil, ""},
+		{"missing IS NULL", true, ""},
+		{"name IS NOT NULL", true, ""},
+		{"missing = 1 OR t.n = 7", true, ""},
+		{"missing = 1 AND t.n = 7", nil, ""},
+		{"missing = 1 AND t.n = 6", false, ""},
+		{"NOT missing = 1", nil, ""},
+		{"name AND TRUE", nil, "AND: expected boolean, got Ada"},
+		{"name LIKE 'A_a'", true, ""},
+		{"name LIKE '%d%'", true, ""},
+		{"name LIKE 'a%'", false, ""},
+		{"name NOT LIKE 'x.%'", true, ""},
+		{"LOWER(name) || 1", nil, "position 19: unexpected character '|'"},
+		{"UPPER(name)", "ADA", ""},
+		{"LENGTH(name) + ABS(-2)", int64(5), ""},
+		{"COALESCE(missing, NULL, name)", "Ada", ""},
+		{"n", nil, `column reference "n" is ambiguous`},
+		{"t.nope", nil, `column "t.nope" not found`},
+		{"SUM(x)", nil, "aggregate SUM(x) isn't allowed here"},
+		{"NOPE(x)", nil, "unknown function NOPE"},
+		{"ABS(x, x)", nil, "ABS takes 1 argument, got 2"},
+	}
+
+	for _, c := range cases {
+		stmt, err := parse("SELECT " + c.expr + " FROM t")
+		if err != nil {
+			if err.Error() != c.err {
+				t.Errorf("%s: unexpected parse error: %s", c.expr, err)
+			}
+			continue
+		}
+		eval, err := compile(stmt.items[0].expr, exprScope)
+		if err == nil {
+			var got interface{}
+			got, err = eval(row)
+			if err == nil {
+				if diff := cmp.Diff(c.expect, got); diff != "" {
+					t.Errorf("%s: result mismatch (-want +got):\n%s", c.expr, diff)
+				}
+			}
+		}
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%s: error mismatch. expected: %q, got: %v", c.expr, c.err, err)
+		}
+	}
+}
+
+func TestExprString(t *testing.T) {
+	cases := []struct {
+		expr   string
+		expect string
+	}{
+		{"(a + 1) * 2", "(a + 1) * 2"},
+		{"a + 1 * 2", "a + 1 * 2"},
+		{"a - b - c", "a - b - c"},
+		{"a - (b - c)", "a - (b - c)"},
+		{"-(a * b)", "-(a * b)"},
+		{"- -a", "-(-a)"},
+		{"NOT (a AND b)", "NOT (a AND b)"},
+		{"(a OR b) AND c", "(a OR b) AND c"},
+		{"(a = b) = c", "(a = b) = c"},
+		{"(a = 1) IS NULL", "(a = 1) IS NULL"},
+		{"a + 1 IS NOT NULL", "a + 1 IS NOT NULL"},
+		{"ABS(a - 1) / 2", "ABS(a - 1) / 2"},
+	}
+	for _, c := range cases {
+		stmt, err := parse("SELECT " + c.expr + " FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := stmt.items[0].expr
+		if got := e.String(); got != c.expect {
+			t.Errorf("%s: expected: %q, got: %q", c.expr, c.expect, got)
+			continue
+		}
+		// the text of an expression must parse to the same expression
+		stmt, err = parse("SELECT " + e.String() + " FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalExprs(e, stmt.items[0].expr) {
+			t.Errorf("%s: expression text parses to a different expression", c.expr)
+		}
+	}
+}
+
+func TestExprColumn(t *testing.T) {
+	cases := []struct {
+		expr   string
+		expect tabular.Column
+	}{
+		{"t.n", tabular.Column{Title: "t.n", Type: &tabular.ColType{"integer"}}},
+		{"'a'", tabular.Column{Title: "'a'", Type: &tabular.ColType{"string"}}},
+		{"t.n * 2", tabular.Column{Title: "t.n * 2", Type: &tabular.ColType{"number", "null"}}},
+		{"x > 1", tabular.Column{Title: "x > 1", Type: &tabular.ColType{"boolean", "null"}}},
+		{"COALESCE(x, 1)", tabular.Column{Title: "COALESCE(x, 1)"}},
+	}
+	for _, c := range cases {
+		stmt, err := parse("SELECT " + c.expr + " FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(c.expect, exprColumn(stmt.items[0].expr, exprScope)); diff != "" {
+			t.Errorf("%s: column mismatch (-want +got):\n%s", c.expr, diff)
+		}
+	}
+}
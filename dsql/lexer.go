@@ -0,0 +1,152 @@
+package dsql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind enumerates the kinds of lexical tokens
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	// tokIdent is a bare word: a keyword, name or function
+	tokIdent
+	// tokQuotedIdent is a "double quoted" or `backtick quoted` name, which is
+	// never a keyword
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokSymbol
+)
+
+// token is a lexical token of a query
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// String gives a description of the token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// is reports if a token is a bare word matching a keyword, case-insensitively
+func (t token) is(keyword string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, keyword)
+}
+
+// symbols are the operators & punctuation of the language, longest first
+var symbols = []string{"<=", ">=", "<>", "!=", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ".", ";"}
+
+// lex splits a query into tokens, ending with an EOF token
+func lex(query string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(query); {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case strings.HasPrefix(query[i:], "--"):
+			// line comment
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case r == '\'':
+			text, n, err := lexQuoted(query[i:], '\'')
+			if err != nil {
+				return nil, fmt.Errorf("position %d: unterminated string", i)
+			}
+			toks = append(toks, token{kind: tokString, text: text, pos: i})
+			i += n
+		case r == '"' || r == '`':
+			text, n, err := lexQuoted(query[i:], byte(r))
+			if err != nil {
+				return nil, fmt.Errorf("position %d: unterminated quoted name", i)
+			}
+			toks = append(toks, token{kind: tokQuotedIdent, text: text, pos: i})
+			i += n
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			n := lexNumber(query[i:])
+			toks = append(toks, token{kind: tokNumber, text: query[i : i+n], pos: i})
+			i += n
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(query) {
+				r, size := utf8.DecodeRuneInString(query[i:])
+				if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			toks = append(toks, token{kind: tokIdent, text: query[start:i], pos: start})
+		default:
+			matched := false
+			for _, sym := range symbols {
+				if strings.HasPrefix(query[i:], sym) {
+					toks = append(toks, token{kind: tokSymbol, text: sym, pos: i})
+					i += len(sym)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("position %d: unexpected character %q", i, r)
+			}
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(query)}), nil
+}
+
+// lexQuoted reads text between quote characters, where a doubled quote is an
+// escaped quote. It returns the unescaped text & the number of bytes read
+func lexQuoted(s string, quote byte) (string, int, error) {
+	sb := &strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				sb.WriteByte(quote)
+				i++
+				continue
+			}
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteByte(s[i])
+	}
+	return "", 0, fmt.Errorf("unterminated")
+}
+
+// lexNumber gives the length of the number at the start of s
+func lexNumber(s string) int {
+	i := 0
+	digits := func() {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(s) && s[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
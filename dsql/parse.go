@@ -0,0 +1,526 @@
+package dsql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset/dsio"
+)
+
+// selectStmt is a parsed SELECT query
+type selectStmt struct {
+	items   []selectItem
+	from    tableRef
+	joins   []joinClause
+	where   expr
+	groupBy []expr
+	orderBy []orderItem
+	// limit is -1 for queries without a LIMIT clause
+	limit  int
+	offset int
+}
+
+// selectItem is a column of query results. star items select every column,
+// or every column of one table when table is set
+type selectItem struct {
+	expr  expr
+	alias string
+	star  bool
+	table string
+}
+
+// tableRef names a queried table
+type tableRef struct {
+	name  string
+	alias string
+}
+
+// scopeName gives the name columns of the table are qualified by
+func (t tableRef) scopeName() string {
+	if t.alias != "" {
+		return t.alias
+	}
+	return t.name
+}
+
+// joinClause joins a table on a condition
+type joinClause struct {
+	jt    dsio.JoinType
+	table tableRef
+	on    expr
+}
+
+// orderItem is a sort key of query results
+type orderItem struct {
+	expr       expr
+	descending bool
+}
+
+// keywords are reserved words that can't be used as unquoted names
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "BY": true,
+	"ORDER": true, "ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "FULL": true, "OUTER": true,
+	"ON": true, "AS": true, "AND": true, "OR": true, "NOT": true, "IS": true,
+	"NULL": true, "TRUE": true, "FALSE": true, "LIKE": true, "DISTINCT": true,
+}
+
+// parser is a recursive descent parser of queries
+type parser struct {
+	toks []token
+	pos  int
+}
+
+// parse parses a query
+func parse(query string) (*selectStmt, error) {
+	toks, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	p.acceptSymbol(";")
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return stmt, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("position %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+// acceptKeyword consumes the next token if it's the given keyword
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.peek().is(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if tok := p.peek(); !p.acceptKeyword(keyword) {
+		return p.errorf(tok, "expected %s, got %s", keyword, tok)
+	}
+	return nil
+}
+
+// acceptSymbol consumes the next token if it's the given symbol
+func (p *parser) acceptSymbol(sym string) bool {
+	if tok := p.peek(); tok.kind == tokSymbol && tok.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(sym string) error {
+	if tok := p.peek(); !p.acceptSymbol(sym) {
+		return p.errorf(tok, "expected %q, got %s", sym, tok)
+	}
+	return nil
+}
+
+// isName reports if a token can be a table, column or alias name
+func isName(tok token) bool {
+	return tok.kind == tokQuotedIdent || tok.kind == tokIdent && !keywords[strings.ToUpper(tok.text)]
+}
+
+func (p *parser) parseName(what string) (string, error) {
+	tok := p.next()
+	if !isName(tok) {
+		return "", p.errorf(tok, "expected %s, got %s", what, tok)
+	}
+	return tok.text, nil
+}
+
+// parseAlias parses an optional alias, with or without AS
+func (p *parser) parseAlias() (string, error) {
+	if p.acceptKeyword("AS") {
+		return p.parseName("alias")
+	}
+	if isName(p.peek()) {
+		return p.next().text, nil
+	}
+	return "", nil
+}
+
+func (p *parser) parseSelect() (*selectStmt, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	stmt := &selectStmt{limit: -1}
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		stmt.items = append(stmt.items, item)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	var err error
+	if stmt.from, err = p.parseTableRef(); err != nil {
+		return nil, err
+	}
+
+	for {
+		jt, ok, err := p.parseJoinType()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		join := joinClause{jt: jt}
+		if join.table, err = p.parseTableRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		if join.on, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		stmt.joins = append(stmt.joins, join)
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if stmt.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			stmt.groupBy = append(stmt.groupBy, e)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{expr: e}
+			if p.acceptKeyword("DESC") {
+				item.descending = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, item)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		if stmt.limit, err = p.parseCount("LIMIT"); err != nil {
+			return nil, err
+		}
+		if p.acceptKeyword("OFFSET") {
+			if stmt.offset, err = p.parseCount("OFFSET"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return stmt, nil
+}
+
+// parseCount parses the non-negative integer of a LIMIT or OFFSET clause
+func (p *parser) parseCount(clause string) (int, error) {
+	tok := p.next()
+	n, err := strconv.Atoi(tok.text)
+	if tok.kind != tokNumber || err != nil || n < 0 {
+		return 0, p.errorf(tok, "%s must be a non-negative integer, got %s", clause, tok)
+	}
+	return n, nil
+}
+
+func (p *parser) parseSelectItem() (selectItem, error) {
+	if p.acceptSymbol("*") {
+		return selectItem{star: true}, nil
+	}
+	// table.*
+	if isName(p.peek()) && p.pos+2 < len(p.toks) && p.toks[p.pos+1].text == "." && p.toks[p.pos+2].kind == tokSymbol && p.toks[p.pos+2].text == "*" {
+		table := p.next().text
+		p.pos += 2
+		return selectItem{star: true, table: table}, nil
+	}
+
+	e, err := p.parseExpr()
+	if err != nil {
+		return selectItem{}, err
+	}
+	alias, err := p.parseAlias()
+	return selectItem{expr: e, alias: alias}, err
+}
+
+func (p *parser) parseTableRef() (tableRef, error) {
+	name, err := p.parseName("table name")
+	if err != nil {
+		return tableRef{}, err
+	}
+	alias, err := p.parseAlias()
+	return tableRef{name: name, alias: alias}, err
+}
+
+// parseJoinType parses the keywords that start a join clause
+func (p *parser) parseJoinType() (jt dsio.JoinType, ok bool, err error) {
+	switch {
+	case p.acceptKeyword("JOIN"):
+		return dsio.InnerJoin, true, nil
+	case p.acceptKeyword("INNER"):
+		jt = dsio.InnerJoin
+	case p.acceptKeyword("LEFT"):
+		jt = dsio.LeftJoin
+		p.acceptKeyword("OUTER")
+	case p.acceptKeyword("FULL"):
+		jt = dsio.FullOuterJoin
+		p.acceptKeyword("OUTER")
+	default:
+		return jt, false, nil
+	}
+	return jt, true, p.expectKeyword("JOIN")
+}
+
+// parseExpr parses an expression. From loosest to tightest binding, operators
+// are OR, AND, NOT, comparisons, addition & subtraction, multiplication,
+// division & modulo, then negation
+func (p *parser) parseExpr() (expr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "NOT", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOps = map[string]string{"=": "=", "!=": "!=", "<>": "!=", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+func (p *parser) parseComparison() (expr, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if op, ok := comparisonOps[tok.text]; ok && tok.kind == tokSymbol {
+		p.pos++
+		r, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: op, l: l, r: r}, nil
+	}
+
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNullExpr{x: l, not: not}, nil
+	}
+
+	not := false
+	if tok.is("NOT") && p.toks[p.pos+1].is("LIKE") {
+		p.pos++
+		not = true
+	}
+	if p.acceptKeyword("LIKE") {
+		r, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		var like expr = &binaryExpr{op: "LIKE", l: l, r: r}
+		if not {
+			like = &unaryExpr{op: "NOT", x: like}
+		}
+		return like, nil
+	}
+	return l, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokSymbol || tok.text != "+" && tok.text != "-" {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: tok.text, l: l, r: r}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokSymbol || tok.text != "*" && tok.text != "/" && tok.text != "%" {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: tok.text, l: l, r: r}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.acceptSymbol("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "-", x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokNumber:
+		if i, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return &literal{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %s", tok)
+		}
+		return &literal{value: f}, nil
+	case tok.kind == tokString:
+		return &literal{value: tok.text}, nil
+	case tok.is("NULL"):
+		return &literal{}, nil
+	case tok.is("TRUE"):
+		return &literal{value: true}, nil
+	case tok.is("FALSE"):
+		return &literal{value: false}, nil
+	case tok.kind == tokSymbol && tok.text == "(":
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expectSymbol(")")
+	case tok.kind == tokIdent && p.peek().kind == tokSymbol && p.peek().text == "(":
+		return p.parseCall(tok)
+	case isName(tok):
+		if p.acceptSymbol(".") {
+			name, err := p.parseName("column name")
+			if err != nil {
+				return nil, err
+			}
+			return &colRef{table: tok.text, name: name}, nil
+		}
+		return &colRef{name: tok.text}, nil
+	}
+	return nil, p.errorf(tok, "unexpected %s", tok)
+}
+
+// parseCall parses the arguments of a function call
+func (p *parser) parseCall(name token) (expr, error) {
+	p.pos++ // (
+	call := &callExpr{name: strings.ToUpper(name.text)}
+	if p.acceptSymbol(")") {
+		return call, nil
+	}
+	if p.acceptSymbol("*") {
+		call.star = true
+		return call, p.expectSymbol(")")
+	}
+	call.distinct = p.acceptKeyword("DISTINCT")
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	return call, p.expectSymbol(")")
+}
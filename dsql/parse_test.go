@@ -0,0 +1,129 @@
+package dsql
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestParse(t *testing.T) {
+	stmt, err := parse(`
+		SELECT u.name AS who, COUNT(*), sum(o.total) total, o.*
+		FROM users u
+		LEFT OUTER JOIN "order items" o ON u.id = o.user_id AND o.region = u.region
+		WHERE NOT o.total IS NULL AND (u.name LIKE 'a%' OR u.age >= -2.5e1) -- trailing comment
+		GROUP BY u.name
+		ORDER BY 2 DESC, who
+		LIMIT 10 OFFSET 5;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []string{}
+	for _, item := range stmt.items {
+		switch {
+		case item.star:
+			items = append(items, item.table+".*")
+		default:
+			items = append(items, item.expr.String()+" -> "+item.alias)
+		}
+	}
+	expectItems := []string{"u.name -> who", "COUNT(*) -> ", "SUM(o.total) -> total", "o.*"}
+	if diff := cmp.Diff(expectItems, items); diff != "" {
+		t.Errorf("select items mismatch (-want +got):\n%s", diff)
+	}
+
+	if stmt.from != (tableRef{name: "users", alias: "u"}) {
+		t.Errorf("from mismatch. got: %#v", stmt.from)
+	}
+	if len(stmt.joins) != 1 {
+		t.Fatalf("expected 1 join, got %d", len(stmt.joins))
+	}
+	join := stmt.joins[0]
+	if join.jt != dsio.LeftJoin || join.table != (tableRef{name: "order items", alias: "o"}) {
+		t.Errorf("join mismatch. got: %s %#v", join.jt, join.table)
+	}
+	if got := join.on.String(); got != "u.id = o.user_id AND o.region = u.region" {
+		t.Errorf("join condition mismatch. got: %s", got)
+	}
+	if got := stmt.where.String(); got != "NOT o.total IS NULL AND (u.name LIKE 'a%' OR u.age >= -25)" {
+		t.Errorf("where mismatch. got: %s", got)
+	}
+	if or, ok := stmt.where.(*binaryExpr).r.(*binaryExpr); !ok || or.op != "OR" {
+		t.Errorf("expected parenthesized OR to bind tighter than AND")
+	}
+	if len(stmt.groupBy) != 1 || stmt.groupBy[0].String() != "u.name" {
+		t.Errorf("group by mismatch. got: %v", stmt.groupBy)
+	}
+	if len(stmt.orderBy) != 2 || stmt.orderBy[0].expr.String() != "2" || !stmt.orderBy[0].descending || stmt.orderBy[1].descending {
+		t.Errorf("order by mismatch. got: %v", stmt.orderBy)
+	}
+	if stmt.limit != 10 || stmt.offset != 5 {
+		t.Errorf("limit mismatch. got: %d offset %d", stmt.limit, stmt.offset)
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	cases := []struct {
+		query  string
+		expect string
+	}{
+		{"SELECT 1 + 2 * 3 FROM t", "+(1, *(2, 3))"},
+		{"SELECT (1 + 2) * 3 FROM t", "*(+(1, 2), 3)"},
+		{"SELECT a OR b AND NOT c FROM t", "OR(a, AND(b, NOT(c)))"},
+		{"SELECT a - b - c FROM t", "-(-(a, b), c)"},
+		{"SELECT a NOT LIKE 'x' FROM t", "NOT(LIKE(a, 'x'))"},
+		{"SELECT a <> b FROM t", "!=(a, b)"},
+		{"SELECT -a % 2 FROM t", "%(-(a), 2)"},
+		{"SELECT \"select\", `it's` FROM t", "select"},
+	}
+	for _, c := range cases {
+		stmt, err := parse(c.query)
+		if err != nil {
+			t.Errorf("%s: %s", c.query, err)
+			continue
+		}
+		if got := tree(stmt.items[0].expr); got != c.expect {
+			t.Errorf("%s: expected: %s, got: %s", c.query, c.expect, got)
+		}
+	}
+}
+
+// tree writes an expression with explicit structure
+func tree(e expr) string {
+	switch x := e.(type) {
+	case *binaryExpr:
+		return x.op + "(" + tree(x.l) + ", " + tree(x.r) + ")"
+	case *unaryExpr:
+		return x.op + "(" + tree(x.x) + ")"
+	}
+	return e.String()
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		err   string
+	}{
+		{"", "position 0: expected SELECT, got end of query"},
+		{"SELECT a", "position 8: expected FROM, got end of query"},
+		{"SELECT a FROM", "position 13: expected table name, got end of query"},
+		{"SELECT a FROM t WHERE", "position 21: unexpected end of query"},
+		{"SELECT a FROM t LIMIT -1", `position 22: LIMIT must be a non-negative integer, got "-"`},
+		{"SELECT a FROM t LEFT t2 ON a = b", `position 21: expected JOIN, got "t2"`},
+		{"SELECT a FROM t JOIN t2", "position 23: expected ON, got end of query"},
+		{"SELECT 'abc FROM t", "position 7: unterminated string"},
+		{"SELECT a FROM t ORDER a", `position 22: expected BY, got "a"`},
+		{"SELECT a FROM t t2 t3", `position 19: unexpected "t3"`},
+		{"SELECT a # b FROM t", "position 9: unexpected character '#'"},
+		{"SELECT count(a FROM t", `position 15: expected ")", got "FROM"`},
+		{"SELECT a IS b FROM t", `position 12: expected NULL, got "b"`},
+	}
+	for _, c := range cases {
+		_, err := parse(c.query)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q error mismatch. expected: %q, got: %v", c.query, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+// Package dsql runs SQL queries against dataset bodies. Queries are a subset of
+// SQL's SELECT statement: result columns, FROM, INNER, LEFT & FULL OUTER
+// JOIN, WHERE, GROUP BY, ORDER BY, LIMIT & OFFSET. Tables are tabular
+// dsio.EntryReaders, named by the caller, and columns are named by the titles
+// of a table's schema. Grouped rows can only be ordered by result columns.
+// Queries are planned into chains of dsio readers, so results are read as a
+// stream of entries
+package dsql
+
+import (
+	"fmt"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
+)
+
+var log = logger.Logger("dsql")
+
+// Syntax is the TransformStep syntax of queries
+const Syntax = "qri-sql"
+
+// Config configures how queries are run
+type Config struct {
+	// MergeJoins joins tables with sort-merge joins that spill to disk. By
+	// default tables are joined with hash joins, which hold the rows of each
+	// joined table in memory
+	MergeJoins bool
+	// SortMemoryBudget is the approximate number of bytes of rows ORDER BY
+	// holds in memory before spilling to temporary files
+	SortMemoryBudget int64
+	// TempDir is the directory sorts spill to, defaulting to the system
+	// temporary directory
+	TempDir string
+}
+
+// DefaultConfig returns the default configuration for queries
+func DefaultConfig() *Config {
+	return &Config{
+		SortMemoryBudget: dsio.DefaultSortMemoryBudget,
+	}
+}
+
+// Query runs a query against tables, giving a reader of result rows. The
+// structure of results keeps the format of the FROM table & has a tabular
+// schema describing result columns. Each table can only be read once per
+// query. Closing the result closes the readers of queried tables
+func Query(query string, tables map[string]dsio.EntryReader, options ...func(*Config)) (dsio.EntryReader, error) {
+	cfg := DefaultConfig()
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	stmt, err := parse(query)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("parsing query: %w", err)
+	}
+	pl := &planner{cfg: cfg, tables: tables, used: map[string]bool{}, names: map[string]bool{}}
+	return pl.plan(stmt)
+}
+
+// QueryStep runs the script of a transform step with the qri-sql syntax
+func QueryStep(step *dataset.TransformStep, tables map[string]dsio.EntryReader, options ...func(*Config)) (dsio.EntryReader, error) {
+	if step.Syntax != Syntax {
+		return nil, fmt.Errorf("unsupported transform syntax %q", step.Syntax)
+	}
+	switch script := step.Script.(type) {
+	case string:
+		return Query(script, tables, options...)
+	case []byte:
+		return Query(string(script), tables, options...)
+	}
+	return nil, fmt.Errorf("expected query script to be a string, got %T", step.Script)
+}
+
+// planner builds a chain of readers that computes a query
+type planner struct {
+	cfg    *Config
+	tables map[string]dsio.EntryReader
+	// used are the names of tables that have been read
+	used map[string]bool
+	// names are the names tables are qualified by
+	names map[string]bool
+}
+
+func (pl *planner) plan(stmt *selectStmt) (dsio.EntryReader, error) {
+	r, s, err := pl.open(stmt.from)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, join := range stmt.joins {
+		if r, s, err = pl.join(r, s, join); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.where != nil {
+		if r, err = where(r, s, stmt.where); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		outputs []expr
+		// hidden is the number of columns added after result columns to sort
+		// by columns that aren't selected
+		hidden int
+	)
+	grouped := len(stmt.groupBy) > 0
+	for _, item := range stmt.items {
+		if !item.star && hasAggregate(item.expr) {
+			grouped = true
+		}
+	}
+	if grouped {
+		r, outputs, err = group(r, s, stmt)
+	} else {
+		r, outputs, hidden, err = project(r, s, stmt.items, stmt.orderBy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmt.orderBy) > 0 {
+		if r, err = pl.orderBy(r, outputs, hidden, stmt.orderBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.limit >= 0 || stmt.offset > 0 {
+		index := 0
+		paged := &dsio.PagedReader{Reader: r, Limit: stmt.limit, Offset: stmt.offset}
+		r = dsio.NewMapReader(paged, nil, func(ent dsio.Entry) (dsio.Entry, error) {
+			ent.Index = index
+			index++
+			return ent, nil
+		})
+	}
+
+	return r, nil
+}
+
+// open gives the reader & scope of a queried table
+func (pl *planner) open(ref tableRef) (dsio.EntryReader, scope, error) {
+	r, ok := pl.tables[ref.name]
+	if !ok {
+		return nil, nil, fmt.Errorf("table %q not found", ref.name)
+	}
+	if pl.used[ref.name] {
+		return nil, nil, fmt.Errorf("table %q is read more than once", ref.name)
+	}
+	if pl.names[ref.scopeName()] {
+		return nil, nil, fmt.Errorf("table name %q is used more than once", ref.scopeName())
+	}
+	pl.used[ref.name] = true
+	pl.names[ref.scopeName()] = true
+
+	cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("table %q: %w", ref.name, err)
+	}
+	s := make(scope, len(cols))
+	for i, col := range cols {
+		s[i] = scopeColumn{table: ref.scopeName(), col: col}
+	}
+	return r, s, nil
+}
+
+// join joins a table to the rows read so far. Join conditions must be
+// equalities between a column of each side, combined with AND
+func (pl *planner) join(left dsio.EntryReader, ls scope, join joinClause) (dsio.EntryReader, scope, error) {
+	right, rs, err := pl.open(join.table)
+	if err != nil {
+		return nil, nil, err
+	}
+	leftTitles, err := titles(left)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var on []dsio.JoinOn
+	for _, cond := range conjunction(join.on) {
+		eq, ok := cond.(*binaryExpr)
+		if !ok || eq.op != "=" {
+			return nil, nil, fmt.Errorf("join condition %s must compare columns with =", cond)
+		}
+		a, aok := eq.l.(*colRef)
+		b, bok := eq.r.(*colRef)
+		if !aok || !bok {
+			return nil, nil, fmt.Errorf("join condition %s must compare columns with =", cond)
+		}
+		li, lerr := ls.resolve(a)
+		ri, rerr := rs.resolve(b)
+		if lerr != nil || rerr != nil {
+			li, lerr = ls.resolve(b)
+			ri, rerr = rs.resolve(a)
+		}
+		if lerr != nil || rerr != nil {
+			return nil, nil, fmt.Errorf("join condition %s must compare a column of %q with a column of the tables before it", cond, join.table.scopeName())
+		}
+		on = append(on, dsio.JoinOn{Left: leftTitles[li], Right: rs[ri].col.Title})
+	}
+
+	var jr *dsio.JoinReader
+	if pl.cfg.MergeJoins {
+		jr, err = dsio.NewMergeJoinReader(left, right, on, join.jt)
+	} else {
+		jr, err = dsio.NewHashJoinReader(left, right, on, join.jt)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	joined := make(scope, 0, len(ls)+len(rs))
+	joined = append(append(joined, ls...), rs...)
+	return jr, joined, nil
+}
+
+// conjunction splits an expression into the operands of AND operators
+func conjunction(e expr) []expr {
+	if b, ok := e.(*binaryExpr); ok && b.op == "AND" {
+		return append(conjunction(b.l), conjunction(b.r)...)
+	}
+	return []expr{e}
+}
+
+// titles gives the column titles of a reader's schema
+func titles(r dsio.EntryReader) ([]string, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+	if err != nil {
+		return nil, err
+	}
+	return cols.Titles(), nil
+}
+
+// rowValues gives the values of a tabular entry
+func rowValues(ent dsio.Entry) ([]interface{}, error) {
+	row, ok := ent.Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("entry %d: expected array row, got %T", ent.Index, ent.Value)
+	}
+	return row, nil
+}
+
+// where filters rows that don't match a condition. Null conditions don't
+// match
+func where(r dsio.EntryReader, s scope, cond expr) (dsio.EntryReader, error) {
+	eval, err := compile(cond, s)
+	if err != nil {
+		return nil, fmt.Errorf("WHERE: %w", err)
+	}
+	return dsio.NewFilterReader(r, func(ent dsio.Entry) (bool, error) {
+		row, err := rowValues(ent)
+		if err != nil {
+			return false, err
+		}
+		v, err := eval(row)
+		if err != nil || v == nil {
+			return false, err
+		}
+		keep, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("WHERE condition must be boolean, got %v", v)
+		}
+		return keep, nil
+	}), nil
+}
+
+// project evaluates result columns for each row. ORDER BY expressions that
+// aren't result columns are evaluated into hidden columns added after result
+// columns, for orderBy to sort by & drop. It returns the expressions of all
+// columns & the number of hidden columns
+func project(r dsio.EntryReader, s scope, items []selectItem, orderBy []orderItem) (dsio.EntryReader, []expr, int, error) {
+	sourceTitles, err := titles(r)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var (
+		cols    tabular.Columns
+		evals   []evalFunc
+		outputs []expr
+	)
+	for _, item := range items {
+		if item.star {
+			matched := false
+			for i, sc := range s {
+				if item.table != "" && sc.table != item.table {
+					continue
+				}
+				matched = true
+				idx := i
+				evals = append(evals, func(row []interface{}) (interface{}, error) {
+					if idx < len(row) {
+						return row[idx], nil
+					}
+					return nil, nil
+				})
+				col := sc.col
+				col.Title = sourceTitles[i]
+				cols = append(cols, col)
+				outputs = append(outputs, &colRef{table: sc.table, name: sc.col.Title})
+			}
+			if !matched {
+				return nil, nil, 0, fmt.Errorf("table %q not found", item.table)
+			}
+			continue
+		}
+
+		eval, err := compile(item.expr, s)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		col := exprColumn(item.expr, s)
+		if ref, ok := item.expr.(*colRef); ok {
+			col.Title = ref.name
+		}
+		if item.alias != "" {
+			col.Title = item.alias
+		}
+		evals = append(evals, eval)
+		cols = append(cols, col)
+		outputs = append(outputs, item.expr)
+	}
+
+	hidden := 0
+	for _, item := range orderBy {
+		if _, ok := item.expr.(*literal); ok || resultIndex(cols.Titles(), outputs, item.expr) >= 0 {
+			continue
+		}
+		eval, err := compile(item.expr, s)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("ORDER BY: %w", err)
+		}
+		evals = append(evals, eval)
+		cols = append(cols, exprColumn(item.expr, s))
+		outputs = append(outputs, item.expr)
+		hidden++
+	}
+
+	cols = tabular.Columns{}.Merge(cols)
+	return dsio.NewMapReader(r, cols.JSONSchema(), func(ent dsio.Entry) (dsio.Entry, error) {
+		row, err := rowValues(ent)
+		if err != nil {
+			return ent, err
+		}
+		values := make([]interface{}, len(evals))
+		for i, eval := range evals {
+			if values[i], err = eval(row); err != nil {
+				return ent, err
+			}
+		}
+		ent.Value = values
+		return ent, nil
+	}), outputs, hidden, nil
+}
+
+// group groups rows & computes aggregates. Result columns must be grouped
+// columns or aggregates of a column
+func group(r dsio.EntryReader, s scope, stmt *selectStmt) (dsio.EntryReader, []expr, error) {
+	sourceTitles, err := titles(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, len(stmt.groupBy))
+	keyIndexes := make([]int, len(stmt.groupBy))
+	for i, e := range stmt.groupBy {
+		ref, ok := e.(*colRef)
+		if !ok {
+			return nil, nil, fmt.Errorf("GROUP BY %s: only columns can be grouped by", e)
+		}
+		idx, err := s.resolve(ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("GROUP BY: %w", err)
+		}
+		keys[i], keyIndexes[i] = sourceTitles[idx], idx
+	}
+
+	var (
+		aggs []dsio.AggregateSpec
+		// positions are the columns of grouped rows that give each result
+		// column
+		positions []int
+		names     []string
+		outputs   []expr
+	)
+	for _, item := range stmt.items {
+		if item.star {
+			return nil, nil, fmt.Errorf("* can't be selected from grouped rows")
+		}
+		title := item.alias
+		switch x := item.expr.(type) {
+		case *colRef:
+			idx, err := s.resolve(x)
+			if err != nil {
+				return nil, nil, err
+			}
+			pos := -1
+			for i, k := range keyIndexes {
+				if k == idx {
+					pos = i
+				}
+			}
+			if pos < 0 {
+				return nil, nil, fmt.Errorf("column %q must be in GROUP BY or used in an aggregate", x)
+			}
+			positions = append(positions, pos)
+			if title == "" {
+				title = x.name
+			}
+		case *callExpr:
+			spec, err := aggregateSpec(x, s, sourceTitles)
+			if err != nil {
+				return nil, nil, err
+			}
+			spec.Title = fmt.Sprintf("agg_%d", len(aggs))
+			aggs = append(aggs, spec)
+			positions = append(positions, len(keys)+len(aggs)-1)
+			if title == "" {
+				title = x.String()
+			}
+		default:
+			return nil, nil, fmt.Errorf("%s must be a grouped column or an aggregate", item.expr)
+		}
+		names = append(names, title)
+		outputs = append(outputs, item.expr)
+	}
+
+	gr, err := dsio.NewHashGroupReader(r, keys, aggs)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupCols, _, err := tabular.ColumnsFromJSONSchema(gr.Structure().Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	cols := make(tabular.Columns, len(positions))
+	for i, pos := range positions {
+		cols[i] = groupCols[pos]
+		cols[i].Title = names[i]
+	}
+	cols = tabular.Columns{}.Merge(cols)
+
+	return dsio.NewMapReader(gr, cols.JSONSchema(), func(ent dsio.Entry) (dsio.Entry, error) {
+		row, err := rowValues(ent)
+		if err != nil {
+			return ent, err
+		}
+		values := make([]interface{}, len(positions))
+		for i, pos := range positions {
+			values[i] = row[pos]
+		}
+		ent.Value = values
+		return ent, nil
+	}), outputs, nil
+}
+
+// aggregateSpec converts an aggregate call to an aggregate of a column
+func aggregateSpec(call *callExpr, s scope, sourceTitles []string) (dsio.AggregateSpec, error) {
+	agg, ok := aggregates[call.name]
+	if !ok {
+		return dsio.AggregateSpec{}, fmt.Errorf("%s must be a grouped column or an aggregate", call)
+	}
+	if call.star {
+		if agg != dsio.AggCount {
+			return dsio.AggregateSpec{}, fmt.Errorf("invalid aggregate %s", call)
+		}
+		return dsio.AggregateSpec{Func: agg}, nil
+	}
+	if call.distinct {
+		if agg != dsio.AggCount {
+			return dsio.AggregateSpec{}, fmt.Errorf("DISTINCT is only supported by COUNT, got %s", call)
+		}
+		agg = dsio.AggDistinctCount
+	}
+	if len(call.args) != 1 {
+		return dsio.AggregateSpec{}, fmt.Errorf("%s takes 1 argument, got %d", call.name, len(call.args))
+	}
+	ref, ok := call.args[0].(*colRef)
+	if !ok {
+		return dsio.AggregateSpec{}, fmt.Errorf("%s: only columns can be aggregated", call)
+	}
+	idx, err := s.resolve(ref)
+	if err != nil {
+		return dsio.AggregateSpec{}, fmt.Errorf("%s: %w", call, err)
+	}
+	return dsio.AggregateSpec{Func: agg, Column: sourceTitles[idx]}, nil
+}
+
+// orderBy sorts result rows. Sort keys are result columns, named by title,
+// by the expression that selects them or by position counting from 1, or the
+// hidden columns project adds after result columns, which are dropped once
+// rows are sorted. Grouped rows can only be sorted by result columns
+func (pl *planner) orderBy(r dsio.EntryReader, outputs []expr, hidden int, items []orderItem) (dsio.EntryReader, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+	if err != nil {
+		return nil, err
+	}
+	resultTitles := cols.Titles()
+	visible := len(resultTitles) - hidden
+
+	keys := make([]dsio.SortKey, len(items))
+	for i, item := range items {
+		idx := -1
+		if x, ok := item.expr.(*literal); ok {
+			n, ok := x.value.(int64)
+			if !ok || n < 1 || int(n) > visible {
+				return nil, fmt.Errorf("ORDER BY position %s is out of range", x)
+			}
+			idx = int(n) - 1
+		} else {
+			idx = resultIndex(resultTitles, outputs, item.expr)
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("ORDER BY %s must be a result column", item.expr)
+		}
+		keys[i] = dsio.SortKey{Column: resultTitles[idx], Descending: item.descending}
+	}
+
+	sr, err := dsio.NewSortedReader(r, keys)
+	if err != nil {
+		return nil, err
+	}
+	sr.MemoryBudget = pl.cfg.SortMemoryBudget
+	sr.TempDir = pl.cfg.TempDir
+	if hidden == 0 {
+		return sr, nil
+	}
+
+	return dsio.NewMapReader(sr, cols[:visible].JSONSchema(), func(ent dsio.Entry) (dsio.Entry, error) {
+		row, err := rowValues(ent)
+		if err != nil {
+			return ent, err
+		}
+		ent.Value = row[:visible]
+		return ent, nil
+	}), nil
+}
+
+// resultIndex finds the column an ORDER BY expression sorts by, matching
+// unqualified column names to column titles, then expressions to the
+// expressions that select columns. It gives -1 when no column matches
+func resultIndex(resultTitles []string, outputs []expr, e expr) int {
+	if ref, ok := e.(*colRef); ok && ref.table == "" {
+		for i, title := range resultTitles {
+			if title == ref.name {
+				return i
+			}
+		}
+	}
+	for i, out := range outputs {
+		if equalExprs(out, e) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,246 @@
+package dsql
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
+)
+
+func testTables(t *testing.T) map[string]dsio.EntryReader {
+	users, err := dsio.NewCSVReader(&dataset.Structure{
+		Format: "csv",
+		Schema: tabular.Columns{
+			{Title: "id", Type: &tabular.ColType{"integer"}},
+			{Title: "name", Type: &tabular.ColType{"string"}},
+			{Title: "region", Type: &tabular.ColType{"string"}},
+		}.JSONSchema(),
+	}, strings.NewReader("1,ada,east\n2,bab,west\n3,cat,east\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := dsio.NewJSONReader(&dataset.Structure{
+		Format: "json",
+		Schema: tabular.Columns{
+			{Title: "id", Type: &tabular.ColType{"integer"}},
+			{Title: "user_id", Type: &tabular.ColType{"integer"}},
+			{Title: "total", Type: &tabular.ColType{"number"}},
+		}.JSONSchema(),
+	}, strings.NewReader(`[[10,1,5.5],[11,2,3],[12,1,1.5],[13,4,9]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]dsio.EntryReader{"users": users, "orders": orders}
+}
+
+func readRows(t *testing.T, r dsio.EntryReader) []interface{} {
+	t.Helper()
+	rows := []interface{}{}
+	for i := 0; ; i++ {
+		ent, err := r.ReadEntry()
+		if err == io.EOF {
+			return rows
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if ent.Index != i {
+			t.Errorf("row %d: unexpected index %d", i, ent.Index)
+		}
+		rows = append(rows, ent.Value)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	cases := []struct {
+		description string
+		query       string
+		options     []func(*Config)
+		titles      []string
+		rows        []interface{}
+	}{
+		{"filter & project",
+			"SELECT name, id * 10 AS tens FROM users WHERE region = 'east' ORDER BY name DESC", nil,
+			[]string{"name", "tens"},
+			[]interface{}{
+				[]interface{}{"cat", int64(30)},
+				[]interface{}{"ada", int64(10)},
+			},
+		},
+		{"inner join",
+			"SELECT u.name, o.total FROM users u JOIN orders o ON u.id = o.user_id ORDER BY o.total", nil,
+			[]string{"name", "total"},
+			[]interface{}{
+				[]interface{}{"ada", 1.5},
+				[]interface{}{"bab", int64(3)},
+				[]interface{}{"ada", 5.5},
+			},
+		},
+		{"left join & group",
+			"SELECT u.name, COUNT(*) AS n, SUM(o.total) FROM users u LEFT JOIN orders o ON o.user_id = u.id GROUP BY u.name ORDER BY n DESC, name", nil,
+			[]string{"name", "n", "SUM(o.total)"},
+			[]interface{}{
+				[]interface{}{"ada", int64(2), 7.0},
+				[]interface{}{"bab", int64(1), int64(3)},
+				[]interface{}{"cat", int64(1), nil},
+			},
+		},
+		{"aggregates without group",
+			"SELECT COUNT(DISTINCT user_id) users, MAX(total), AVG(total) FROM orders", nil,
+			[]string{"users", "MAX(total)", "AVG(total)"},
+			[]interface{}{
+				[]interface{}{int64(3), int64(9), 4.75},
+			},
+		},
+		{"limit & offset",
+			"SELECT * FROM orders ORDER BY 1 LIMIT 2 OFFSET 1", nil,
+			[]string{"id", "user_id", "total"},
+			[]interface{}{
+				[]interface{}{int64(11), int64(2), int64(3)},
+				[]interface{}{int64(12), int64(1), 1.5},
+			},
+		},
+		{"full outer merge join",
+			"SELECT u.id, o.id FROM users u FULL OUTER JOIN orders o ON u.id = o.user_id ORDER BY 2, 1",
+			[]func(*Config){func(cfg *Config) { cfg.MergeJoins = true }},
+			[]string{"id", "id_2"},
+			[]interface{}{
+				[]interface{}{int64(3), nil},
+				[]interface{}{int64(1), int64(10)},
+				[]interface{}{int64(2), int64(11)},
+				[]interface{}{int64(1), int64(12)},
+				[]interface{}{nil, int64(13)},
+			},
+		},
+		{"order by unselected column",
+			"SELECT name FROM users ORDER BY region DESC, id DESC", nil,
+			[]string{"name"},
+			[]interface{}{
+				[]interface{}{"bab"},
+				[]interface{}{"cat"},
+				[]interface{}{"ada"},
+			},
+		},
+		{"order by expression",
+			"SELECT (id + 1) * 2, id % 2 FROM users ORDER BY id % 2, (id + 1) * 2 DESC", nil,
+			[]string{"(id + 1) * 2", "id % 2"},
+			[]interface{}{
+				[]interface{}{int64(6), int64(0)},
+				[]interface{}{int64(8), int64(1)},
+				[]interface{}{int64(4), int64(1)},
+			},
+		},
+		{"star of joined tables",
+			"SELECT o.*, u.* FROM users u JOIN orders o ON u.id = o.user_id WHERE o.id = 11", nil,
+			[]string{"id_2", "user_id", "total", "id", "name", "region"},
+			[]interface{}{
+				[]interface{}{int64(11), int64(2), int64(3), int64(2), "bab", "west"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := Query(c.query, testTables(t), c.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cols, _, err := tabular.ColumnsFromJSONSchema(r.Structure().Schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.titles, cols.Titles()); diff != "" {
+				t.Errorf("titles mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(c.rows, readRows(t, r)); diff != "" {
+				t.Errorf("rows mismatch (-want +got):\n%s", diff)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestQueryStructure(t *testing.T) {
+	r, err := Query("SELECT name, id > 1 AS later, COUNT(*) FROM users GROUP BY name", testTables(t))
+	if err == nil || err.Error() != "id > 1 must be a grouped column or an aggregate" {
+		t.Errorf("expected grouped expression error. got: %v", err)
+	}
+
+	if r, err = Query("SELECT name, id > 1 AS later FROM users", testTables(t)); err != nil {
+		t.Fatal(err)
+	}
+	expect := &dataset.Structure{
+		Qri:    dataset.KindStructure.String(),
+		Format: "csv",
+		Schema: tabular.Columns{
+			{Title: "name", Type: &tabular.ColType{"string"}},
+			{Title: "later", Type: &tabular.ColType{"boolean", "null"}},
+		}.JSONSchema(),
+	}
+	if diff := cmp.Diff(expect, r.Structure()); diff != "" {
+		t.Errorf("structure mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		err   string
+	}{
+		{"SELECT FROM users", `parsing query: position 7: unexpected "FROM"`},
+		{"SELECT * FROM people", `table "people" not found`},
+		{"SELECT * FROM users a JOIN users b ON a.id = b.id", `table "users" is read more than once`},
+		{"SELECT * FROM users o JOIN orders o ON o.id = o.id", `table name "o" is used more than once`},
+		{"SELECT * FROM users u JOIN orders o ON u.id < o.user_id", "join condition u.id < o.user_id must compare columns with ="},
+		{"SELECT * FROM users u JOIN orders o ON u.id = 1", "join condition u.id = 1 must compare columns with ="},
+		{"SELECT * FROM users u JOIN orders o ON u.id = u.name", `join condition u.id = u.name must compare a column of "o" with a column of the tables before it`},
+		{"SELECT x.* FROM users", `table "x" not found`},
+		{"SELECT nope FROM users", `column "nope" not found`},
+		{"SELECT * FROM users WHERE SUM(id) > 1", "WHERE: aggregate SUM(id) isn't allowed here"},
+		{"SELECT name, COUNT(*) FROM users GROUP BY region", `column "name" must be in GROUP BY or used in an aggregate`},
+		{"SELECT * FROM users GROUP BY region", "* can't be selected from grouped rows"},
+		{"SELECT region FROM users GROUP BY LOWER(region)", "GROUP BY LOWER(region): only columns can be grouped by"},
+		{"SELECT SUM(id + 1) FROM users", "SUM(id + 1): only columns can be aggregated"},
+		{"SELECT SUM(DISTINCT id) FROM users", "DISTINCT is only supported by COUNT, got SUM(DISTINCT id)"},
+		{"SELECT region, COUNT(*) FROM users GROUP BY region ORDER BY name", "ORDER BY name must be a result column"},
+		{"SELECT name FROM users ORDER BY nope", `ORDER BY: column "nope" not found`},
+		{"SELECT name FROM users ORDER BY 2", "ORDER BY position 2 is out of range"},
+	}
+	for _, c := range cases {
+		_, err := Query(c.query, testTables(t))
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q error mismatch. expected: %q, got: %v", c.query, c.err, err)
+		}
+	}
+
+	r, err := Query("SELECT * FROM users WHERE name", testTables(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err == nil || err.Error() != "WHERE condition must be boolean, got ada" {
+		t.Errorf("expected non-boolean condition error. got: %v", err)
+	}
+}
+
+func TestQueryStep(t *testing.T) {
+	step := &dataset.TransformStep{Syntax: "qri-sql", Script: "SELECT COUNT(*) FROM orders"}
+	r, err := QueryStep(step, testTables(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{[]interface{}{int64(4)}}, readRows(t, r)); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := QueryStep(&dataset.TransformStep{Syntax: "starlark", Script: "print('hi')"}, testTables(t)); err == nil || err.Error() != `unsupported transform syntax "starlark"` {
+		t.Errorf("expected syntax error. got: %v", err)
+	}
+	if _, err := QueryStep(&dataset.TransformStep{Syntax: Syntax, Script: 5}, testTables(t)); err == nil || err.Error() != "expected query script to be a string, got int" {
+		t.Errorf("expected script type error. got: %v", err)
+	}
+}
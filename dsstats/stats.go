@@ -2,8 +2,12 @@
 package dsstats
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"sort"
+	"strings"
 
 	"github.com/axiomhq/hyperloglog"
 	topk "github.com/dgryski/go-topk"
@@ -31,6 +35,12 @@ var (
 // Calculate determines a stats component by reading each entry in the Body of a
 // given dataset. Requires an open BodyFile and well-formed Structure component
 func Calculate(ds *dataset.Dataset) (st *dataset.Stats, err error) {
+	return CalculateContext(context.Background(), ds)
+}
+
+// CalculateContext is Calculate with a context, stopping with the context's
+// error when the context is cancelled
+func CalculateContext(ctx context.Context, ds *dataset.Dataset) (st *dataset.Stats, err error) {
 	body := ds.BodyFile()
 	if body == nil {
 		return nil, fmt.Errorf("stats: dataset has no body file")
@@ -44,16 +54,22 @@ func Calculate(ds *dataset.Dataset) (st *dataset.Stats, err error) {
 		return nil, err
 	}
 
-	return CalculateFromEntryReader(r)
+	return CalculateFromEntryReaderContext(ctx, r)
 }
 
 // CalculateFromEntryReader consumes an entry reader to generate a Stats
 // component
 func CalculateFromEntryReader(r dsio.EntryReader) (st *dataset.Stats, err error) {
+	return CalculateFromEntryReaderContext(context.Background(), r)
+}
+
+// CalculateFromEntryReaderContext is CalculateFromEntryReader with a context,
+// stopping with the context's error when the context is cancelled
+func CalculateFromEntryReaderContext(ctx context.Context, r dsio.EntryReader) (st *dataset.Stats, err error) {
 	acc := NewAccumulator(r.Structure())
 	defer acc.Close()
 
-	err = dsio.EachEntry(r, func(i int, ent dsio.Entry, e error) error {
+	err = dsio.EachEntry(dsio.NewContextReader(ctx, r), func(i int, ent dsio.Entry, e error) error {
 		if e != nil {
 			return e
 		}
@@ -61,6 +77,9 @@ func CalculateFromEntryReader(r dsio.EntryReader) (st *dataset.Stats, err error)
 		return nil
 	})
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -170,7 +189,15 @@ func newAccumulator(val interface{}) accumulator {
 		return &nullAcc{}
 	case float64, float32:
 		return newNumericAcc("number")
-	case int, int32, int64:
+	case int, int32, int64, *big.Int:
+		return newNumericAcc("integer")
+	case *big.Float:
+		return newNumericAcc("number")
+	case json.Number:
+		// decimal mode numbers
+		if strings.ContainsAny(string(val.(json.Number)), ".eE") {
+			return newNumericAcc("number")
+		}
 		return newNumericAcc("integer")
 	case string:
 		return newStringAcc()
@@ -340,6 +367,22 @@ func (acc *numericAcc) Write(e dsio.Entry) {
 		v = float64(x)
 	case float64:
 		v = x
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return
+		}
+		v = f
+	case *big.Int:
+		if x == nil {
+			return
+		}
+		v, _ = new(big.Float).SetInt(x).Float64()
+	case *big.Float:
+		if x == nil {
+			return
+		}
+		v, _ = x.Float64()
 	default:
 		return
 	}
@@ -1,6 +1,7 @@
 package dsstats
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -527,3 +528,46 @@ func TestLongStringValues(t *testing.T) {
 		t.Errorf("result mismatch (-want +got):%s\n", diff)
 	}
 }
+
+func TestCalculateContext(t *testing.T) {
+	ds := &dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2,3]`)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CalculateContext(ctx, ds); err != context.Canceled {
+		t.Errorf("expected context.Canceled. got: %v", err)
+	}
+}
+
+func TestExactNumbers(t *testing.T) {
+	for _, mode := range []string{"decimal", "big"} {
+		t.Run(mode, func(t *testing.T) {
+			ds := &dataset.Dataset{
+				Structure: &dataset.Structure{
+					Format:       dataset.NDJSONDataFormat.String(),
+					FormatConfig: map[string]interface{}{"numbers": mode},
+					Schema:       dataset.BaseSchemaArray,
+				},
+			}
+			data := "{\"i\":1,\"f\":0.5}\n{\"i\":3,\"f\":1.5}\n{\"i\":null,\"f\":1}\n"
+			ds.SetBodyFile(qfs.NewMemfileBytes("body.ndjson", []byte(data)))
+
+			got, err := Calculate(ds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary := map[string][]interface{}{}
+			for _, st := range got.Stats.([]map[string]interface{}) {
+				summary[st["key"].(string)] = []interface{}{st["type"], st["count"], st["min"], st["max"], st["mean"]}
+			}
+			expect := map[string][]interface{}{
+				"f": {"numeric", 3, 0.5, 1.5, 1.0},
+				"i": {"numeric", 2, 1.0, 3.0, 2.0},
+			}
+			if diff := cmp.Diff(expect, summary); diff != "" {
+				t.Errorf("result mismatch (-want +got):%s\n", diff)
+			}
+		})
+	}
+}
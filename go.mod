@@ -1,27 +1,62 @@
 module github.com/qri-io/dataset
 
-go 1.15
+go 1.18
 
 require (
 	github.com/360EntSecGroup-Skylar/excelize v1.4.1
+	github.com/andybalholm/brotli v1.0.4
+	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516
 	github.com/axiomhq/hyperloglog v0.0.0-20191112132149-a4c4c47bc57f
-	github.com/dgryski/go-sip13 v0.0.0-20200911182023-62edffca9245 // indirect
 	github.com/dgryski/go-topk v0.0.0-20191119021947-593b4f2374c9
+	github.com/dsnet/compress v0.0.1
 	github.com/google/go-cmp v0.5.5
 	github.com/ipfs/go-log v1.0.5
 	github.com/jinzhu/copier v0.0.0-20190924061706-b57f9002281a
-	github.com/k0kubun/colorstring v0.0.0-20150214042306-9440f1994b88 // indirect
-	github.com/klauspost/compress v1.13.0
+	github.com/klauspost/compress v1.17.0
 	github.com/libp2p/go-libp2p-core v0.8.5
+	github.com/linkedin/goavro/v2 v2.10.1
+	github.com/mattn/go-sqlite3 v1.14.8
 	github.com/mr-tron/base58 v1.2.0
 	github.com/multiformats/go-multihash v0.0.15
+	github.com/pierrec/lz4/v4 v4.1.8
 	github.com/qri-io/compare v0.1.0
 	github.com/qri-io/jsonschema v0.2.2-0.20210618085106-a515144d7449
 	github.com/qri-io/qfs v0.6.1-0.20210629014446-45bdcdb57434
 	github.com/qri-io/varName v0.1.0
-	github.com/sergi/go-diff v1.1.0 // indirect
 	github.com/ugorji/go/codec v1.1.7
+	github.com/ulikunitz/xz v0.5.10
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
 	github.com/yudai/gojsondiff v1.0.0
+	golang.org/x/text v0.3.6
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/apache/thrift v0.14.2 // indirect
+	github.com/btcsuite/btcd v0.21.0-beta // indirect
+	github.com/dgryski/go-metro v0.0.0-20180109044635-280f6062b5bc // indirect
+	github.com/dgryski/go-sip13 v0.0.0-20200911182023-62edffca9245 // indirect
+	github.com/gogo/protobuf v1.3.2 // indirect
+	github.com/golang/snappy v0.0.3 // indirect
+	github.com/google/flatbuffers v1.11.0 // indirect
+	github.com/ipfs/go-log/v2 v2.1.3 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.4 // indirect
+	github.com/libp2p/go-openssl v0.0.7 // indirect
+	github.com/minio/blake2b-simd v0.0.0-20160723061019-3f5f724cb5b1 // indirect
+	github.com/minio/sha256-simd v1.0.0 // indirect
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/multiformats/go-varint v0.0.6 // indirect
+	github.com/opentracing/opentracing-go v1.2.0 // indirect
+	github.com/qri-io/jsonpointer v0.1.1 // indirect
+	github.com/sergi/go-diff v1.1.0 // indirect
+	github.com/spacemonkeygo/spacelog v0.0.0-20180420211403-2296661a0572 // indirect
 	github.com/yudai/golcs v0.0.0-20170316035057-ecda9a501e82 // indirect
 	github.com/yudai/pp v2.0.1+incompatible // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	go.uber.org/zap v1.16.0 // indirect
+	golang.org/x/crypto v0.0.0-20210506145944-38f3c27a63bf // indirect
+	golang.org/x/sys v0.0.0-20210511113859-b0526f3d8744 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
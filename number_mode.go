@@ -0,0 +1,45 @@
+package dataset
+
+import "fmt"
+
+// NumberMode determines the go types readers decode numbers into. Formats
+// that support it configure a mode with the "numbers" format config option
+type NumberMode string
+
+const (
+	// NumbersNative decodes integers as int64 & all other numbers as float64.
+	// Numbers that don't fit in 64 bits lose precision. This is the default
+	NumbersNative NumberMode = ""
+	// NumbersDecimal decodes numbers as json.Number values, keeping the exact
+	// decimal text of each number
+	NumbersDecimal NumberMode = "decimal"
+	// NumbersBig decodes integers as *big.Int & all other numbers as
+	// *big.Float, with enough precision for every digit of the number
+	NumbersBig NumberMode = "big"
+)
+
+// ParseNumberMode interprets a string into a number mode. "native" and the
+// empty string are both the default mode
+func ParseNumberMode(s string) (NumberMode, error) {
+	switch s {
+	case "", "native":
+		return NumbersNative, nil
+	case "decimal":
+		return NumbersDecimal, nil
+	case "big":
+		return NumbersBig, nil
+	}
+	return NumbersNative, fmt.Errorf("invalid numbers value: %s", s)
+}
+
+// numberModeOption reads the "numbers" option from a format config map
+func numberModeOption(opts map[string]interface{}) (NumberMode, error) {
+	if opts["numbers"] == nil {
+		return NumbersNative, nil
+	}
+	s, ok := opts["numbers"].(string)
+	if !ok {
+		return NumbersNative, fmt.Errorf("invalid numbers value: %v", opts["numbers"])
+	}
+	return ParseNumberMode(s)
+}
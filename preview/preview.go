@@ -91,7 +91,7 @@ func Create(ctx context.Context, ds *dataset.Dataset) (*dataset.Dataset, error)
 			size = int(sf.Size())
 		}
 
-		data, err := dsio.ConvertFile(teedFile, ds.Structure, st, MaxNumDatasetRowsInPreview, 0, false)
+		data, err := dsio.ConvertFileContext(ctx, teedFile, ds.Structure, st, MaxNumDatasetRowsInPreview, 0, false)
 		if err != nil {
 			log.Debugw("converting body file", "err", err.Error())
 			return nil, err
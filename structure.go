@@ -27,6 +27,14 @@ var (
 // provided in a dataset's structure, and then by the natural comparibilty of
 // the datasets
 type Structure struct {
+	// Archive is the format of an archive the body is bundled in, one of "zip"
+	// or "tar". Archived bodies are read from the ArchiveMember file of the
+	// archive. Compression applies to the archive as a whole, as with .tar.gz
+	// files. Archived bodies can be read, but not written
+	Archive string `json:"archive,omitempty"`
+	// ArchiveMember is the slash-separated path of the body file within an
+	// archive
+	ArchiveMember string `json:"archiveMember,omitempty"`
 	// Checksum is a bas58-encoded multihash checksum of the entire data
 	// file this structure points to. This is different from IPFS
 	// hashes, which are calculated after breaking the file into blocks
@@ -35,11 +43,17 @@ type Structure struct {
 	// Compression specifies any compression on the source data,
 	// if empty assume no compression
 	Compression string `json:"compression,omitempty"`
+	// CompressionConfig tunes the compressor used when writing compressed data,
+	// and may reference a dictionary needed to read it. See CompressionOptions
+	// for supported settings
+	CompressionConfig map[string]interface{} `json:"compressionConfig,omitempty"`
 	// Maximum nesting level of composite types in the dataset.
 	// eg: depth 1 == [], depth 2 == [[]]
 	// derived
 	Depth int `json:"depth,omitempty"`
-	// Encoding specifics character encoding, assume utf-8 if not specified
+	// Encoding specifics character encoding, assume utf-8 if not specified.
+	// text-based formats are transcoded to & from utf-8 when reading & writing.
+	// supported encodings are utf-8, latin-1, windows-1252, utf-16 & shift_jis
 	Encoding string `json:"encoding,omitempty"`
 	// ErrCount is the number of errors returned by validating data
 	// against this schema. required
@@ -146,7 +160,10 @@ func (s *Structure) AddFileFormatExtensions(filename string) string {
 // RequiresTabularSchema returns true if the structure's specified data format
 // requires a JSON schema that describes a rectangular data shape
 func (s *Structure) RequiresTabularSchema() bool {
-	return s.Format == CSVDataFormat.String() || s.Format == XLSXDataFormat.String()
+	df := s.DataFormat()
+	dataFormatsLk.RLock()
+	defer dataFormatsLk.RUnlock()
+	return dataFormats[df].Tabular
 }
 
 // Abstract returns this structure instance in it's "Abstract" form
@@ -209,19 +226,22 @@ func (s Structure) MarshalJSONObject() ([]byte, error) {
 	}
 
 	return json.Marshal(&_structure{
-		Checksum:     s.Checksum,
-		Compression:  s.Compression,
-		Depth:        s.Depth,
-		Encoding:     s.Encoding,
-		Entries:      s.Entries,
-		ErrCount:     s.ErrCount,
-		Format:       s.Format,
-		FormatConfig: opt,
-		Length:       s.Length,
-		Path:         s.Path,
-		Qri:          kind,
-		Schema:       s.Schema,
-		Strict:       s.Strict,
+		Archive:           s.Archive,
+		ArchiveMember:     s.ArchiveMember,
+		Checksum:          s.Checksum,
+		Compression:       s.Compression,
+		CompressionConfig: s.CompressionConfig,
+		Depth:             s.Depth,
+		Encoding:          s.Encoding,
+		Entries:           s.Entries,
+		ErrCount:          s.ErrCount,
+		Format:            s.Format,
+		FormatConfig:      opt,
+		Length:            s.Length,
+		Path:              s.Path,
+		Qri:               kind,
+		Schema:            s.Schema,
+		Strict:            s.Strict,
 	})
 }
 
@@ -245,8 +265,11 @@ func (s *Structure) UnmarshalJSON(data []byte) (err error) {
 
 // IsEmpty checks to see if structure has any fields other than the internal path
 func (s *Structure) IsEmpty() bool {
-	return s.Checksum == "" &&
+	return s.Archive == "" &&
+		s.ArchiveMember == "" &&
+		s.Checksum == "" &&
 		s.Compression == "" &&
+		s.CompressionConfig == nil &&
 		s.Depth == 0 &&
 		s.Encoding == "" &&
 		s.Entries == 0 &&
@@ -269,12 +292,21 @@ func (s *Structure) Assign(structures ...*Structure) {
 		if st.Path != "" {
 			s.Path = st.Path
 		}
+		if st.Archive != "" {
+			s.Archive = st.Archive
+		}
+		if st.ArchiveMember != "" {
+			s.ArchiveMember = st.ArchiveMember
+		}
 		if st.Checksum != "" {
 			s.Checksum = st.Checksum
 		}
 		if st.Compression != "" {
 			s.Compression = st.Compression
 		}
+		if st.CompressionConfig != nil {
+			s.CompressionConfig = st.CompressionConfig
+		}
 		if st.Depth != 0 {
 			s.Depth = st.Depth
 		}
@@ -102,8 +102,12 @@ func TestStructureBodyFilename(t *testing.T) {
 
 func TestStructureRequiresTabularSchema(t *testing.T) {
 	tabularFormats := map[string]struct{}{
-		CSVDataFormat.String():  struct{}{},
-		XLSXDataFormat.String(): struct{}{},
+		CSVDataFormat.String():     struct{}{},
+		XLSXDataFormat.String():    struct{}{},
+		ParquetDataFormat.String(): struct{}{},
+		ArrowDataFormat.String():   struct{}{},
+		AvroDataFormat.String():    struct{}{},
+		SQLiteDataFormat.String():  struct{}{},
 	}
 
 	for _, f := range SupportedDataFormats() {
@@ -135,8 +139,11 @@ func TestStructureIsEmpty(t *testing.T) {
 	cases := []struct {
 		st *Structure
 	}{
+		{&Structure{Archive: "zip"}},
+		{&Structure{ArchiveMember: "data/body.csv"}},
 		{&Structure{Checksum: "a"}},
 		{&Structure{Compression: compression.FmtZStandard.String()}},
+		{&Structure{CompressionConfig: map[string]interface{}{}}},
 		{&Structure{Depth: 1}},
 		{&Structure{Encoding: "a"}},
 		{&Structure{Entries: 1}},
@@ -158,15 +165,16 @@ func TestStructureIsEmpty(t *testing.T) {
 
 func TestStructureAssign(t *testing.T) {
 	expect := &Structure{
-		Length:      2503,
-		Checksum:    "hey",
-		Compression: compression.FmtZStandard.String(),
-		Depth:       11,
-		ErrCount:    12,
-		Encoding:    "UTF-8",
-		Entries:     3000000000,
-		Format:      "csv",
-		Strict:      true,
+		Length:            2503,
+		Checksum:          "hey",
+		Compression:       compression.FmtZStandard.String(),
+		CompressionConfig: map[string]interface{}{"level": 19},
+		Depth:             11,
+		ErrCount:          12,
+		Encoding:          "UTF-8",
+		Entries:           3000000000,
+		Format:            "csv",
+		Strict:            true,
 	}
 	got := &Structure{
 		Length: 2000,
@@ -174,15 +182,16 @@ func TestStructureAssign(t *testing.T) {
 	}
 
 	got.Assign(&Structure{
-		Length:      2503,
-		Checksum:    "hey",
-		Compression: compression.FmtZStandard.String(),
-		Depth:       11,
-		ErrCount:    12,
-		Encoding:    "UTF-8",
-		Entries:     3000000000,
-		Format:      "csv",
-		Strict:      true,
+		Length:            2503,
+		Checksum:          "hey",
+		Compression:       compression.FmtZStandard.String(),
+		CompressionConfig: map[string]interface{}{"level": 19},
+		Depth:             11,
+		ErrCount:          12,
+		Encoding:          "UTF-8",
+		Entries:           3000000000,
+		Format:            "csv",
+		Strict:            true,
 	})
 
 	if diff := compareStructures(expect, got); diff != "" {
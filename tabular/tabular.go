@@ -37,6 +37,46 @@ func (cols Columns) Titles() []string {
 	return titles
 }
 
+// JSONSchema gives a schema for a table with these columns: an array of rows,
+// each row an array of column values. It's the inverse of
+// ColumnsFromJSONSchema
+func (cols Columns) JSONSchema() map[string]interface{} {
+	items := make([]interface{}, len(cols))
+	for i, col := range cols {
+		items[i] = col.JSONSchema()
+	}
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}
+}
+
+// Merge gives the columns of cols followed by the columns of other. Titles of
+// other that collide with an earlier title get the lowest numeric suffix that
+// makes them unique, so merging "id" with "id" gives "id" and "id_2"
+func (cols Columns) Merge(other Columns) Columns {
+	merged := make(Columns, 0, len(cols)+len(other))
+	titles := map[string]bool{}
+	for _, col := range cols {
+		merged = append(merged, col)
+		titles[col.Title] = true
+	}
+	for _, col := range other {
+		if titles[col.Title] {
+			base := col.Title
+			for i := 2; titles[col.Title]; i++ {
+				col.Title = fmt.Sprintf("%s_%d", base, i)
+			}
+		}
+		merged = append(merged, col)
+		titles[col.Title] = true
+	}
+	return merged
+}
+
 var validMachineTitle = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z_$0-9]*$`)
 
 // ValidMachineTitles confirms column titles are valid for machine-readability
@@ -72,6 +112,32 @@ type Column struct {
 	Validation  map[string]interface{} `json:"validation,omitempty"`
 }
 
+// JSONSchema gives the schema of a column's values
+func (col Column) JSONSchema() map[string]interface{} {
+	sch := map[string]interface{}{}
+	for key, val := range col.Validation {
+		sch[key] = val
+	}
+	sch["title"] = col.Title
+	if col.Type != nil {
+		switch len(*col.Type) {
+		case 0:
+		case 1:
+			sch["type"] = (*col.Type)[0]
+		default:
+			types := make([]interface{}, len(*col.Type))
+			for i, t := range *col.Type {
+				types[i] = t
+			}
+			sch["type"] = types
+		}
+	}
+	if col.Description != "" {
+		sch["description"] = col.Description
+	}
+	return sch
+}
+
 // ColType implements type information for a tabular column. Column Types can
 // be one or more strings enumerating accepted types
 type ColType []string
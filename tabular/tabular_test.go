@@ -158,6 +158,64 @@ func TestColumnsJSON(t *testing.T) {
 	}
 }
 
+func TestColumnsJSONSchema(t *testing.T) {
+	cols := Columns{
+		{Title: "name", Type: &ColType{"string"}, Description: "who"},
+		{Title: "rating", Type: &ColType{"number", "null"}, Validation: map[string]interface{}{"max": float64(5)}},
+		{Title: "notes"},
+	}
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string", "description": "who"},
+				map[string]interface{}{"title": "rating", "type": []interface{}{"number", "null"}, "max": float64(5)},
+				map[string]interface{}{"title": "notes"},
+			},
+		},
+	}
+	sch := cols.JSONSchema()
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	got, _, err := ColumnsFromJSONSchema(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(cols.Titles(), got.Titles()); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestColumnsMerge(t *testing.T) {
+	left := Columns{
+		{Title: "id", Type: &ColType{"integer"}},
+		{Title: "name", Type: &ColType{"string"}},
+		{Title: "id_2", Type: &ColType{"string"}},
+	}
+	right := Columns{
+		{Title: "id", Type: &ColType{"string"}},
+		{Title: "total", Type: &ColType{"number"}},
+		{Title: "name"},
+	}
+	expect := Columns{
+		{Title: "id", Type: &ColType{"integer"}},
+		{Title: "name", Type: &ColType{"string"}},
+		{Title: "id_2", Type: &ColType{"string"}},
+		{Title: "id_3", Type: &ColType{"string"}},
+		{Title: "total", Type: &ColType{"number"}},
+		{Title: "name_2"},
+	}
+	if diff := cmp.Diff(expect, left.Merge(right)); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"id", "name", "id_2"}, left.Titles()); diff != "" {
+		t.Errorf("expected merge to leave columns unchanged (-want +got):\n%s", diff)
+	}
+}
+
 func TestColumnsTitles(t *testing.T) {
 	cols := Columns{
 		Column{Title: "foo"},
@@ -35,7 +35,13 @@ func flushBatch(ctx context.Context, buf *dsio.EntryBuffer, st *dataset.Structur
 // TODO - refactor this to wrap a reader & return a struct that gives an
 // error or nil on each entry read.
 func EntryReader(r dsio.EntryReader) ([]jsonschema.KeyError, error) {
-	ctx := context.Background()
+	return EntryReaderContext(context.Background(), r)
+}
+
+// EntryReaderContext is EntryReader with a context. Validation stops with the
+// context's error when the context is cancelled
+func EntryReaderContext(ctx context.Context, r dsio.EntryReader) ([]jsonschema.KeyError, error) {
+	r = dsio.NewContextReader(ctx, r)
 	st := r.Structure()
 
 	jsch, err := st.JSONSchema()
@@ -82,6 +88,9 @@ func EntryReader(r dsio.EntryReader) ([]jsonschema.KeyError, error) {
 	})
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, fmt.Errorf("error reading values: %s", err.Error())
 	}
 
@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -53,3 +54,20 @@ func TestEntryReader(t *testing.T) {
 		}
 	}
 }
+
+func TestEntryReaderContext(t *testing.T) {
+	tc, err := dstest.NewTestCaseFromDir("testdata/movies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := dsio.NewEntryReader(tc.Input.Structure, tc.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := EntryReaderContext(ctx, r); err != context.Canceled {
+		t.Errorf("expected context.Canceled. got: %v", err)
+	}
+}
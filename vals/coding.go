@@ -3,6 +3,8 @@ package vals
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"time"
 )
 
 // ConvertDecoded converts an interface that has been decoded into standard go types to a Value
@@ -32,6 +34,29 @@ func ConvertDecoded(d interface{}) (Value, error) {
 		return String(v), nil
 	case bool:
 		return Boolean(v), nil
+	// arbitrary-precision numbers are converted to integer & number values,
+	// which may lose precision
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return Integer(int(i)), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %q", v)
+		}
+		return Number(f), nil
+	case *big.Int:
+		if v.IsInt64() {
+			return Integer(int(v.Int64())), nil
+		}
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return Number(f), nil
+	case *big.Float:
+		f, _ := v.Float64()
+		return Number(f), nil
+	// times have no value type of their own, and are kept as strings
+	case time.Time:
+		return String(v.Format(time.RFC3339Nano)), nil
 	case []interface{}:
 		arr := make(Array, len(v))
 		for i, val := range v {
@@ -3,7 +3,9 @@ package vals
 import (
 	"bytes"
 	"encoding/json"
+	"math/big"
 	"testing"
+	"time"
 )
 
 var (
@@ -49,6 +51,21 @@ func TestConvertDecoded(t *testing.T) {
 			"l": Integer(0),
 			"m": &Object{},
 		}, ""},
+		{[]interface{}{
+			json.Number("12"),
+			json.Number("1.5"),
+			big.NewInt(7),
+			new(big.Int).Lsh(big.NewInt(1), 70),
+			big.NewFloat(0.25),
+		}, &Array{
+			Integer(12),
+			Number(1.5),
+			Integer(7),
+			Number(1 << 70),
+			Number(0.25),
+		}, ""},
+		{time.Date(2013, 3, 21, 20, 4, 0, 500, time.UTC), String("2013-03-21T20:04:00.0000005Z"), ""},
+		{json.Number("twelve"), nil, `invalid number: "twelve"`},
 	}
 
 	for i, c := range cases {
@@ -57,6 +74,9 @@ func TestConvertDecoded(t *testing.T) {
 			t.Errorf("case %d error mismatch. expected: %s, got: %s", i, c.err, err)
 			continue
 		}
+		if c.err != "" {
+			continue
+		}
 
 		if !Equal(c.expect, got) {
 			t.Errorf("case %d result mismatch. epxected: %#v, got: %#v", i, c.expect, got)
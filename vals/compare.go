@@ -28,9 +28,10 @@ func Equal(a, b Value) bool {
 	return false
 }
 
-// CompareTypeBytes compares two byte slices with a known type
-// real on the real, this is a bit of a work in progress
-// TODO - up tests
+// CompareTypeBytes compares two byte slices with a known type. Empty slices
+// sort before all other values. false sorts before true, and objects, arrays
+// & bytes are compared bytewise, which orders canonical JSON encodings
+// consistently
 func CompareTypeBytes(a, b []byte, t Type) (int, error) {
 	if len(a) == 0 && len(b) > 0 {
 		return -1, nil
@@ -47,6 +48,12 @@ func CompareTypeBytes(a, b []byte, t Type) (int, error) {
 		return CompareIntegerBytes(a, b)
 	case TypeNumber:
 		return CompareNumberBytes(a, b)
+	case TypeBoolean:
+		return CompareBooleanBytes(a, b)
+	case TypeNull:
+		return 0, nil
+	case TypeObject, TypeArray, TypeBytes:
+		return bytes.Compare(a, b), nil
 	default:
 		// TODO - other types
 		return 0, fmt.Errorf("invalid type comparison")
@@ -88,3 +95,22 @@ func CompareNumberBytes(a, b []byte) (int, error) {
 	}
 	return -1, nil
 }
+
+// CompareBooleanBytes compares two byte slices of boolean data
+func CompareBooleanBytes(a, b []byte) (int, error) {
+	at, err := ParseBoolean(a)
+	if err != nil {
+		return 0, err
+	}
+	bt, err := ParseBoolean(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case at == bt:
+		return 0, nil
+	case bt:
+		return -1, nil
+	}
+	return 1, nil
+}
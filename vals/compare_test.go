@@ -46,6 +46,13 @@ func TestCompareTypeBytes(t *testing.T) {
 		{"bar", "foo", TypeString, -1, ""},
 		{"0", "0", TypeNumber, 0, ""},
 		{"0", "0", TypeInteger, 0, ""},
+		{"false", "true", TypeBoolean, -1, ""},
+		{"true", "true", TypeBoolean, 0, ""},
+		{"true", "false", TypeBoolean, 1, ""},
+		{"yes", "false", TypeBoolean, 0, `strconv.ParseBool: parsing "yes": invalid syntax`},
+		{"null", "null", TypeNull, 0, ""},
+		{`{"a":1}`, `{"b":0}`, TypeObject, -1, ""},
+		{`[2]`, `[10]`, TypeArray, 1, ""},
 	}
 
 	for i, c := range cases {